@@ -8,6 +8,49 @@ type Config struct {
 	DatabaseDSN string
 	JWTSecret   string
 	ServerPort  string
+
+	// Timbangan yang terpasang langsung di server (opsional)
+	ScaleDriver         string // serial, simulated, atau kosong
+	ScaleDevice         string // contoh: /dev/ttyUSB0
+	ScaleRequestCommand string // contoh: Q\r\n untuk timbangan yang mencetak saat diminta
+
+	// Interval job saran pengisian ulang stok toko (contoh: 24h), kosong = hanya manual
+	ReplenishmentInterval string
+
+	// Penyimpanan foto produk dan stok
+	ImageStoreDriver string // local (default)
+	ImageStoreDir    string // folder untuk driver local
+	ImageBaseURL     string // URL publik folder tersebut
+
+	// Feed harga emas 24K eksternal (opsional)
+	PriceFeedProvider         string // http, file, atau kosong
+	PriceFeedSource           string // URL endpoint JSON atau path file
+	PriceFeedBuyField         string // path field harga beli, contoh: data.buy
+	PriceFeedSellField        string // path field harga jual, contoh: data.sell
+	PriceFeedInterval         string // contoh: 1h, kosong = hanya manual
+	PriceFeedAutoApply        bool   // terapkan otomatis jika perubahan di bawah batas
+	PriceFeedMaxChangePercent string // batas perubahan (%) tanpa persetujuan
+	PriceFeedUser             string // username yang tercatat pada update otomatis
+
+	// Interval worker harga terjadwal (contoh: 1m)
+	PriceScheduleInterval string
+
+	// Toleransi harga setor terhadap harga beli acuan (%)
+	SetorPriceTolerance     string // selisih yang diterima langsung
+	SetorPriceApprovalLimit string // selisih maksimal dengan persetujuan supervisor
+
+	// Batas susut produksi (%) tanpa persetujuan supervisor
+	ProductionMaxYieldLoss string
+
+	// Penawaran harga
+	QuotationValidity       string // masa berlaku default, contoh: 1h
+	QuotationMaxValidity    string // masa berlaku maksimal yang boleh diminta, contoh: 24h
+	QuotationExpiryInterval string // interval pelepasan penawaran kedaluwarsa, contoh: 1m
+
+	// Poin member
+	MemberPointValue          string // nilai rupiah satu poin saat ditukar, 0 = penukaran nonaktif
+	MemberPointExpiryMonths   string // masa berlaku poin dalam bulan, 0 = tidak kedaluwarsa
+	MemberPointExpiryInterval string // interval job kedaluwarsa poin, contoh: 24h
 }
 
 func Load() *Config {
@@ -15,6 +58,40 @@ func Load() *Config {
 		DatabaseDSN: getEnv("DATABASE_DSN", "host=localhost user=starter password=starter123 dbname=starter port=5434 sslmode=disable"),
 		JWTSecret:   getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
 		ServerPort:  getEnv("SERVER_PORT", "8080"),
+
+		ScaleDriver:         getEnv("SCALE_DRIVER", ""),
+		ScaleDevice:         getEnv("SCALE_DEVICE", ""),
+		ScaleRequestCommand: getEnv("SCALE_REQUEST_COMMAND", ""),
+
+		ReplenishmentInterval: getEnv("REPLENISHMENT_INTERVAL", ""),
+
+		ImageStoreDriver: getEnv("IMAGE_STORE_DRIVER", "local"),
+		ImageStoreDir:    getEnv("IMAGE_STORE_DIR", "./uploads"),
+		ImageBaseURL:     getEnv("IMAGE_BASE_URL", "/uploads"),
+
+		PriceFeedProvider:         getEnv("PRICE_FEED_PROVIDER", ""),
+		PriceFeedSource:           getEnv("PRICE_FEED_SOURCE", ""),
+		PriceFeedBuyField:         getEnv("PRICE_FEED_BUY_FIELD", "buy"),
+		PriceFeedSellField:        getEnv("PRICE_FEED_SELL_FIELD", "sell"),
+		PriceFeedInterval:         getEnv("PRICE_FEED_INTERVAL", ""),
+		PriceFeedAutoApply:        getEnv("PRICE_FEED_AUTO_APPLY", "false") == "true",
+		PriceFeedMaxChangePercent: getEnv("PRICE_FEED_MAX_CHANGE_PERCENT", "2"),
+		PriceFeedUser:             getEnv("PRICE_FEED_USER", "admin"),
+
+		PriceScheduleInterval: getEnv("PRICE_SCHEDULE_INTERVAL", "1m"),
+
+		SetorPriceTolerance:     getEnv("SETOR_PRICE_TOLERANCE_PERCENT", "1"),
+		SetorPriceApprovalLimit: getEnv("SETOR_PRICE_APPROVAL_LIMIT_PERCENT", "5"),
+
+		ProductionMaxYieldLoss: getEnv("PRODUCTION_MAX_YIELD_LOSS_PERCENT", "3"),
+
+		QuotationValidity:       getEnv("QUOTATION_VALIDITY", "1h"),
+		QuotationMaxValidity:    getEnv("QUOTATION_MAX_VALIDITY", "24h"),
+		QuotationExpiryInterval: getEnv("QUOTATION_EXPIRY_INTERVAL", "1m"),
+
+		MemberPointValue:          getEnv("MEMBER_POINT_VALUE", "1000"),
+		MemberPointExpiryMonths:   getEnv("MEMBER_POINT_EXPIRY_MONTHS", "12"),
+		MemberPointExpiryInterval: getEnv("MEMBER_POINT_EXPIRY_INTERVAL", "24h"),
 	}
 }
 
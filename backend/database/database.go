@@ -34,21 +34,54 @@ func Migrate() error {
 		&models.User{},           // Then users (depends on roles)
 		&models.Setting{},        // Settings
 		// POS Models
-		&models.GoldCategory{},    // Gold categories
-		&models.Product{},         // Products
-		&models.Location{},        // Locations (gudang/toko)
-		&models.StorageBox{},      // Storage boxes
-		&models.UserLocation{},    // User-Location assignments (employee to store)
-		&models.Member{},          // Members
-		&models.Stock{},           // Stock
-		&models.StockTransfer{},   // Stock transfers
-		&models.RawMaterial{},     // Raw materials
-		&models.Transaction{},     // Transactions
-		&models.TransactionItem{}, // Transaction items
-		&models.PurchaseItem{},    // Purchase items
+		&models.GoldCategory{},            // Gold categories
+		&models.Product{},                 // Products
+		&models.Location{},                // Locations (gudang/toko)
+		&models.StorageBox{},              // Storage boxes
+		&models.UserLocation{},            // User-Location assignments (employee to store)
+		&models.Member{},                  // Members
+		&models.MemberTier{},              // Membership tiers (threshold and discount)
+		&models.MemberPointRule{},         // Point earn rates per transaction type
+		&models.MemberPointEntry{},        // Append-only member point ledger
+		&models.MemberMerge{},             // Audit of duplicate members merged
+		&models.Supplier{},                // Suppliers
+		&models.GoodsReceipt{},            // Goods receipts (penerimaan barang)
+		&models.GoodsReceiptItem{},        // Goods receipt lines
+		&models.ScaleReading{},            // Readings pushed by workstation scales
+		&models.Stock{},                   // Stock
+		&models.StockTransfer{},           // Stock transfers
+		&models.ReplenishmentRule{},       // Min/max stock rules per store segment
+		&models.ReplenishmentSuggestion{}, // Suggested gudang→toko transfers
+		&models.RawMaterial{},             // Raw materials
+		&models.StockWriteOff{},           // Stock write-offs (hilang, dicuri, rusak, lebur)
+		&models.RefineryShipment{},        // Refinery send-outs and settlements
+		&models.MeltBatch{},               // Melt batches (lebur)
+		&models.MeltBatchItem{},           // Raw materials in a melt batch
+		&models.ProductionOrder{},         // Production orders (pengrajin)
+		&models.ProductionInput{},         // Raw materials consumed by production
+		&models.ProductionOutput{},        // Finished pieces from production
+		&models.FineGoldMovement{},        // Fine-gold (emas murni) ledger
+		&models.Transaction{},             // Transactions
+		&models.TransactionItem{},         // Transaction items
+		&models.Quotation{},               // Price quotations holding stock pieces
+		&models.QuotationItem{},           // Quoted pieces with locked prices
+		&models.PurchaseItem{},            // Purchase items
+		&models.SetorConditionRule{},      // Setor price deduction per condition
+		&models.LabelTemplate{},           // Label layouts
+		&models.LabelJob{},                // Label print jobs
+		&models.LabelPrinter{},            // Network label printers registered by admins
+		// Product types master and attribute schema
+		&models.ProductTypeDefinition{},      // Product types (gelang, cincin, bros, ...)
+		&models.ProductAttributeDefinition{}, // Attribute schema per product type
+		&models.ProductAttributeValue{},      // Attribute values per product
+		&models.StoneComponent{},             // Gemstones on products, pieces, setor lines and raw materials
+		&models.ProductImage{},               // Photos of products and pieces
 		// Price Update Tracking
-		&models.PriceUpdateLog{}, // Price update logs
-		&models.PriceDetail{},    // Price update details
+		&models.PriceUpdateLog{},       // Price update logs
+		&models.PriceDetail{},          // Price update details
+		&models.PriceProposal{},        // Price updates proposed by the price feed
+		&models.ScheduledPriceUpdate{}, // Effective-dated price updates
+		&models.LocationPrice{},        // Price overrides per location
 	)
 
 	if err != nil {
@@ -59,11 +92,24 @@ func Migrate() error {
 	// Harga sekarang selalu dihitung dari gold_category
 	removeDeprecatedStockColumns()
 
+	// Fill hierarchy paths for storage boxes created before the storage tree existed
+	backfillStorageBoxPaths()
+
+	// Open the point ledger of members that had points before the ledger existed
+	backfillMemberPointLedger()
+
 	// Create partial unique indexes for soft delete compatibility
 	createPartialUniqueIndexes()
 
 	log.Println("Database migrated successfully")
-	return SeedData()
+	if err := SeedData(); err != nil {
+		return err
+	}
+
+	// Move the old hard-coded spec columns (ring_size, ...) into product attribute values.
+	// Needs the seeded product types, so it runs after SeedData
+	migrateProductSpecColumns()
+	return nil
 }
 
 // dropOldIndexes drops old unique indexes that don't account for soft delete
@@ -195,6 +241,146 @@ func removeDeprecatedStockColumns() {
 	}
 }
 
+// migrateProductSpecColumns copies ring_size, bracelet_length, necklace_length and earring_type
+// into product attribute values of the matching product type. A column is only dropped once every
+// non-empty value has been moved; otherwise it is kept and the leftover count is logged.
+func migrateProductSpecColumns() {
+	specColumns := []struct {
+		column      string
+		productType models.ProductType
+		numeric     bool
+		selectValue bool
+	}{
+		{"ring_size", models.ProductTypeCincin, false, false},
+		{"bracelet_length", models.ProductTypeGelang, true, false},
+		{"necklace_length", models.ProductTypeKalung, true, false},
+		{"earring_type", models.ProductTypeAnting, false, true},
+	}
+
+	for _, spec := range specColumns {
+		var count int64
+		DB.Raw(`
+			SELECT COUNT(*) FROM information_schema.columns 
+			WHERE table_name = 'products' AND column_name = ?
+		`, spec.column).Scan(&count)
+		if count == 0 {
+			continue
+		}
+
+		// Nilai kosong/0 tidak dipindahkan
+		valueExpr := "TRIM(p." + spec.column + ")"
+		numberExpr := "NULL"
+		hasValue := "COALESCE(TRIM(p." + spec.column + "), '') <> ''"
+		validValue := "TRUE"
+		if spec.numeric {
+			valueExpr = "p." + spec.column + "::text"
+			numberExpr = "p." + spec.column
+			hasValue = "COALESCE(p." + spec.column + ", 0) > 0"
+		}
+		if spec.selectValue {
+			// Teks bebas dipetakan ke pilihan atribut; yang tidak cocok tidak dipindahkan
+			valueExpr = `CASE LOWER(TRIM(p.` + spec.column + `))
+				WHEN 'stud' THEN 'tusuk'
+				WHEN 'clip' THEN 'jepit'
+				WHEN 'klip' THEN 'jepit'
+				WHEN 'drop' THEN 'gantung'
+				WHEN 'dangle' THEN 'gantung'
+				WHEN 'ring' THEN 'hoop'
+				WHEN 'other' THEN 'lainnya'
+				ELSE LOWER(TRIM(p.` + spec.column + `)) END`
+			validValue = "jsonb_exists(pad.allowed_values::jsonb, " + valueExpr + ")"
+		}
+
+		result := DB.Exec(`
+			INSERT INTO product_attribute_values (created_at, updated_at, product_id, attribute_id, code, value, number_value, unit)
+			SELECT NOW(), NOW(), p.id, pad.id, pad.code, `+valueExpr+`, `+numberExpr+`, pad.unit
+			FROM products p
+			JOIN product_types pt ON pt.code = ? AND pt.deleted_at IS NULL
+			JOIN product_attribute_definitions pad ON pad.product_type_id = pt.id AND pad.code = ? AND pad.deleted_at IS NULL
+			WHERE p.type = pt.code AND `+hasValue+` AND `+validValue+`
+			AND NOT EXISTS (
+				SELECT 1 FROM product_attribute_values pav
+				WHERE pav.product_id = p.id AND pav.attribute_id = pad.id AND pav.deleted_at IS NULL
+			)
+		`, spec.productType, spec.column)
+		if result.Error != nil {
+			log.Printf("Warning: Failed to migrate products.%s into attributes: %v", spec.column, result.Error)
+			continue
+		}
+		log.Printf("Migrated %d values of products.%s into attributes", result.RowsAffected, spec.column)
+
+		// Nilai pada produk tipe lain atau pilihan yang tidak valid belum pindah: kolom jangan dihapus
+		var remaining int64
+		if err := DB.Raw(`
+			SELECT COUNT(*) FROM products p
+			WHERE `+hasValue+`
+			AND NOT EXISTS (
+				SELECT 1 FROM product_attribute_values pav
+				JOIN product_attribute_definitions pad ON pad.id = pav.attribute_id
+				WHERE pav.product_id = p.id AND pad.code = ? AND pav.deleted_at IS NULL
+			)
+		`, spec.column).Scan(&remaining).Error; err != nil {
+			log.Printf("Warning: Failed to check leftover values of products.%s: %v", spec.column, err)
+			continue
+		}
+		if remaining > 0 {
+			log.Printf("Warning: Keeping products.%s, %d values could not be migrated (other product type or invalid value); move them to attributes manually", spec.column, remaining)
+			continue
+		}
+
+		if err := DB.Exec("ALTER TABLE products DROP COLUMN IF EXISTS " + spec.column).Error; err != nil {
+			log.Printf("Warning: Failed to drop column %s from products: %v", spec.column, err)
+		} else {
+			log.Printf("Dropped deprecated column: products.%s", spec.column)
+		}
+	}
+}
+
+// backfillStorageBoxPaths sets path, path_code and depth for storage boxes that don't have them yet.
+// Boxes from the old flat layout become top level nodes directly under their location.
+func backfillStorageBoxPaths() {
+	var count int64
+	DB.Model(&models.StorageBox{}).Where("path IS NULL OR path = ''").Count(&count)
+	if count == 0 {
+		return
+	}
+
+	result := DB.Exec(`
+		UPDATE storage_boxes sb
+		SET path = '/' || sb.id || '/',
+			path_code = l.code || '/' || sb.code,
+			depth = 0
+		FROM locations l
+		WHERE l.id = sb.location_id
+		AND sb.parent_id IS NULL
+		AND (sb.path IS NULL OR sb.path = '')
+	`)
+	if result.Error != nil {
+		log.Printf("Warning: Failed to backfill storage box paths: %v", result.Error)
+		return
+	}
+	log.Printf("Backfilled path for %d storage boxes", result.RowsAffected)
+}
+
+// backfillMemberPointLedger writes an opening adjust entry for members with points but no ledger entries.
+// Saldo awal tidak kedaluwarsa karena tanggal perolehannya tidak diketahui.
+func backfillMemberPointLedger() {
+	result := DB.Exec(`
+		INSERT INTO member_point_entries (created_at, member_id, type, points, balance, notes)
+		SELECT NOW(), m.id, ?, m.points, m.points, 'Saldo awal'
+		FROM members m
+		WHERE m.points <> 0
+		AND NOT EXISTS (SELECT 1 FROM member_point_entries e WHERE e.member_id = m.id)
+	`, models.MemberPointAdjust)
+	if result.Error != nil {
+		log.Printf("Warning: Failed to backfill member point ledger: %v", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Opened point ledger for %d members", result.RowsAffected)
+	}
+}
+
 // createPartialUniqueIndexes creates partial unique indexes that only apply to non-deleted records
 func createPartialUniqueIndexes() {
 	// PostgreSQL partial unique indexes for soft delete compatibility
@@ -211,13 +397,29 @@ func createPartialUniqueIndexes() {
 		{"idx_users_username_partial", `CREATE UNIQUE INDEX idx_users_username_partial ON users(username) WHERE deleted_at IS NULL`},
 		{"idx_gold_categories_code_partial", `CREATE UNIQUE INDEX idx_gold_categories_code_partial ON gold_categories(code) WHERE deleted_at IS NULL`},
 		{"idx_products_barcode_partial", `CREATE UNIQUE INDEX idx_products_barcode_partial ON products(barcode) WHERE deleted_at IS NULL`},
+		{"idx_product_types_code_partial", `CREATE UNIQUE INDEX idx_product_types_code_partial ON product_types(code) WHERE deleted_at IS NULL`},
+		{"idx_product_attribute_definitions_type_code_partial", `CREATE UNIQUE INDEX idx_product_attribute_definitions_type_code_partial ON product_attribute_definitions(product_type_id, code) WHERE deleted_at IS NULL`},
 		{"idx_locations_code_partial", `CREATE UNIQUE INDEX idx_locations_code_partial ON locations(code) WHERE deleted_at IS NULL`},
 		{"idx_members_member_code_partial", `CREATE UNIQUE INDEX idx_members_member_code_partial ON members(member_code) WHERE deleted_at IS NULL`},
 		{"idx_stocks_serial_number_partial", `CREATE UNIQUE INDEX idx_stocks_serial_number_partial ON stocks(serial_number) WHERE deleted_at IS NULL`},
+		{"idx_stocks_certificate_partial", `CREATE UNIQUE INDEX idx_stocks_certificate_partial ON stocks(manufacturer, certificate_number) WHERE deleted_at IS NULL AND certificate_number <> '' AND status <> 'sold'`},
 		{"idx_stock_transfers_transfer_number_partial", `CREATE UNIQUE INDEX idx_stock_transfers_transfer_number_partial ON stock_transfers(transfer_number) WHERE deleted_at IS NULL`},
 		{"idx_raw_materials_code_partial", `CREATE UNIQUE INDEX idx_raw_materials_code_partial ON raw_materials(code) WHERE deleted_at IS NULL`},
 		{"idx_transactions_transaction_code_partial", `CREATE UNIQUE INDEX idx_transactions_transaction_code_partial ON transactions(transaction_code) WHERE deleted_at IS NULL`},
 		{"idx_user_locations_user_location_partial", `CREATE UNIQUE INDEX idx_user_locations_user_location_partial ON user_locations(user_id, location_id) WHERE deleted_at IS NULL`},
+		{"idx_suppliers_code_partial", `CREATE UNIQUE INDEX idx_suppliers_code_partial ON suppliers(code) WHERE deleted_at IS NULL`},
+		{"idx_goods_receipts_receipt_number_partial", `CREATE UNIQUE INDEX idx_goods_receipts_receipt_number_partial ON goods_receipts(receipt_number) WHERE deleted_at IS NULL`},
+		{"idx_stock_write_offs_write_off_number_partial", `CREATE UNIQUE INDEX idx_stock_write_offs_write_off_number_partial ON stock_write_offs(write_off_number) WHERE deleted_at IS NULL`},
+		{"idx_melt_batches_batch_number_partial", `CREATE UNIQUE INDEX idx_melt_batches_batch_number_partial ON melt_batches(batch_number) WHERE deleted_at IS NULL`},
+		{"idx_refinery_shipments_shipment_number_partial", `CREATE UNIQUE INDEX idx_refinery_shipments_shipment_number_partial ON refinery_shipments(shipment_number) WHERE deleted_at IS NULL`},
+		{"idx_production_orders_order_number_partial", `CREATE UNIQUE INDEX idx_production_orders_order_number_partial ON production_orders(order_number) WHERE deleted_at IS NULL`},
+		{"idx_label_jobs_job_number_partial", `CREATE UNIQUE INDEX idx_label_jobs_job_number_partial ON label_jobs(job_number) WHERE deleted_at IS NULL`},
+		{"idx_storage_boxes_path_code_partial", `CREATE UNIQUE INDEX idx_storage_boxes_path_code_partial ON storage_boxes(path_code) WHERE deleted_at IS NULL AND path_code <> ''`},
+		{"idx_member_tiers_type_partial", `CREATE UNIQUE INDEX idx_member_tiers_type_partial ON member_tiers(type) WHERE deleted_at IS NULL`},
+		{"idx_member_point_rules_transaction_type_partial", `CREATE UNIQUE INDEX idx_member_point_rules_transaction_type_partial ON member_point_rules(transaction_type) WHERE deleted_at IS NULL`},
+		{"idx_quotations_quotation_number_partial", `CREATE UNIQUE INDEX idx_quotations_quotation_number_partial ON quotations(quotation_number) WHERE deleted_at IS NULL`},
+		{"idx_setor_condition_rules_condition_partial", `CREATE UNIQUE INDEX idx_setor_condition_rules_condition_partial ON setor_condition_rules(condition) WHERE deleted_at IS NULL`},
+		{"idx_location_prices_location_category_partial", `CREATE UNIQUE INDEX idx_location_prices_location_category_partial ON location_prices(location_id, gold_category_id) WHERE deleted_at IS NULL`},
 	}
 
 	for _, idx := range partialIndexes {
@@ -294,6 +496,7 @@ func SeedData() error {
 		{Name: "products.create", Module: "Master Data", Category: "Products", Description: "Create new products", Actions: `["create"]`},
 		{Name: "products.update", Module: "Master Data", Category: "Products", Description: "Update existing products", Actions: `["update"]`},
 		{Name: "products.delete", Module: "Master Data", Category: "Products", Description: "Delete products", Actions: `["delete"]`},
+		{Name: "product-types.manage", Module: "Master Data", Category: "Products", Description: "Manage product types and their attribute schema", Actions: `["create", "update", "delete"]`},
 
 		// Locations Management (Gudang & Toko)
 		{Name: "locations.view", Module: "Master Data", Category: "Locations", Description: "View locations and storage boxes", Actions: `["read"]`},
@@ -306,6 +509,7 @@ func SeedData() error {
 		{Name: "members.create", Module: "Member Management", Category: "Members", Description: "Create new members", Actions: `["create"]`},
 		{Name: "members.update", Module: "Member Management", Category: "Members", Description: "Update existing members", Actions: `["update"]`},
 		{Name: "members.delete", Module: "Member Management", Category: "Members", Description: "Delete members", Actions: `["delete"]`},
+		{Name: "members.merge", Module: "Member Management", Category: "Members", Description: "Merge duplicate members", Actions: `["update", "delete"]`},
 
 		// Stocks Management
 		{Name: "stocks.view", Module: "Inventory", Category: "Stocks", Description: "View stocks list and details", Actions: `["read"]`},
@@ -314,6 +518,50 @@ func SeedData() error {
 		{Name: "stocks.delete", Module: "Inventory", Category: "Stocks", Description: "Delete stocks", Actions: `["delete"]`},
 		{Name: "stocks.transfer", Module: "Inventory", Category: "Stocks", Description: "Transfer stocks between locations", Actions: `["transfer"]`},
 
+		// Suppliers Management
+		{Name: "suppliers.view", Module: "Master Data", Category: "Suppliers", Description: "View suppliers list and details", Actions: `["read"]`},
+		{Name: "suppliers.create", Module: "Master Data", Category: "Suppliers", Description: "Create new suppliers", Actions: `["create"]`},
+		{Name: "suppliers.update", Module: "Master Data", Category: "Suppliers", Description: "Update existing suppliers", Actions: `["update"]`},
+		{Name: "suppliers.delete", Module: "Master Data", Category: "Suppliers", Description: "Delete suppliers", Actions: `["delete"]`},
+
+		// Goods Receipts (Penerimaan Barang)
+		{Name: "goods-receipts.view", Module: "Inventory", Category: "Goods Receipts", Description: "View goods receipts list and details", Actions: `["read"]`},
+		{Name: "goods-receipts.create", Module: "Inventory", Category: "Goods Receipts", Description: "Create goods receipts from suppliers", Actions: `["create"]`},
+		{Name: "goods-receipts.post", Module: "Inventory", Category: "Goods Receipts", Description: "Post goods receipts into stock", Actions: `["post"]`},
+		{Name: "goods-receipts.cancel", Module: "Inventory", Category: "Goods Receipts", Description: "Cancel goods receipts", Actions: `["cancel"]`},
+
+		// Melting & Refinery (Lebur)
+		{Name: "melt-batches.view", Module: "Inventory", Category: "Melting", Description: "View melt batches and refinery shipments", Actions: `["read"]`},
+		{Name: "melt-batches.create", Module: "Inventory", Category: "Melting", Description: "Create melt batches from raw materials", Actions: `["create"]`},
+		{Name: "melt-batches.process", Module: "Inventory", Category: "Melting", Description: "Record melt results and cancel melt batches", Actions: `["update"]`},
+		{Name: "refinery-shipments.create", Module: "Inventory", Category: "Melting", Description: "Send melt batches to a refinery", Actions: `["create"]`},
+		{Name: "refinery-shipments.settle", Module: "Inventory", Category: "Melting", Description: "Record refinery settlements", Actions: `["update"]`},
+
+		// Production (Produksi)
+		{Name: "production.view", Module: "Inventory", Category: "Production", Description: "View production orders", Actions: `["read"]`},
+		{Name: "production.create", Module: "Inventory", Category: "Production", Description: "Create and cancel production orders", Actions: `["create", "cancel"]`},
+		{Name: "production.complete", Module: "Inventory", Category: "Production", Description: "Complete production orders into stock", Actions: `["update"]`},
+		{Name: "production.approve-yield-loss", Module: "Inventory", Category: "Production", Description: "Approve production yield loss above the limit (supervisor)", Actions: `["approve"]`},
+
+		// Replenishment (Pengisian Ulang Toko)
+		{Name: "replenishment.view", Module: "Inventory", Category: "Replenishment", Description: "View min/max rules and replenishment suggestions", Actions: `["read"]`},
+		{Name: "replenishment.manage", Module: "Inventory", Category: "Replenishment", Description: "Manage min/max rules and run suggestions", Actions: `["create", "update", "delete"]`},
+		{Name: "replenishment.accept", Module: "Inventory", Category: "Replenishment", Description: "Accept or dismiss suggestions into draft transfers", Actions: `["approve"]`},
+
+		// Fine Gold (Emas Murni)
+		{Name: "fine-gold.reconcile", Module: "Inventory", Category: "Fine Gold", Description: "Book fine-gold variances as adjustments", Actions: `["create"]`},
+
+		// Write-offs (Penghapusan Stok)
+		{Name: "write-offs.view", Module: "Inventory", Category: "Write-offs", Description: "View stock write-offs", Actions: `["read"]`},
+		{Name: "write-offs.create", Module: "Inventory", Category: "Write-offs", Description: "Submit stock write-offs (lost, stolen, damaged, melt)", Actions: `["create"]`},
+		{Name: "write-offs.approve", Module: "Inventory", Category: "Write-offs", Description: "Approve or reject stock write-offs", Actions: `["approve", "reject"]`},
+
+		// Labels (Cetak Label Barcode)
+		{Name: "labels.view", Module: "Inventory", Category: "Labels", Description: "View label templates and print jobs", Actions: `["read"]`},
+		{Name: "labels.print", Module: "Inventory", Category: "Labels", Description: "Render and print stock labels", Actions: `["create", "update"]`},
+		{Name: "labels.manage-templates", Module: "Inventory", Category: "Labels", Description: "Create, update and delete label templates", Actions: `["create", "update", "delete"]`},
+		{Name: "labels.manage-printers", Module: "Inventory", Category: "Labels", Description: "Register network label printers", Actions: `["create", "update", "delete"]`},
+
 		// Raw Materials Management (Bahan Baku)
 		{Name: "raw-materials.view", Module: "Inventory", Category: "Raw Materials", Description: "View raw materials list and details", Actions: `["read"]`},
 		{Name: "raw-materials.create", Module: "Inventory", Category: "Raw Materials", Description: "Create new raw material entries", Actions: `["create"]`},
@@ -326,6 +574,8 @@ func SeedData() error {
 		{Name: "transactions.sale", Module: "POS", Category: "Transactions", Description: "Create sale transactions (Penjualan)", Actions: `["create"]`},
 		{Name: "transactions.purchase", Module: "POS", Category: "Transactions", Description: "Create purchase/deposit transactions (Setor Emas)", Actions: `["create"]`},
 		{Name: "transactions.cancel", Module: "POS", Category: "Transactions", Description: "Cancel transactions", Actions: `["cancel"]`},
+		{Name: "transactions.approve-setor-price", Module: "POS", Category: "Transactions", Description: "Approve setor prices outside the tolerance (supervisor)", Actions: `["approve"]`},
+		{Name: "setor-rules.update", Module: "POS", Category: "Transactions", Description: "Manage setor condition deductions", Actions: `["update"]`},
 
 		// POS View Permissions (untuk karyawan yang butuh akses POS tanpa akses master data)
 		{Name: "pos.view-products", Module: "POS", Category: "POS Access", Description: "View products for POS operations", Actions: `["read"]`},
@@ -334,6 +584,7 @@ func SeedData() error {
 		{Name: "pos.view-locations", Module: "POS", Category: "POS Access", Description: "View locations for POS operations", Actions: `["read"]`},
 		{Name: "pos.update-gold-prices", Module: "POS", Category: "POS Access", Description: "Update daily gold prices for POS operations", Actions: `["update"]`},
 		{Name: "pos.update-stocks", Module: "POS", Category: "POS Access", Description: "Update stocks for POS operations", Actions: `["update"]`},
+		{Name: "pos.use-scale", Module: "POS", Category: "POS Access", Description: "Record weights from a connected scale", Actions: `["create", "read"]`},
 		{Name: "pos.view-members", Module: "POS", Category: "POS Access", Description: "View members for POS operations", Actions: `["read"]`},
 		{Name: "pos.create-members", Module: "POS", Category: "POS Access", Description: "Create members for POS operations", Actions: `["create"]`},
 		{Name: "pos.update-members", Module: "POS", Category: "POS Access", Description: "Update members for POS operations", Actions: `["update"]`},
@@ -379,6 +630,9 @@ func SeedData() error {
 		"pos.view-locations",
 		"pos.update-gold-prices",
 		"pos.update-stocks",
+		"pos.use-scale",
+		"labels.view",
+		"labels.print",
 		"transactions.view",
 		"transactions.create",
 		"transactions.sale",
@@ -419,5 +673,107 @@ func SeedData() error {
 		DB.Where(models.Setting{Key: setting.Key}).FirstOrCreate(&setting)
 	}
 
+	// Create default product types with their attribute schema
+	lengthMin := 1.0
+	defaultProductTypes := []models.ProductTypeDefinition{
+		{Code: models.ProductTypeGelang, Name: "Gelang", BarcodePrefix: "GLG", SortOrder: 1, IsActive: true, Attributes: []models.ProductAttributeDefinition{
+			{Code: "bracelet_length", Name: "Panjang Gelang", DataType: models.AttributeTypeNumber, Unit: "cm", MinValue: &lengthMin},
+		}},
+		{Code: models.ProductTypeCincin, Name: "Cincin", BarcodePrefix: "CIN", SortOrder: 2, IsActive: true, Attributes: []models.ProductAttributeDefinition{
+			{Code: "ring_size", Name: "Ukuran Cincin", DataType: models.AttributeTypeText},
+		}},
+		{Code: models.ProductTypeKalung, Name: "Kalung", BarcodePrefix: "KLG", SortOrder: 3, IsActive: true, Attributes: []models.ProductAttributeDefinition{
+			{Code: "necklace_length", Name: "Panjang Kalung", DataType: models.AttributeTypeNumber, Unit: "cm", MinValue: &lengthMin},
+		}},
+		{Code: models.ProductTypeAnting, Name: "Anting", BarcodePrefix: "ANT", SortOrder: 4, IsActive: true, Attributes: []models.ProductAttributeDefinition{
+			{Code: "earring_type", Name: "Tipe Anting", DataType: models.AttributeTypeSelect, AllowedValues: []string{"tusuk", "jepit", "gantung", "hoop", "lainnya"}},
+		}},
+		{Code: models.ProductTypeLiontin, Name: "Liontin", BarcodePrefix: "LNT", SortOrder: 5, IsActive: true},
+		{Code: models.ProductTypeLogamMulia, Name: "Logam Mulia", BarcodePrefix: "LM", SortOrder: 6, IsActive: true},
+		{Code: models.ProductTypeOther, Name: "Lainnya", BarcodePrefix: "GLD", SortOrder: 99, IsActive: true},
+	}
+
+	for _, productType := range defaultProductTypes {
+		DB.Where(models.ProductTypeDefinition{Code: productType.Code}).FirstOrCreate(&productType)
+	}
+
+	// Create default label templates
+	defaultLabelTemplates := []models.LabelTemplate{
+		{
+			Name:         "Label Perhiasan A4 (3 x 20)",
+			Description:  "Kertas label perhiasan ekor tikus 60 x 12 mm, lembar A4",
+			WidthMM:      60,
+			HeightMM:     12,
+			PageWidthMM:  210,
+			PageHeightMM: 297,
+			Columns:      3,
+			Rows:         20,
+			MarginLeftMM: 7.5,
+			MarginTopMM:  8.5,
+			GapXMM:       7.5,
+			GapYMM:       2,
+			PaddingMM:    1,
+			Symbology:    "code128",
+			DPI:          300,
+			FontSizePt:   5,
+			Fields:       []string{"product_name", "weight", "gold_category"},
+			IsDefault:    true,
+			IsActive:     true,
+		},
+		{
+			Name:        "Thermal Roll QR 40 x 20",
+			Description: "Printer thermal roll (ZPL), QR di kiri",
+			WidthMM:     40,
+			HeightMM:    20,
+			Columns:     1,
+			Rows:        1,
+			PaddingMM:   1.5,
+			Symbology:   "qr",
+			DPI:         203,
+			FontSizePt:  6,
+			Fields:      []string{"serial_number", "product_name", "weight", "gold_category"},
+			IsActive:    true,
+		},
+	}
+
+	for _, template := range defaultLabelTemplates {
+		DB.Where(models.LabelTemplate{Name: template.Name}).FirstOrCreate(&template)
+	}
+
+	// Create default setor deductions per condition
+	defaultSetorRules := []models.SetorConditionRule{
+		{Condition: models.RawMaterialConditionNew, DeductionPercent: 0},
+		{Condition: models.RawMaterialConditionLikeNew, DeductionPercent: 0},
+		{Condition: models.RawMaterialConditionScratched, DeductionPercent: 2},
+		{Condition: models.RawMaterialConditionDented, DeductionPercent: 5},
+		{Condition: models.RawMaterialConditionDamaged, DeductionPercent: 10},
+	}
+
+	for _, rule := range defaultSetorRules {
+		DB.Where(models.SetorConditionRule{Condition: rule.Condition}).FirstOrCreate(&rule)
+	}
+
+	// Create default membership tiers and point rates (only when none are configured yet)
+	var tierCount int64
+	DB.Model(&models.MemberTier{}).Count(&tierCount)
+	if tierCount == 0 {
+		DB.Create(&[]models.MemberTier{
+			{Type: models.MemberTypeRegular, Name: "Regular", MinSpend: 0},
+			{Type: models.MemberTypeSilver, Name: "Silver", MinSpend: 20000000},      // 20 juta
+			{Type: models.MemberTypeGold, Name: "Gold", MinSpend: 50000000},          // 50 juta
+			{Type: models.MemberTypePlatinum, Name: "Platinum", MinSpend: 100000000}, // 100 juta
+		})
+	}
+	var pointRuleCount int64
+	DB.Model(&models.MemberPointRule{}).Count(&pointRuleCount)
+	if pointRuleCount == 0 {
+		DB.Create(&[]models.MemberPointRule{
+			{TransactionType: models.TransactionTypeSale, AmountPerPoint: 100000},     // 1 poin per 100 ribu belanja
+			{TransactionType: models.TransactionTypePurchase, AmountPerPoint: 200000}, // 1 poin per 200 ribu setor
+		})
+	}
+	DB.Where(models.Setting{Key: models.MemberTierWindowSettingKey}).
+		FirstOrCreate(&models.Setting{Key: models.MemberTierWindowSettingKey, Value: string(models.MemberTierWindowLifetime)})
+
 	return nil
 }
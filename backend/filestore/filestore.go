@@ -0,0 +1,47 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Store saves uploaded files (foto produk, thumbnail, ...) under a key such as
+// "products/12/1700000000.jpg" and serves them from a public URL
+type Store interface {
+	// Put stores the content under key, replacing any existing file
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Delete removes the file, a missing file is not an error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the file
+	URL(key string) string
+}
+
+var ErrInvalidKey = errors.New("invalid file key")
+
+// CleanKey normalises a key and rejects keys escaping the store root
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(key, "\\", "/")), "/")
+	if key == "" || key == "." || strings.HasPrefix(key, "..") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+	return key, nil
+}
+
+// New returns the store configured by name. "local" (default) keeps files on the server disk
+// under dir and serves them from baseURL. S3-compatible storage is not available yet.
+func New(driver, dir, baseURL string) (Store, error) {
+	switch driver {
+	case "", "local":
+		if dir == "" {
+			return nil, errors.New("upload directory is required for the local store")
+		}
+		return NewLocalStore(dir, baseURL), nil
+	case "s3":
+		return nil, errors.New("s3 file store is not supported yet")
+	}
+	return nil, fmt.Errorf("unknown file store driver: %s", driver)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== BULLION (LOGAM MULIA) ====================
+
+// bullionWeightTolerance is the allowed difference (gram) between a bar brought back and the bar we sold
+const bullionWeightTolerance = 0.01
+
+// normalizeCertificate trims and upper-cases a certificate serial for comparison
+func normalizeCertificate(cert string) string {
+	return strings.ToUpper(strings.TrimSpace(cert))
+}
+
+// validateBullionCertificates checks the certificate serials of new bullion pieces:
+// one per piece, unique in the list and not already held as unsold stock
+func validateBullionCertificates(db *gorm.DB, product models.Product, certificates []string, quantity int) ([]string, error) {
+	if !product.IsBullion() {
+		if len(certificates) > 0 {
+			return nil, fmt.Errorf("Product %s is not bullion, certificate numbers are not used", product.Name)
+		}
+		return nil, nil
+	}
+	if len(certificates) != quantity {
+		return nil, fmt.Errorf("Product %s: %d certificate numbers given for quantity %d", product.Name, len(certificates), quantity)
+	}
+
+	normalized := make([]string, len(certificates))
+	seen := make(map[string]bool)
+	for i, cert := range certificates {
+		cert = normalizeCertificate(cert)
+		if cert == "" {
+			return nil, fmt.Errorf("Product %s: certificate number of piece %d is empty", product.Name, i+1)
+		}
+		if seen[cert] {
+			return nil, fmt.Errorf("Certificate %s is listed twice", cert)
+		}
+		seen[cert] = true
+		normalized[i] = cert
+	}
+
+	var existing []string
+	db.Model(&models.Stock{}).
+		Where("manufacturer = ? AND certificate_number IN ? AND status <> ?", product.Manufacturer, normalized, models.StockStatusSold).
+		Pluck("certificate_number", &existing)
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("Certificate already in stock: %s", strings.Join(existing, ", "))
+	}
+	return normalized, nil
+}
+
+// findBuybackStock finds the bullion piece we sold with this certificate serial.
+// The bar must be sold by us and not bought back already.
+func findBuybackStock(db *gorm.DB, manufacturer, certificate string) (models.Stock, error) {
+	var stock models.Stock
+	certificate = normalizeCertificate(certificate)
+	query := db.Preload("Product").Preload("Product.GoldCategory").
+		Joins("JOIN products ON products.id = stocks.product_id AND products.kind = ?", models.ProductKindBullion).
+		Where("stocks.certificate_number = ?", certificate)
+	if manufacturer != "" {
+		query = query.Where("LOWER(stocks.manufacturer) = LOWER(?)", strings.TrimSpace(manufacturer))
+	}
+	if err := query.Order("stocks.id DESC").First(&stock).Error; err != nil {
+		return stock, fmt.Errorf("Certificate %s was not sold by this store", certificate)
+	}
+	if stock.Status != models.StockStatusSold {
+		return stock, fmt.Errorf("Certificate %s is still in stock (%s), it cannot be bought back", certificate, stock.Status)
+	}
+
+	var count int64
+	db.Model(&models.TransactionItem{}).
+		Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+		Where("transaction_items.buyback_of_stock_id = ? AND transactions.status = ?", stock.ID, "completed").
+		Count(&count)
+	if count > 0 {
+		return stock, fmt.Errorf("Certificate %s has already been bought back", certificate)
+	}
+	return stock, nil
+}
+
+// VerifyBullionCertificate checks a certificate serial before buyback and returns the original sale
+func VerifyBullionCertificate(c *gin.Context) {
+	certificate := c.Query("certificate_number")
+	if certificate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "certificate_number is required"})
+		return
+	}
+
+	stock, err := findBuybackStock(database.DB, c.Query("manufacturer"), certificate)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"valid": false, "reason": err.Error()}})
+		return
+	}
+
+	var sale models.Transaction
+	if stock.TransactionID != nil {
+		database.DB.Preload("Member").First(&sale, *stock.TransactionID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"valid":         true,
+		"stock":         stock,
+		"sale":          sale,
+		"buyback_price": stock.BuyPrice(),
+	}})
+}
+
+type BullionPriceItem struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	BuyPrice  float64 `json:"buy_price" binding:"required,gt=0"`
+	SellPrice float64 `json:"sell_price" binding:"required,gt=0"`
+}
+
+type UpdateBullionPricesRequest struct {
+	Prices []BullionPriceItem `json:"prices" binding:"required,min=1,dive"`
+}
+
+// UpdateBullionPrices sets the published buy/sell price per bar of bullion products
+func UpdateBullionPrices(c *gin.Context) {
+	var req UpdateBullionPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	tx := database.DB.Begin()
+	var updated []models.Product
+	for _, item := range req.Prices {
+		var product models.Product
+		if err := tx.First(&product, item.ProductID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product ID %d not found", item.ProductID)})
+			return
+		}
+		if !product.IsBullion() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product %s is not bullion", product.Name)})
+			return
+		}
+		if item.BuyPrice > item.SellPrice {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product %s: buy price cannot exceed sell price", product.Name)})
+			return
+		}
+
+		product.BullionBuyPrice = item.BuyPrice
+		product.BullionSellPrice = item.SellPrice
+		product.BullionPriceUpdatedAt = &now
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"bullion_buy_price":        item.BuyPrice,
+			"bullion_sell_price":       item.SellPrice,
+			"bullion_price_updated_at": now,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		updated = append(updated, product)
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": updated, "message": fmt.Sprintf("%d bullion prices updated", len(updated))})
+}
+
+// validateProductKind checks the kind specific fields of a product
+func validateProductKind(product *models.Product, stones []models.StoneComponent) error {
+	switch product.Kind {
+	case "":
+		product.Kind = models.ProductKindJewelry
+	case models.ProductKindJewelry:
+	case models.ProductKindBullion:
+		if strings.TrimSpace(product.Manufacturer) == "" {
+			return fmt.Errorf("Manufacturer is required for bullion")
+		}
+		if product.MakingCharge != 0 || len(stones) > 0 {
+			return fmt.Errorf("Bullion has no making charge or stones")
+		}
+	default:
+		return fmt.Errorf("Invalid product kind %s, use jewelry or bullion", product.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== FINE GOLD LEDGER ====================
+
+// onHandStockStatuses are the stock statuses still physically held by a location
+var onHandStockStatuses = []models.StockStatus{
+	models.StockStatusAvailable,
+	models.StockStatusReserved,
+	models.StockStatusTransfer,
+}
+
+// recordFineGold appends a movement to the fine-gold ledger
+func recordFineGold(tx *gorm.DB, movement models.FineGoldMovement) error {
+	if movement.GrossWeight == 0 && movement.FineWeight == 0 {
+		return nil
+	}
+	if movement.OccurredAt.IsZero() {
+		movement.OccurredAt = time.Now()
+	}
+	return tx.Create(&movement).Error
+}
+
+// recordStockFineGold books a whole stock piece in (sign 1) or out (sign -1) of its location
+func recordStockFineGold(tx *gorm.DB, stock models.Stock, movementType models.FineGoldMovementType, sign float64, referenceType string, referenceID uint, referenceNumber string, userID *uint) error {
+	if stock.Product.ID == 0 || stock.Product.GoldCategory.ID == 0 {
+		if err := tx.Preload("GoldCategory").First(&stock.Product, stock.ProductID).Error; err != nil {
+			return fmt.Errorf("Product of stock %s not found", stock.SerialNumber)
+		}
+	}
+
+	movement := models.FineGoldMovement{
+		LocationID:      stock.LocationID,
+		Type:            movementType,
+		GoldCategoryID:  &stock.Product.GoldCategoryID,
+		GrossWeight:     sign * stock.EffectiveWeight(),
+		ReferenceType:   referenceType,
+		ReferenceID:     referenceID,
+		ReferenceNumber: referenceNumber,
+		CreatedByID:     userID,
+		Notes:           "Stok " + stock.SerialNumber,
+	}
+	if purity := stock.Product.GoldCategory.Purity; purity != nil {
+		movement.Purity = *purity
+		movement.FineWeight = movement.GrossWeight * *purity
+	} else {
+		movement.PurityMissing = true
+	}
+	return recordFineGold(tx, movement)
+}
+
+// recordRawMaterialFineGold books the net weight of a raw material in (sign 1) or out (sign -1) of its location
+func recordRawMaterialFineGold(tx *gorm.DB, rm models.RawMaterial, movementType models.FineGoldMovementType, sign float64, referenceType string, referenceID uint, referenceNumber string, userID *uint) error {
+	if rm.GoldCategoryID != nil && rm.GoldCategory == nil {
+		var category models.GoldCategory
+		if err := tx.First(&category, *rm.GoldCategoryID).Error; err == nil {
+			rm.GoldCategory = &category
+		}
+	}
+
+	movement := models.FineGoldMovement{
+		LocationID:      rm.LocationID,
+		Type:            movementType,
+		GoldCategoryID:  rm.GoldCategoryID,
+		GrossWeight:     sign * rm.WeightGrams,
+		Purity:          rm.PurityFraction(),
+		FineWeight:      sign * rm.FineWeight(),
+		PurityMissing:   rm.PurityFraction() <= 0,
+		ReferenceType:   referenceType,
+		ReferenceID:     referenceID,
+		ReferenceNumber: referenceNumber,
+		CreatedByID:     userID,
+		Notes:           "Bahan baku " + rm.Code,
+	}
+	return recordFineGold(tx, movement)
+}
+
+// FineGoldBalance compares the ledger balance of a location with the fine gold it actually holds
+type FineGoldBalance struct {
+	LocationID          uint    `json:"location_id"`
+	LocationName        string  `json:"location_name"`
+	LedgerBalance       float64 `json:"ledger_balance"`        // Saldo menurut mutasi
+	StockFineWeight     float64 `json:"stock_fine_weight"`     // Emas murni di barang jadi
+	RawMaterialFine     float64 `json:"raw_material_fine"`     // Emas murni di bahan baku
+	MeltedFineWeight    float64 `json:"melted_fine_weight"`    // Emas murni hasil lebur yang belum dikirim/dipakai
+	OnHand              float64 `json:"on_hand"`               // Total emas murni fisik
+	Variance            float64 `json:"variance"`              // Fisik - saldo mutasi, selisih yang tidak terjelaskan
+	UnpricedGrossWeight float64 `json:"unpriced_gross_weight"` // Berat kotor dengan kategori/kadar tanpa kemurnian
+}
+
+// fineGoldOnHand computes the physical fine-gold holdings and ledger balance per location
+func fineGoldOnHand(db *gorm.DB, locationID string) (map[uint]*FineGoldBalance, error) {
+	var locations []models.Location
+	query := db.Model(&models.Location{})
+	if locationID != "" {
+		query = query.Where("id = ?", locationID)
+	}
+	if err := query.Order("name").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make(map[uint]*FineGoldBalance)
+	for _, loc := range locations {
+		balances[loc.ID] = &FineGoldBalance{LocationID: loc.ID, LocationName: loc.Name}
+	}
+
+	// Barang jadi
+	weight := stockWeightExpr("s", "p")
+	var stockRows []struct {
+		LocationID uint
+		Fine       float64
+		Unpriced   float64
+	}
+	db.Table("stocks s").
+		Select(fmt.Sprintf(`s.location_id,
+			COALESCE(SUM(CASE WHEN gc.purity IS NOT NULL THEN %s * gc.purity ELSE 0 END), 0) as fine,
+			COALESCE(SUM(CASE WHEN gc.purity IS NULL THEN %s ELSE 0 END), 0) as unpriced`, weight, weight)).
+		Joins("JOIN products p ON p.id = s.product_id").
+		Joins("JOIN gold_categories gc ON gc.id = p.gold_category_id").
+		Where("s.deleted_at IS NULL AND s.status IN ?", onHandStockStatuses).
+		Group("s.location_id").
+		Scan(&stockRows)
+	for _, row := range stockRows {
+		if b, ok := balances[row.LocationID]; ok {
+			b.StockFineWeight = row.Fine
+			b.UnpricedGrossWeight += row.Unpriced
+		}
+	}
+
+	// Bahan baku (kadar per item, fallback ke kategori)
+	var rawMaterials []models.RawMaterial
+	db.Preload("GoldCategory").Where("status = ?", models.RawMaterialStatusAvailable).Find(&rawMaterials)
+	for i := range rawMaterials {
+		b, ok := balances[rawMaterials[i].LocationID]
+		if !ok {
+			continue
+		}
+		if rawMaterials[i].PurityFraction() <= 0 {
+			b.UnpricedGrossWeight += rawMaterials[i].WeightGrams
+			continue
+		}
+		b.RawMaterialFine += rawMaterials[i].FineWeight()
+	}
+
+	// Hasil lebur yang masih di tangan
+	var meltRows []struct {
+		LocationID uint
+		Fine       float64
+	}
+	db.Model(&models.MeltBatch{}).
+		Select("location_id, COALESCE(SUM(actual_fine_weight), 0) as fine").
+		Where("status = ?", models.MeltBatchStatusMelted).
+		Group("location_id").
+		Scan(&meltRows)
+	for _, row := range meltRows {
+		if b, ok := balances[row.LocationID]; ok {
+			b.MeltedFineWeight = row.Fine
+		}
+	}
+
+	// Saldo mutasi
+	var ledgerRows []struct {
+		LocationID uint
+		Balance    float64
+	}
+	db.Model(&models.FineGoldMovement{}).
+		Select("location_id, COALESCE(SUM(fine_weight), 0) as balance").
+		Group("location_id").
+		Scan(&ledgerRows)
+	for _, row := range ledgerRows {
+		if b, ok := balances[row.LocationID]; ok {
+			b.LedgerBalance = row.Balance
+		}
+	}
+
+	for _, b := range balances {
+		b.OnHand = b.StockFineWeight + b.RawMaterialFine + b.MeltedFineWeight
+		b.Variance = b.OnHand - b.LedgerBalance
+	}
+	return balances, nil
+}
+
+// categoriesWithoutPurity returns gold categories whose weight cannot be converted to fine gold
+func categoriesWithoutPurity(db *gorm.DB) []models.GoldCategory {
+	var categories []models.GoldCategory
+	db.Where("purity IS NULL OR purity <= 0").Order("code").Find(&categories)
+	return categories
+}
+
+// GetFineGoldBalance returns the current fine-gold holdings per location against the ledger
+func GetFineGoldBalance(c *gin.Context) {
+	balances, err := fineGoldOnHand(database.DB, c.Query("location_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var result []FineGoldBalance
+	var total FineGoldBalance
+	for _, b := range balances {
+		result = append(result, *b)
+		total.LedgerBalance += b.LedgerBalance
+		total.StockFineWeight += b.StockFineWeight
+		total.RawMaterialFine += b.RawMaterialFine
+		total.MeltedFineWeight += b.MeltedFineWeight
+		total.OnHand += b.OnHand
+		total.Variance += b.Variance
+		total.UnpricedGrossWeight += b.UnpricedGrossWeight
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                      result,
+		"total":                     total,
+		"categories_without_purity": categoriesWithoutPurity(database.DB),
+	})
+}
+
+// GetFineGoldMovements returns fine-gold ledger lines
+func GetFineGoldMovements(c *gin.Context) {
+	var movements []models.FineGoldMovement
+	query := database.DB.Preload("Location").Preload("GoldCategory")
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("occurred_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("occurred_at <= ?", endDate+" 23:59:59")
+	}
+	if c.Query("purity_missing") == "true" {
+		query = query.Where("purity_missing = ?", true)
+	}
+
+	if err := query.Order("occurred_at DESC, id DESC").Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": movements})
+}
+
+// FineGoldPeriodReport is the fine-gold movement of one location over a period
+type FineGoldPeriodReport struct {
+	LocationID          uint                                    `json:"location_id"`
+	LocationName        string                                  `json:"location_name"`
+	Opening             float64                                 `json:"opening"`
+	In                  map[models.FineGoldMovementType]float64 `json:"in"`
+	Out                 map[models.FineGoldMovementType]float64 `json:"out"`
+	TotalIn             float64                                 `json:"total_in"`
+	TotalOut            float64                                 `json:"total_out"`
+	Closing             float64                                 `json:"closing"`               // Saldo awal + masuk - keluar
+	OnHand              *float64                                `json:"on_hand,omitempty"`     // Fisik, hanya jika periode sampai hari ini
+	Variance            *float64                                `json:"variance,omitempty"`    // Fisik - saldo akhir
+	UnpricedGrossWeight float64                                 `json:"unpriced_gross_weight"` // Mutasi tanpa kadar dalam periode
+}
+
+// GetFineGoldReport returns opening balance + in - out = closing in fine-gold grams per location
+func GetFineGoldReport(c *gin.Context) {
+	now := time.Now()
+	startDate := c.DefaultQuery("start_date", now.Format("2006-01")+"-01")
+	endDate := c.DefaultQuery("end_date", now.Format("2006-01-02"))
+	locationID := c.Query("location_id")
+
+	balances, err := fineGoldOnHand(database.DB, locationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reports := make(map[uint]*FineGoldPeriodReport)
+	for id, b := range balances {
+		reports[id] = &FineGoldPeriodReport{
+			LocationID:   id,
+			LocationName: b.LocationName,
+			In:           make(map[models.FineGoldMovementType]float64),
+			Out:          make(map[models.FineGoldMovementType]float64),
+		}
+	}
+
+	// Saldo awal
+	var openingRows []struct {
+		LocationID uint
+		Balance    float64
+	}
+	database.DB.Model(&models.FineGoldMovement{}).
+		Select("location_id, COALESCE(SUM(fine_weight), 0) as balance").
+		Where("occurred_at < ?", startDate).
+		Group("location_id").
+		Scan(&openingRows)
+	for _, row := range openingRows {
+		if r, ok := reports[row.LocationID]; ok {
+			r.Opening = row.Balance
+		}
+	}
+
+	// Mutasi dalam periode, dipisah masuk dan keluar per jenis
+	var movementRows []struct {
+		LocationID uint
+		Type       models.FineGoldMovementType
+		InFine     float64
+		OutFine    float64
+		Unpriced   float64
+	}
+	database.DB.Model(&models.FineGoldMovement{}).
+		Select(`location_id, type,
+			COALESCE(SUM(CASE WHEN fine_weight > 0 THEN fine_weight ELSE 0 END), 0) as in_fine,
+			COALESCE(SUM(CASE WHEN fine_weight < 0 THEN -fine_weight ELSE 0 END), 0) as out_fine,
+			COALESCE(SUM(CASE WHEN purity_missing THEN ABS(gross_weight) ELSE 0 END), 0) as unpriced`).
+		Where("occurred_at >= ? AND occurred_at <= ?", startDate, endDate+" 23:59:59").
+		Group("location_id, type").
+		Scan(&movementRows)
+	for _, row := range movementRows {
+		r, ok := reports[row.LocationID]
+		if !ok {
+			continue
+		}
+		if row.InFine > 0 {
+			r.In[row.Type] += row.InFine
+			r.TotalIn += row.InFine
+		}
+		if row.OutFine > 0 {
+			r.Out[row.Type] += row.OutFine
+			r.TotalOut += row.OutFine
+		}
+		r.UnpricedGrossWeight += row.Unpriced
+	}
+
+	// Selisih fisik hanya bisa dihitung bila periode berakhir hari ini
+	includesToday := endDate >= now.Format("2006-01-02")
+
+	var result []FineGoldPeriodReport
+	for id, r := range reports {
+		r.Closing = r.Opening + r.TotalIn - r.TotalOut
+		if includesToday {
+			onHand := balances[id].OnHand
+			variance := onHand - r.Closing
+			r.OnHand = &onHand
+			r.Variance = &variance
+		}
+		result = append(result, *r)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":                startDate,
+		"end_date":                  endDate,
+		"data":                      result,
+		"categories_without_purity": categoriesWithoutPurity(database.DB),
+	})
+}
+
+type ReconcileFineGoldRequest struct {
+	LocationID uint   `json:"location_id" binding:"required"`
+	Notes      string `json:"notes" binding:"required"`
+}
+
+// ReconcileFineGold books the current physical-vs-ledger variance of a location as an adjustment.
+// Dipakai juga untuk saldo awal saat ledger pertama kali digunakan.
+func ReconcileFineGold(c *gin.Context) {
+	var req ReconcileFineGoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	balances, err := fineGoldOnHand(database.DB, fmt.Sprint(req.LocationID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	balance, ok := balances[req.LocationID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	if balance.Variance > -fineGoldTolerance && balance.Variance < fineGoldTolerance {
+		c.JSON(http.StatusOK, gin.H{"data": balance, "message": "Fine gold balance already reconciles"})
+		return
+	}
+
+	movement := models.FineGoldMovement{
+		LocationID:      req.LocationID,
+		Type:            models.FineGoldMovementAdjustment,
+		FineWeight:      balance.Variance,
+		ReferenceType:   "reconciliation",
+		ReferenceNumber: generateTransactionCode("FGR"),
+		CreatedByID:     &currentUserID,
+		Notes:           req.Notes,
+	}
+	if err := recordFineGold(database.DB, movement); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance.LedgerBalance += balance.Variance
+	balance.Variance = 0
+	c.JSON(http.StatusCreated, gin.H{"data": balance, "adjustment": movement.FineWeight})
+}
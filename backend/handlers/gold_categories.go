@@ -38,6 +38,13 @@ type CreateGoldCategoryRequest struct {
 	SellPrice   float64  `json:"sell_price" binding:"required"`
 	Description string   `json:"description"`
 	IsActive    *bool    `json:"is_active"`
+
+	// Aturan derivasi dari harga dasar 24K
+	DerivePrice       bool                     `json:"derive_price"`
+	SellMarkupPercent float64                  `json:"sell_markup_percent"`
+	BuySpreadPercent  float64                  `json:"buy_spread_percent"`
+	RoundingStep      float64                  `json:"rounding_step"`
+	RoundingMode      models.PriceRoundingMode `json:"rounding_mode"`
 }
 
 // CreateGoldCategory creates a new gold category
@@ -61,6 +68,16 @@ func CreateGoldCategory(c *gin.Context) {
 		SellPrice:   req.SellPrice,
 		Description: req.Description,
 		IsActive:    isActive,
+
+		DerivePrice:       req.DerivePrice,
+		SellMarkupPercent: req.SellMarkupPercent,
+		BuySpreadPercent:  req.BuySpreadPercent,
+		RoundingStep:      req.RoundingStep,
+		RoundingMode:      req.RoundingMode,
+	}
+	if err := validatePricingRule(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := database.DB.Create(&category).Error; err != nil {
@@ -79,6 +96,13 @@ type UpdateGoldCategoryRequest struct {
 	SellPrice   float64  `json:"sell_price"`
 	Description string   `json:"description"`
 	IsActive    *bool    `json:"is_active"`
+
+	// Aturan derivasi dari harga dasar 24K
+	DerivePrice       *bool                    `json:"derive_price"`
+	SellMarkupPercent *float64                 `json:"sell_markup_percent"`
+	BuySpreadPercent  *float64                 `json:"buy_spread_percent"`
+	RoundingStep      *float64                 `json:"rounding_step"`
+	RoundingMode      models.PriceRoundingMode `json:"rounding_mode"`
 }
 
 // UpdateGoldCategory updates an existing gold category
@@ -117,6 +141,25 @@ func UpdateGoldCategory(c *gin.Context) {
 	if req.IsActive != nil {
 		category.IsActive = *req.IsActive
 	}
+	if req.DerivePrice != nil {
+		category.DerivePrice = *req.DerivePrice
+	}
+	if req.SellMarkupPercent != nil {
+		category.SellMarkupPercent = *req.SellMarkupPercent
+	}
+	if req.BuySpreadPercent != nil {
+		category.BuySpreadPercent = *req.BuySpreadPercent
+	}
+	if req.RoundingStep != nil {
+		category.RoundingStep = *req.RoundingStep
+	}
+	if req.RoundingMode != "" {
+		category.RoundingMode = req.RoundingMode
+	}
+	if err := validatePricingRule(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := database.DB.Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetGoodsReceipts returns goods receipts (penerimaan barang) with filters
+func GetGoodsReceipts(c *gin.Context) {
+	var receipts []models.GoodsReceipt
+	query := database.DB.Preload("Supplier").Preload("Location").Preload("ReceivedBy")
+
+	if supplierID := c.Query("supplier_id"); supplierID != "" {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("received_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("received_at <= ?", endDate+" 23:59:59")
+	}
+
+	if err := query.Order("received_at DESC").Find(&receipts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": receipts})
+}
+
+// GetGoodsReceipt returns a single goods receipt with its lines
+func GetGoodsReceipt(c *gin.Context) {
+	id := c.Param("id")
+	var receipt models.GoodsReceipt
+	if err := database.DB.Preload("Supplier").Preload("Location").Preload("ReceivedBy").
+		Preload("Items").Preload("Items.Product").Preload("Items.Product.GoldCategory").Preload("Items.StorageBox").
+		First(&receipt, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goods receipt not found"})
+		return
+	}
+
+	// Stocks created from this receipt
+	var stocks []models.Stock
+	database.DB.Where("goods_receipt_id = ?", receipt.ID).Order("serial_number").Find(&stocks)
+
+	c.JSON(http.StatusOK, gin.H{"data": receipt, "stocks": stocks})
+}
+
+type GoodsReceiptItemRequest struct {
+	ProductID    uint            `json:"product_id" binding:"required"`
+	StorageBoxID uint            `json:"storage_box_id" binding:"required"`
+	Quantity     int             `json:"quantity" binding:"required,min=1"`
+	PieceWeights []float64       `json:"piece_weights"`       // Optional, measured net weight per piece
+	PieceGross   []float64       `json:"piece_gross_weights"` // Optional, measured gross weight per piece
+	Certificates []string        `json:"certificate_numbers"` // Required for bullion, one per piece
+	CostType     models.CostType `json:"cost_type" binding:"required"`
+	CostPerGram  float64         `json:"cost_per_gram"`
+	CostPerPiece float64         `json:"cost_per_piece"`
+	Notes        string          `json:"notes"`
+}
+
+type CreateGoodsReceiptRequest struct {
+	SupplierID    uint                      `json:"supplier_id" binding:"required"`
+	LocationID    uint                      `json:"location_id" binding:"required"`
+	InvoiceNumber string                    `json:"invoice_number"`
+	ReceivedAt    *time.Time                `json:"received_at"`
+	Items         []GoodsReceiptItemRequest `json:"items" binding:"required,min=1"`
+	Notes         string                    `json:"notes"`
+	Post          bool                      `json:"post"` // Langsung posting dan buat stok
+}
+
+// CreateGoodsReceipt creates a goods receipt in draft, optionally posting it right away
+func CreateGoodsReceipt(c *gin.Context) {
+	var req CreateGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, req.SupplierID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	var location models.Location
+	if err := database.DB.First(&location, req.LocationID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Location not found"})
+		return
+	}
+
+	receivedAt := time.Now()
+	if req.ReceivedAt != nil {
+		receivedAt = *req.ReceivedAt
+	}
+
+	receipt := models.GoodsReceipt{
+		ReceiptNumber: generateTransactionCode("GR"),
+		SupplierID:    req.SupplierID,
+		LocationID:    req.LocationID,
+		InvoiceNumber: req.InvoiceNumber,
+		ReceivedAt:    receivedAt,
+		ReceivedByID:  userID.(uint),
+		Status:        models.GoodsReceiptStatusDraft,
+		Notes:         req.Notes,
+	}
+
+	for _, itemReq := range req.Items {
+		item, err := buildGoodsReceiptItem(database.DB, req.LocationID, itemReq)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		receipt.Items = append(receipt.Items, item)
+		receipt.TotalQuantity += item.Quantity
+		receipt.TotalWeight += item.TotalWeight
+		receipt.TotalCost += item.TotalCost
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&receipt).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Post {
+		if _, err := postGoodsReceipt(tx, &receipt); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	database.DB.Preload("Supplier").Preload("Location").Preload("ReceivedBy").
+		Preload("Items").Preload("Items.Product").First(&receipt, receipt.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": receipt})
+}
+
+// buildGoodsReceiptItem validates a receipt line and computes its weight and cost
+func buildGoodsReceiptItem(db *gorm.DB, locationID uint, req GoodsReceiptItemRequest) (models.GoodsReceiptItem, error) {
+	var product models.Product
+	if err := db.First(&product, req.ProductID).Error; err != nil {
+		return models.GoodsReceiptItem{}, fmt.Errorf("Product ID %d not found", req.ProductID)
+	}
+
+	var box models.StorageBox
+	if err := db.Where("id = ? AND location_id = ?", req.StorageBoxID, locationID).First(&box).Error; err != nil {
+		return models.GoodsReceiptItem{}, fmt.Errorf("Storage box ID %d not found in this location", req.StorageBoxID)
+	}
+	if storageBoxHasChildren(db, box.ID) {
+		return models.GoodsReceiptItem{}, fmt.Errorf("Storage box %s is not a leaf node", box.PathCode)
+	}
+
+	if len(req.PieceWeights) > 0 && len(req.PieceWeights) != req.Quantity {
+		return models.GoodsReceiptItem{}, fmt.Errorf("Product %s: %d piece weights given for quantity %d", product.Name, len(req.PieceWeights), req.Quantity)
+	}
+	if len(req.PieceGross) > 0 && len(req.PieceGross) != req.Quantity {
+		return models.GoodsReceiptItem{}, fmt.Errorf("Product %s: %d piece gross weights given for quantity %d", product.Name, len(req.PieceGross), req.Quantity)
+	}
+
+	certificates, err := validateBullionCertificates(db, product, req.Certificates, req.Quantity)
+	if err != nil {
+		return models.GoodsReceiptItem{}, err
+	}
+
+	switch req.CostType {
+	case models.CostTypePerGram:
+		if req.CostPerGram <= 0 {
+			return models.GoodsReceiptItem{}, fmt.Errorf("Product %s: cost_per_gram is required", product.Name)
+		}
+	case models.CostTypePerPiece:
+		if req.CostPerPiece <= 0 {
+			return models.GoodsReceiptItem{}, fmt.Errorf("Product %s: cost_per_piece is required", product.Name)
+		}
+	default:
+		return models.GoodsReceiptItem{}, fmt.Errorf("Invalid cost_type, use per_gram or per_piece")
+	}
+
+	item := models.GoodsReceiptItem{
+		ProductID:    req.ProductID,
+		Product:      product,
+		StorageBoxID: req.StorageBoxID,
+		Quantity:     req.Quantity,
+		PieceWeights: req.PieceWeights,
+		PieceGross:   req.PieceGross,
+		Certificates: certificates,
+		CostType:     req.CostType,
+		CostPerGram:  req.CostPerGram,
+		CostPerPiece: req.CostPerPiece,
+		Notes:        req.Notes,
+	}
+
+	for i := 0; i < item.Quantity; i++ {
+		item.TotalWeight += item.PieceWeight(i)
+		item.TotalCost += item.PieceCost(i)
+	}
+
+	// Product is only needed for calculation, don't let GORM upsert it
+	item.Product = models.Product{}
+	return item, nil
+}
+
+// postGoodsReceipt creates one Stock per received piece carrying its cost basis and receipt reference
+func postGoodsReceipt(tx *gorm.DB, receipt *models.GoodsReceipt) ([]models.Stock, error) {
+	// Klaim draft dengan update bersyarat agar dua posting bersamaan tidak membuat stok dua kali
+	now := time.Now()
+	result := tx.Model(&models.GoodsReceipt{}).
+		Where("id = ? AND status = ?", receipt.ID, models.GoodsReceiptStatusDraft).
+		Updates(map[string]interface{}{
+			"status":    models.GoodsReceiptStatusPosted,
+			"posted_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("Only draft receipts can be posted")
+	}
+	receipt.Status = models.GoodsReceiptStatusPosted
+	receipt.PostedAt = &now
+
+	var supplier models.Supplier
+	if err := tx.First(&supplier, receipt.SupplierID).Error; err != nil {
+		return nil, fmt.Errorf("Supplier not found")
+	}
+
+	var items []models.GoodsReceiptItem
+	if err := tx.Preload("Product").Where("goods_receipt_id = ?", receipt.ID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	serialIndex := 0
+	var stocks []models.Stock
+
+	for i := range items {
+		item := &items[i]
+		for p := 0; p < item.Quantity; p++ {
+			pieceWeight := item.PieceWeight(p)
+			pieceCost := item.PieceCost(p)
+			costPerGram := item.CostPerGram
+			if item.CostType == models.CostTypePerPiece && pieceWeight > 0 {
+				costPerGram = pieceCost / pieceWeight
+			}
+
+			stock := models.Stock{
+				ProductID:          item.ProductID,
+				LocationID:         receipt.LocationID,
+				StorageBoxID:       item.StorageBoxID,
+				SerialNumber:       generateSerialNumber(item.Product.Barcode, timestamp, serialIndex),
+				Status:             models.StockStatusAvailable,
+				SupplierName:       supplier.Name,
+				SupplierID:         &supplier.ID,
+				GoodsReceiptID:     &receipt.ID,
+				GoodsReceiptItemID: &item.ID,
+				ReceivedAt:         &receipt.ReceivedAt,
+				CostPrice:          pieceCost,
+				CostPerGram:        costPerGram,
+				Notes:              item.Notes,
+			}
+			if p < len(item.Certificates) {
+				stock.CertificateNumber = item.Certificates[p]
+				stock.Manufacturer = item.Product.Manufacturer
+			}
+			// Only store measured weights, otherwise the piece keeps following the product weight
+			if len(item.PieceWeights) > 0 {
+				stock.NetWeight = pieceWeight
+				stock.GrossWeight = item.PieceGrossWeight(p)
+				stock.WeightVerifiedAt = &receipt.ReceivedAt
+				stock.WeightVerifiedByID = &receipt.ReceivedByID
+			}
+			serialIndex++
+
+			if err := tx.Create(&stock).Error; err != nil {
+				return nil, err
+			}
+			if err := recordStockFineGold(tx, stock, models.FineGoldMovementReceipt, 1, "goods_receipt", receipt.ID, receipt.ReceiptNumber, &receipt.ReceivedByID); err != nil {
+				return nil, err
+			}
+			stocks = append(stocks, stock)
+		}
+	}
+
+	return stocks, nil
+}
+
+// PostGoodsReceipt posts a draft goods receipt and creates the stock pieces
+func PostGoodsReceipt(c *gin.Context) {
+	id := c.Param("id")
+	var receipt models.GoodsReceipt
+	if err := database.DB.First(&receipt, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goods receipt not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	stocks, err := postGoodsReceipt(tx, &receipt)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": receipt, "stocks": stocks, "count": len(stocks)})
+}
+
+// CancelGoodsReceipt cancels a goods receipt.
+// A posted receipt can only be cancelled while none of its pieces have left the available status.
+func CancelGoodsReceipt(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	// Kunci baris penerimaan agar tidak bentrok dengan posting atau pembatalan lain
+	var receipt models.GoodsReceipt
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&receipt, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Goods receipt not found"})
+		return
+	}
+
+	if receipt.Status == models.GoodsReceiptStatusCancelled {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Goods receipt is already cancelled"})
+		return
+	}
+
+	if receipt.Status == models.GoodsReceiptStatusPosted {
+		// Kunci stok penerimaan agar penjualan atau transfer tidak masuk di antara pengecekan dan penghapusan
+		var stocks []models.Stock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("goods_receipt_id = ?", receipt.ID).Find(&stocks).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		usedCount := 0
+		for _, stock := range stocks {
+			if stock.Status != models.StockStatusAvailable {
+				usedCount++
+			}
+		}
+		if usedCount > 0 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%d pieces from this receipt are no longer available", usedCount)})
+			return
+		}
+
+		for _, stock := range stocks {
+			if err := recordStockFineGold(tx, stock, models.FineGoldMovementReceipt, -1, "goods_receipt", receipt.ID, receipt.ReceiptNumber, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		result := tx.Where("goods_receipt_id = ? AND status = ?", receipt.ID, models.StockStatusAvailable).Delete(&models.Stock{})
+		if result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		if result.RowsAffected != int64(len(stocks)) {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "Pieces from this receipt changed while cancelling, try again"})
+			return
+		}
+	}
+
+	receipt.Status = models.GoodsReceiptStatusCancelled
+	if err := tx.Save(&receipt).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": receipt})
+}
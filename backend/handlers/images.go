@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/filestore"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+// ==================== PRODUCT & STOCK IMAGES ====================
+
+const (
+	// maxImageBytes is the largest photo accepted per file
+	maxImageBytes = 10 << 20
+	// maxImagePixels guards against images that are small on disk but huge once decoded
+	maxImagePixels = 40_000_000
+	// maxImagesPerItem is the number of photos kept per product or stock piece
+	maxImagesPerItem = 10
+	// thumbnailSize is the longest side (px) of generated thumbnails
+	thumbnailSize = 320
+)
+
+// imageStore is where uploaded photos are kept
+var imageStore filestore.Store
+
+// SetImageStore sets the file store used for product and stock photos
+func SetImageStore(store filestore.Store) {
+	imageStore = store
+}
+
+// imageFormat is an accepted upload format, recognised by its magic bytes
+type imageFormat struct {
+	contentType string
+	ext         string
+	magic       func(header []byte) bool
+}
+
+var imageFormats = []imageFormat{
+	{"image/jpeg", ".jpg", func(h []byte) bool { return bytes.HasPrefix(h, []byte{0xFF, 0xD8, 0xFF}) }},
+	{"image/png", ".png", func(h []byte) bool { return bytes.HasPrefix(h, []byte("\x89PNG\r\n\x1a\n")) }},
+	{"image/webp", ".webp", func(h []byte) bool {
+		return len(h) >= 12 && bytes.Equal(h[:4], []byte("RIFF")) && bytes.Equal(h[8:12], []byte("WEBP"))
+	}},
+}
+
+// detectImageFormat identifies the format from the file content, never from the file name
+func detectImageFormat(data []byte) (imageFormat, bool) {
+	for _, format := range imageFormats {
+		if format.magic(data) {
+			return format, true
+		}
+	}
+	return imageFormat{}, false
+}
+
+// processedImage is a validated upload together with its thumbnail
+type processedImage struct {
+	data        []byte
+	thumbnail   []byte
+	format      imageFormat
+	width       int
+	height      int
+	contentSize int64
+}
+
+// processImage reads, validates and decodes an uploaded photo and renders its thumbnail
+func processImage(file *multipart.FileHeader) (*processedImage, error) {
+	if file.Size > maxImageBytes {
+		return nil, fmt.Errorf("%s is larger than %d MB", file.Filename, maxImageBytes>>20)
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("%s is larger than %d MB", file.Filename, maxImageBytes>>20)
+	}
+
+	format, ok := detectImageFormat(data)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a JPEG, PNG or WebP image", file.Filename)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot be read: %v", file.Filename, err)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("%s is too large (%dx%d px)", file.Filename, config.Width, config.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot be read: %v", file.Filename, err)
+	}
+
+	thumbnail, err := renderThumbnail(img)
+	if err != nil {
+		return nil, err
+	}
+	return &processedImage{
+		data:        data,
+		thumbnail:   thumbnail,
+		format:      format,
+		width:       config.Width,
+		height:      config.Height,
+		contentSize: int64(len(data)),
+	}, nil
+}
+
+// renderThumbnail scales the image to fit thumbnailSize and encodes it as JPEG on a white background
+func renderThumbnail(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			height = height * thumbnailSize / width
+			width = thumbnailSize
+		} else {
+			width = width * thumbnailSize / height
+			height = thumbnailSize
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// uploadedImageFiles returns the files of the multipart fields "files" and "file"
+func uploadedImageFiles(c *gin.Context) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, errors.New("No file uploaded")
+	}
+	files := append(form.File["files"], form.File["file"]...)
+	if len(files) == 0 {
+		return nil, errors.New("No file uploaded")
+	}
+	return files, nil
+}
+
+// saveItemImages validates the uploaded photos, stores them with their thumbnails and records them
+// for the owner (product_id or stock_id). Files are removed again if anything fails.
+func saveItemImages(c *gin.Context, ownerColumn string, ownerID uint) ([]models.ProductImage, int, error) {
+	if imageStore == nil {
+		return nil, http.StatusServiceUnavailable, errors.New("Image storage is not configured")
+	}
+	files, err := uploadedImageFiles(c)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var existing int64
+	database.DB.Model(&models.ProductImage{}).Where(ownerColumn+" = ?", ownerID).Count(&existing)
+	if int(existing)+len(files) > maxImagesPerItem {
+		return nil, http.StatusBadRequest, fmt.Errorf("At most %d images per item, %d already uploaded", maxImagesPerItem, existing)
+	}
+
+	// Semua file divalidasi dulu sebelum ada yang disimpan
+	processed := make([]*processedImage, len(files))
+	for i, file := range files {
+		if processed[i], err = processImage(file); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	ctx := c.Request.Context()
+	var stored []string
+	cleanup := func() {
+		for _, key := range stored {
+			if err := imageStore.Delete(context.Background(), key); err != nil {
+				log.Printf("failed to remove image %s: %v", key, err)
+			}
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+	uploaderID := userID.(uint)
+	prefix := "products"
+	if ownerColumn == "stock_id" {
+		prefix = "stocks"
+	}
+
+	var images []models.ProductImage
+	for i, img := range processed {
+		name := fmt.Sprintf("%s/%d/%d_%d", prefix, ownerID, time.Now().UnixNano(), i)
+		key, thumbKey := name+img.format.ext, name+"_thumb.jpg"
+		if err := imageStore.Put(ctx, key, bytes.NewReader(img.data), img.format.contentType); err != nil {
+			cleanup()
+			return nil, http.StatusInternalServerError, err
+		}
+		stored = append(stored, key)
+		if err := imageStore.Put(ctx, thumbKey, bytes.NewReader(img.thumbnail), "image/jpeg"); err != nil {
+			cleanup()
+			return nil, http.StatusInternalServerError, err
+		}
+		stored = append(stored, thumbKey)
+
+		photo := models.ProductImage{
+			StorageKey:   key,
+			ThumbKey:     thumbKey,
+			URL:          imageStore.URL(key),
+			ThumbnailURL: imageStore.URL(thumbKey),
+			ContentType:  img.format.contentType,
+			Width:        img.width,
+			Height:       img.height,
+			SizeBytes:    img.contentSize,
+			SortOrder:    int(existing) + i,
+			IsPrimary:    existing == 0 && i == 0,
+			UploadedByID: &uploaderID,
+		}
+		if ownerColumn == "stock_id" {
+			photo.StockID = &ownerID
+		} else {
+			photo.ProductID = &ownerID
+		}
+		images = append(images, photo)
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&images).Error; err != nil {
+		tx.Rollback()
+		cleanup()
+		return nil, http.StatusInternalServerError, err
+	}
+	if ownerColumn == "product_id" {
+		if err := syncProductImageURL(tx, ownerID); err != nil {
+			tx.Rollback()
+			cleanup()
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	tx.Commit()
+	return images, http.StatusCreated, nil
+}
+
+// syncProductImageURL keeps Product.ImageURL pointing at the primary photo for older clients
+func syncProductImageURL(tx *gorm.DB, productID uint) error {
+	var images []models.ProductImage
+	if err := tx.Where("product_id = ?", productID).Order("sort_order, id").Find(&images).Error; err != nil {
+		return err
+	}
+	url := ""
+	if primary := models.PrimaryImage(images); primary != nil {
+		url = primary.URL
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).Update("image_url", url).Error
+}
+
+// deleteItemImage removes a photo and its files; the next photo becomes primary if needed
+func deleteItemImage(c *gin.Context, ownerColumn string, ownerID uint) {
+	var photo models.ProductImage
+	if err := database.DB.Where("id = ? AND "+ownerColumn+" = ?", c.Param("image_id"), ownerID).First(&photo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Unscoped().Delete(&photo).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if photo.IsPrimary {
+		var next models.ProductImage
+		if err := tx.Where(ownerColumn+" = ?", ownerID).Order("sort_order, id").First(&next).Error; err == nil {
+			if err := tx.Model(&next).Update("is_primary", true).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+	if ownerColumn == "product_id" {
+		if err := syncProductImageURL(tx, ownerID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	// File dihapus setelah commit; kegagalan hanya meninggalkan file yatim
+	if imageStore != nil {
+		for _, key := range []string{photo.StorageKey, photo.ThumbKey} {
+			if err := imageStore.Delete(c.Request.Context(), key); err != nil {
+				log.Printf("failed to remove image %s: %v", key, err)
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// setPrimaryItemImage marks a photo as the one shown on receipts and the scan view
+func setPrimaryItemImage(c *gin.Context, ownerColumn string, ownerID uint) {
+	var photo models.ProductImage
+	if err := database.DB.Where("id = ? AND "+ownerColumn+" = ?", c.Param("image_id"), ownerID).First(&photo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Model(&models.ProductImage{}).Where(ownerColumn+" = ?", ownerID).Update("is_primary", false).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Model(&photo).Update("is_primary", true).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ownerColumn == "product_id" {
+		if err := syncProductImageURL(tx, ownerID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": photo})
+}
+
+// UploadProductImages uploads one or more photos of a product design (multipart field "files")
+func UploadProductImages(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	images, status, err := saveItemImages(c, "product_id", product.ID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"data": images})
+}
+
+// DeleteProductImage deletes a photo of a product design
+func DeleteProductImage(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	deleteItemImage(c, "product_id", product.ID)
+}
+
+// SetPrimaryProductImage sets the main photo of a product design
+func SetPrimaryProductImage(c *gin.Context) {
+	var product models.Product
+	if err := database.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	setPrimaryItemImage(c, "product_id", product.ID)
+}
+
+// UploadStockImages uploads one or more photos of a single stock piece (multipart field "files")
+func UploadStockImages(c *gin.Context) {
+	var stock models.Stock
+	if err := database.DB.First(&stock, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+	images, status, err := saveItemImages(c, "stock_id", stock.ID)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"data": images})
+}
+
+// DeleteStockImage deletes a photo of a stock piece
+func DeleteStockImage(c *gin.Context) {
+	var stock models.Stock
+	if err := database.DB.First(&stock, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+	deleteItemImage(c, "stock_id", stock.ID)
+}
+
+// SetPrimaryStockImage sets the main photo of a stock piece
+func SetPrimaryStockImage(c *gin.Context) {
+	var stock models.Stock
+	if err := database.DB.First(&stock, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+	setPrimaryItemImage(c, "stock_id", stock.ID)
+}
@@ -0,0 +1,716 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/labels"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// labelFields are the text lines a template can print next to the barcode
+var labelFields = map[string]bool{
+	"product_name":  true,
+	"product_code":  true,
+	"serial_number": true,
+	"weight":        true,
+	"gold_category": true,
+	"location":      true,
+	"storage_box":   true,
+}
+
+// ==================== LABEL TEMPLATES ====================
+
+// GetLabelTemplates returns all label templates
+func GetLabelTemplates(c *gin.Context) {
+	var templates []models.LabelTemplate
+	query := database.DB
+
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+
+	if err := query.Order("is_default DESC, name").Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+// GetLabelTemplate returns a single label template
+func GetLabelTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var template models.LabelTemplate
+	if err := database.DB.First(&template, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label template not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+type LabelTemplateRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	Description  string   `json:"description"`
+	WidthMM      float64  `json:"width_mm" binding:"required,gt=0"`
+	HeightMM     float64  `json:"height_mm" binding:"required,gt=0"`
+	PageWidthMM  float64  `json:"page_width_mm"`
+	PageHeightMM float64  `json:"page_height_mm"`
+	Columns      int      `json:"columns"`
+	Rows         int      `json:"rows"`
+	MarginLeftMM float64  `json:"margin_left_mm"`
+	MarginTopMM  float64  `json:"margin_top_mm"`
+	GapXMM       float64  `json:"gap_x_mm"`
+	GapYMM       float64  `json:"gap_y_mm"`
+	PaddingMM    float64  `json:"padding_mm"`
+	Symbology    string   `json:"symbology"`
+	DPI          int      `json:"dpi"`
+	FontSizePt   float64  `json:"font_size_pt"`
+	Fields       []string `json:"fields"`
+	IsDefault    bool     `json:"is_default"`
+	IsActive     *bool    `json:"is_active"`
+}
+
+// apply validates the request and copies it onto the template
+func (req *LabelTemplateRequest) apply(template *models.LabelTemplate) error {
+	for _, field := range req.Fields {
+		if !labelFields[field] {
+			return fmt.Errorf("Unknown label field: %s", field)
+		}
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.WidthMM = req.WidthMM
+	template.HeightMM = req.HeightMM
+	template.PageWidthMM = req.PageWidthMM
+	template.PageHeightMM = req.PageHeightMM
+	template.Columns = req.Columns
+	template.Rows = req.Rows
+	template.MarginLeftMM = req.MarginLeftMM
+	template.MarginTopMM = req.MarginTopMM
+	template.GapXMM = req.GapXMM
+	template.GapYMM = req.GapYMM
+	template.PaddingMM = req.PaddingMM
+	template.Symbology = req.Symbology
+	template.DPI = req.DPI
+	template.FontSizePt = req.FontSizePt
+	template.Fields = req.Fields
+	template.IsDefault = req.IsDefault
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+
+	spec := labelSpec(*template)
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	// Simpan nilai default yang diisi oleh Validate
+	template.Columns = spec.Columns
+	template.Rows = spec.Rows
+	template.Symbology = string(spec.Symbology)
+	template.DPI = spec.DPI
+	template.FontSizePt = spec.FontSizePt
+	return nil
+}
+
+// CreateLabelTemplate creates a new label template
+func CreateLabelTemplate(c *gin.Context) {
+	var req LabelTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template := models.LabelTemplate{IsActive: true}
+	if err := req.apply(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if template.IsDefault {
+		tx.Model(&models.LabelTemplate{}).Where("is_default = ?", true).Update("is_default", false)
+	}
+	if err := tx.Create(&template).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{"data": template})
+}
+
+// UpdateLabelTemplate replaces the layout of a label template
+func UpdateLabelTemplate(c *gin.Context) {
+	id := c.Param("id")
+	var template models.LabelTemplate
+	if err := database.DB.First(&template, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label template not found"})
+		return
+	}
+
+	var req LabelTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.apply(&template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if template.IsDefault {
+		tx.Model(&models.LabelTemplate{}).Where("is_default = ? AND id <> ?", true, template.ID).Update("is_default", false)
+	}
+	if err := tx.Save(&template).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// DeleteLabelTemplate deletes a label template
+func DeleteLabelTemplate(c *gin.Context) {
+	id := c.Param("id")
+	if err := database.DB.Delete(&models.LabelTemplate{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Label template deleted successfully"})
+}
+
+// ==================== LABEL PRINTERS ====================
+
+// GetLabelPrinters returns the registered label printers
+func GetLabelPrinters(c *gin.Context) {
+	var printers []models.LabelPrinter
+	query := database.DB.Preload("Location")
+
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ? OR location_id IS NULL", locationID)
+	}
+
+	if err := query.Order("name").Find(&printers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": printers})
+}
+
+type LabelPrinterRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Address    string `json:"address" binding:"required"` // host atau host:port
+	LocationID *uint  `json:"location_id"`                // Kosong = bisa dipakai semua lokasi
+	Notes      string `json:"notes"`
+	IsActive   *bool  `json:"is_active"`
+}
+
+// apply validates the request and copies it onto the printer
+func (req *LabelPrinterRequest) apply(printer *models.LabelPrinter) error {
+	address, err := normalizePrinterAddress(req.Address)
+	if err != nil {
+		return err
+	}
+	printer.Name = req.Name
+	printer.Address = address
+	printer.LocationID = req.LocationID
+	printer.Notes = req.Notes
+	if req.IsActive != nil {
+		printer.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// CreateLabelPrinter registers a network label printer
+func CreateLabelPrinter(c *gin.Context) {
+	var req LabelPrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	printer := models.LabelPrinter{IsActive: true}
+	if err := req.apply(&printer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.Create(&printer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": printer})
+}
+
+// UpdateLabelPrinter updates a registered label printer
+func UpdateLabelPrinter(c *gin.Context) {
+	id := c.Param("id")
+	var printer models.LabelPrinter
+	if err := database.DB.First(&printer, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label printer not found"})
+		return
+	}
+
+	var req LabelPrinterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.apply(&printer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := database.DB.Save(&printer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": printer})
+}
+
+// DeleteLabelPrinter deletes a registered label printer
+func DeleteLabelPrinter(c *gin.Context) {
+	id := c.Param("id")
+	if err := database.DB.Delete(&models.LabelPrinter{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Label printer deleted successfully"})
+}
+
+// labelSpec converts a stored template into the renderer layout
+func labelSpec(t models.LabelTemplate) labels.Template {
+	return labels.Template{
+		WidthMM:      t.WidthMM,
+		HeightMM:     t.HeightMM,
+		PageWidthMM:  t.PageWidthMM,
+		PageHeightMM: t.PageHeightMM,
+		Columns:      t.Columns,
+		Rows:         t.Rows,
+		MarginLeftMM: t.MarginLeftMM,
+		MarginTopMM:  t.MarginTopMM,
+		GapXMM:       t.GapXMM,
+		GapYMM:       t.GapYMM,
+		PaddingMM:    t.PaddingMM,
+		Symbology:    labels.Symbology(t.Symbology),
+		DPI:          t.DPI,
+		FontSizePt:   t.FontSizePt,
+	}
+}
+
+// stockLabel builds the label content of a stock piece from the template fields
+func stockLabel(template models.LabelTemplate, stock models.Stock) labels.Label {
+	label := labels.Label{Code: stock.SerialNumber}
+	for _, field := range template.Fields {
+		var line string
+		switch field {
+		case "product_name":
+			line = stock.Product.Name
+		case "product_code":
+			line = stock.Product.Barcode
+		case "serial_number":
+			line = stock.SerialNumber
+		case "weight":
+			line = fmt.Sprintf("%.3f g", stock.EffectiveWeight())
+		case "gold_category":
+			line = stock.Product.GoldCategory.Name
+		case "location":
+			line = stock.Location.Name
+		case "storage_box":
+			line = stock.StorageBox.PathCode
+			if line == "" {
+				line = stock.StorageBox.Code
+			}
+		}
+		if line != "" {
+			label.Lines = append(label.Lines, line)
+		}
+	}
+	return label
+}
+
+// ==================== LABEL JOBS ====================
+
+// GetLabelJobs returns label jobs
+func GetLabelJobs(c *gin.Context) {
+	var jobs []models.LabelJob
+	query := database.DB.Preload("Template").Preload("CreatedBy")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if storageBoxID := c.Query("storage_box_id"); storageBoxID != "" {
+		query = query.Where("storage_box_id = ?", storageBoxID)
+	}
+
+	if err := query.Order("created_at DESC").Limit(100).Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": jobs})
+}
+
+// GetLabelJob returns a single label job
+func GetLabelJob(c *gin.Context) {
+	id := c.Param("id")
+	var job models.LabelJob
+	if err := database.DB.Preload("Template").Preload("Printer").Preload("CreatedBy").First(&job, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+type CreateLabelJobRequest struct {
+	TemplateID   uint   `json:"template_id"` // Kosong = template default
+	Format       string `json:"format" binding:"required,oneof=png pdf zpl"`
+	StockIDs     []uint `json:"stock_ids"`
+	StorageBoxID *uint  `json:"storage_box_id"` // Cetak semua stok tersedia di kotak (termasuk sub-kotak)
+	PrinterID    *uint  `json:"printer_id"`     // Printer ZPL terdaftar, job selesai otomatis jika terkirim
+}
+
+// CreateLabelJob renders labels for a set of stocks or a whole storage box
+func CreateLabelJob(c *gin.Context) {
+	var req CreateLabelJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.StockIDs) == 0 && req.StorageBoxID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stock_ids or storage_box_id is required"})
+		return
+	}
+	if req.PrinterID != nil && req.Format != string(models.LabelFormatZPL) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "printer_id is only supported for zpl"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	var printer *models.LabelPrinter
+	if req.PrinterID != nil {
+		printer = &models.LabelPrinter{}
+		if err := database.DB.Where("is_active = ?", true).First(printer, *req.PrinterID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Label printer not found"})
+			return
+		}
+		if printer.LocationID != nil && !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, *printer.LocationID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke printer ini"})
+			return
+		}
+	}
+
+	var template models.LabelTemplate
+	templateQuery := database.DB.Where("is_active = ?", true)
+	if req.TemplateID > 0 {
+		templateQuery = templateQuery.Where("id = ?", req.TemplateID)
+	} else {
+		templateQuery = templateQuery.Order("is_default DESC, id")
+	}
+	if err := templateQuery.First(&template).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label template not found"})
+		return
+	}
+
+	stockIDs := req.StockIDs
+	if req.StorageBoxID != nil {
+		scope, err := storageSubtreeScope(fmt.Sprint(*req.StorageBoxID))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Storage box not found"})
+			return
+		}
+		var boxStockIDs []uint
+		database.DB.Model(&models.Stock{}).Scopes(scope).
+			Where("status = ?", models.StockStatusAvailable).
+			Order("serial_number ASC").
+			Pluck("stocks.id", &boxStockIDs)
+		stockIDs = append(stockIDs, boxStockIDs...)
+	}
+
+	stocks, err := loadLabelStocks(stockIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(stocks) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No stocks to print"})
+		return
+	}
+
+	// Render sekali untuk memastikan semua label valid sebelum job disimpan
+	output, _, err := renderLabelJob(template, models.LabelFormat(req.Format), stocks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to render labels: " + err.Error()})
+		return
+	}
+
+	ids := make([]uint, len(stocks))
+	for i, stock := range stocks {
+		ids[i] = stock.ID
+	}
+
+	job := models.LabelJob{
+		JobNumber:    generateTransactionCode("LBL"),
+		TemplateID:   template.ID,
+		Format:       models.LabelFormat(req.Format),
+		StockIDs:     ids,
+		StorageBoxID: req.StorageBoxID,
+		LabelCount:   len(stocks),
+		Status:       models.LabelJobStatusRendered,
+		PrinterID:    req.PrinterID,
+		CreatedByID:  currentUserID,
+	}
+
+	if err := database.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Kirim langsung ke printer jaringan (raw port 9100)
+	if printer != nil {
+		if err := sendToPrinter(printer.Address, output); err != nil {
+			database.DB.Model(&job).Updates(map[string]interface{}{
+				"status":        models.LabelJobStatusFailed,
+				"error_message": err.Error(),
+			})
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send labels to printer: " + err.Error(), "data": job})
+			return
+		}
+		if err := completeLabelJob(database.DB, &job, currentUserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	database.DB.Preload("Template").Preload("Printer").First(&job, job.ID)
+	c.JSON(http.StatusCreated, gin.H{
+		"data":       job,
+		"output_url": fmt.Sprintf("/api/labels/jobs/%d/output", job.ID),
+	})
+}
+
+// GetLabelJobOutput downloads the rendered labels of a job
+func GetLabelJobOutput(c *gin.Context) {
+	id := c.Param("id")
+	var job models.LabelJob
+	if err := database.DB.Preload("Template").First(&job, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label job not found"})
+		return
+	}
+	if job.Template == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label template no longer exists"})
+		return
+	}
+
+	stocks, err := loadLabelStocks(job.StockIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	output, contentType, err := renderLabelJob(*job.Template, job.Format, stocks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	extension := string(job.Format)
+	if job.Format == models.LabelFormatPNG && len(stocks) > 1 {
+		extension = "zip"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", job.JobNumber, extension))
+	c.Data(http.StatusOK, contentType, output)
+}
+
+// CompleteLabelJob confirms the labels were printed and marks the stocks as printed
+func CompleteLabelJob(c *gin.Context) {
+	id := c.Param("id")
+	var job models.LabelJob
+	if err := database.DB.First(&job, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label job not found"})
+		return
+	}
+
+	if job.Status != models.LabelJobStatusRendered && job.Status != models.LabelJobStatusFailed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot complete a %s label job", job.Status)})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	tx := database.DB.Begin()
+	if err := completeLabelJob(tx, &job, userID.(uint)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// CancelLabelJob cancels a label job that was not printed
+func CancelLabelJob(c *gin.Context) {
+	id := c.Param("id")
+	var job models.LabelJob
+	if err := database.DB.First(&job, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Label job not found"})
+		return
+	}
+
+	if job.Status == models.LabelJobStatusCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Completed label jobs cannot be cancelled"})
+		return
+	}
+
+	job.Status = models.LabelJobStatusCancelled
+	if err := database.DB.Save(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+// loadLabelStocks loads stocks in the requested order with everything a label may print
+func loadLabelStocks(ids []uint) ([]models.Stock, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var stocks []models.Stock
+	if err := database.DB.Preload("Product").Preload("Product.GoldCategory").
+		Preload("Location").Preload("StorageBox").
+		Where("id IN ?", ids).Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Stock, len(stocks))
+	for _, stock := range stocks {
+		byID[stock.ID] = stock
+	}
+
+	ordered := make([]models.Stock, 0, len(stocks))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		stock, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("Stock ID %d not found", id)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ordered = append(ordered, stock)
+	}
+	return ordered, nil
+}
+
+// renderLabelJob renders the labels in the requested format and returns the content type
+func renderLabelJob(template models.LabelTemplate, format models.LabelFormat, stocks []models.Stock) ([]byte, string, error) {
+	spec := labelSpec(template)
+	items := make([]labels.Label, len(stocks))
+	for i, stock := range stocks {
+		items[i] = stockLabel(template, stock)
+	}
+
+	switch format {
+	case models.LabelFormatPDF:
+		output, err := labels.RenderPDF(spec, items)
+		return output, "application/pdf", err
+	case models.LabelFormatZPL:
+		output, err := labels.RenderZPL(spec, items)
+		return []byte(output), "text/plain; charset=utf-8", err
+	case models.LabelFormatPNG:
+		if len(items) == 1 {
+			output, err := labels.RenderPNG(spec, items[0])
+			return output, "image/png", err
+		}
+
+		// Banyak label PNG dikemas dalam satu zip
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		for i, item := range items {
+			image, err := labels.RenderPNG(spec, item)
+			if err != nil {
+				return nil, "", err
+			}
+			file, err := archive.Create(fmt.Sprintf("%s.png", stocks[i].SerialNumber))
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := file.Write(image); err != nil {
+				return nil, "", err
+			}
+		}
+		if err := archive.Close(); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "application/zip", nil
+	}
+	return nil, "", fmt.Errorf("Unknown label format: %s", format)
+}
+
+// normalizePrinterAddress validates a printer address and adds the raw printing port 9100 if missing
+func normalizePrinterAddress(address string) (string, error) {
+	if address == "" {
+		return "", fmt.Errorf("Printer address is required")
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "9100")
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host == "" || port == "" {
+		return "", fmt.Errorf("Invalid printer address %s, use host or host:port", address)
+	}
+	return address, nil
+}
+
+// sendToPrinter sends raw printer data (ZPL) to a registered network printer
+func sendToPrinter(address string, data []byte) error {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+	_, err = conn.Write(data)
+	return err
+}
+
+// completeLabelJob marks the job completed and its stocks as printed
+func completeLabelJob(db *gorm.DB, job *models.LabelJob, userID uint) error {
+	now := time.Now()
+	if len(job.StockIDs) > 0 {
+		if err := db.Model(&models.Stock{}).
+			Where("id IN ?", job.StockIDs).
+			Updates(map[string]interface{}{
+				"barcode_printed":    true,
+				"barcode_printed_at": now,
+			}).Error; err != nil {
+			return err
+		}
+	}
+
+	job.Status = models.LabelJobStatusCompleted
+	job.CompletedAt = &now
+	job.CompletedByID = &userID
+	job.ErrorMessage = ""
+	return db.Save(job).Error
+}
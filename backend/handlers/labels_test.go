@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"starter/backend/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// recordingPool is a connection pool that records the statements it is asked to execute
+type recordingPool struct {
+	statements []string
+	args       [][]interface{}
+}
+
+func (p *recordingPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (p *recordingPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.statements = append(p.statements, query)
+	p.args = append(p.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (p *recordingPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("query not supported")
+}
+
+func (p *recordingPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func recordingDB(t *testing.T) (*gorm.DB, *recordingPool) {
+	t.Helper()
+	pool := &recordingPool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return db, pool
+}
+
+func TestCompleteLabelJobMarksStocksPrinted(t *testing.T) {
+	db, pool := recordingDB(t)
+	job := models.LabelJob{ID: 1, JobNumber: "LBL1", TemplateID: 1, Format: models.LabelFormatZPL,
+		StockIDs: []uint{3, 5}, Status: models.LabelJobStatusFailed, ErrorMessage: "printer offline", CreatedByID: 2}
+
+	if err := completeLabelJob(db, &job, 7); err != nil {
+		t.Fatalf("completeLabelJob: %v", err)
+	}
+
+	if job.Status != models.LabelJobStatusCompleted || job.CompletedAt == nil || job.ErrorMessage != "" {
+		t.Errorf("job = %+v, want completed without error", job)
+	}
+	if job.CompletedByID == nil || *job.CompletedByID != 7 {
+		t.Errorf("completed by = %v, want 7", job.CompletedByID)
+	}
+	if len(pool.statements) != 2 {
+		t.Fatalf("statements = %q, want stock update and job save", pool.statements)
+	}
+	stockUpdate := pool.statements[0]
+	if !strings.HasPrefix(stockUpdate, `UPDATE "stocks" SET`) || !strings.Contains(stockUpdate, `"barcode_printed"`) || !strings.Contains(stockUpdate, "id IN") {
+		t.Errorf("stock update = %q", stockUpdate)
+	}
+	ids := map[interface{}]bool{}
+	for _, arg := range pool.args[0] {
+		ids[arg] = true
+	}
+	if !ids[uint(3)] || !ids[uint(5)] {
+		t.Errorf("stock update args = %v, want stock 3 and 5", pool.args[0])
+	}
+	if !strings.HasPrefix(pool.statements[1], `UPDATE "label_jobs" SET`) {
+		t.Errorf("job save = %q", pool.statements[1])
+	}
+}
+
+func TestCompleteLabelJobWithoutStocks(t *testing.T) {
+	db, pool := recordingDB(t)
+	job := models.LabelJob{ID: 1, JobNumber: "LBL2", TemplateID: 1, Format: models.LabelFormatPDF, CreatedByID: 2}
+
+	if err := completeLabelJob(db, &job, 7); err != nil {
+		t.Fatalf("completeLabelJob: %v", err)
+	}
+	if len(pool.statements) != 1 || !strings.HasPrefix(pool.statements[0], `UPDATE "label_jobs" SET`) {
+		t.Errorf("statements = %q, want only the job save", pool.statements)
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== PER-LOCATION PRICE OVERRIDES ====================
+
+// locationPriceBook holds the price overrides per location and gold category
+type locationPriceBook map[uint]map[uint]models.LocationPrice
+
+// loadLocationPrices loads the price overrides, of the given locations only if any are given
+func loadLocationPrices(db *gorm.DB, locationIDs ...uint) locationPriceBook {
+	var overrides []models.LocationPrice
+	query := db.Model(&models.LocationPrice{})
+	if len(locationIDs) > 0 {
+		query = query.Where("location_id IN ?", locationIDs)
+	}
+	query.Find(&overrides)
+
+	book := make(locationPriceBook)
+	for _, override := range overrides {
+		if book[override.LocationID] == nil {
+			book[override.LocationID] = make(map[uint]models.LocationPrice)
+		}
+		book[override.LocationID][override.GoldCategoryID] = override
+	}
+	return book
+}
+
+// category returns the gold category with the prices effective at the location.
+// An override that no longer gives valid prices (contoh: delta setelah harga global turun) is ignored.
+func (b locationPriceBook) category(locationID uint, category models.GoldCategory) models.GoldCategory {
+	if override, ok := b[locationID][category.ID]; ok {
+		if effective := override.Apply(category); validTradePrices(effective) {
+			return effective
+		}
+	}
+	return category
+}
+
+// validTradePrices reports whether a category can be traded at its prices: both positive and buy not above sell
+func validTradePrices(category models.GoldCategory) bool {
+	return category.BuyPrice > 0 && category.SellPrice > 0 && category.BuyPrice <= category.SellPrice
+}
+
+// applyToStock sets the prices effective at the stock's location on its product category
+func (b locationPriceBook) applyToStock(stock *models.Stock) {
+	stock.Product.GoldCategory = b.category(stock.LocationID, stock.Product.GoldCategory)
+}
+
+// effectiveCategory loads a gold category with the prices effective at a location
+func effectiveCategory(db *gorm.DB, locationID, categoryID uint) (models.GoldCategory, error) {
+	var category models.GoldCategory
+	if err := db.First(&category, categoryID).Error; err != nil {
+		return category, err
+	}
+	return loadLocationPrices(db, locationID).category(locationID, category), nil
+}
+
+// locationPriceExpr returns the SQL expression for the effective buy or sell price per gram
+// of a category at the location in locationColumn (contoh: s.location_id).
+// Like locationPriceBook.category, an override giving invalid prices falls back to the global price.
+func locationPriceExpr(locationColumn, categoryAlias, side string) string {
+	effective := func(side string) string {
+		return fmt.Sprintf("(CASE lp.mode WHEN '%s' THEN lp.%s ELSE %s.%s + lp.%s END)",
+			models.LocationPriceModeAbsolute, side, categoryAlias, side, side)
+	}
+	buy, sell := effective("buy_price"), effective("sell_price")
+	return fmt.Sprintf(`COALESCE(
+		(SELECT CASE WHEN %s > 0 AND %s > 0 AND %s <= %s THEN %s END FROM location_prices lp
+			WHERE lp.location_id = %s AND lp.gold_category_id = %s.id AND lp.deleted_at IS NULL),
+		%s.%s)`,
+		buy, sell, buy, sell, effective(side),
+		locationColumn, categoryAlias,
+		categoryAlias, side)
+}
+
+// LocationCategoryPrice shows the global, override and effective price of a category at a location
+type LocationCategoryPrice struct {
+	GoldCategoryID  uint                  `json:"gold_category_id"`
+	Code            string                `json:"code"`
+	Name            string                `json:"name"`
+	GlobalBuyPrice  float64               `json:"global_buy_price"`
+	GlobalSellPrice float64               `json:"global_sell_price"`
+	Override        *models.LocationPrice `json:"override,omitempty"`
+	BuyPrice        float64               `json:"buy_price"`  // Harga yang dipakai di lokasi ini
+	SellPrice       float64               `json:"sell_price"` // Harga yang dipakai di lokasi ini
+
+	OverrideInvalid bool `json:"override_invalid,omitempty"` // Override diabaikan karena harga efektifnya tidak valid
+}
+
+// GetLocationPrices returns the effective prices of all active gold categories at a location
+func GetLocationPrices(c *gin.Context) {
+	var location models.Location
+	if err := database.DB.First(&location, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	var categories []models.GoldCategory
+	if err := database.DB.Where("is_active = ?", true).Order("code").Find(&categories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	book := loadLocationPrices(database.DB, location.ID)
+	prices := make([]LocationCategoryPrice, 0, len(categories))
+	for _, category := range categories {
+		effective := book.category(location.ID, category)
+		price := LocationCategoryPrice{
+			GoldCategoryID:  category.ID,
+			Code:            category.Code,
+			Name:            category.Name,
+			GlobalBuyPrice:  category.BuyPrice,
+			GlobalSellPrice: category.SellPrice,
+			BuyPrice:        effective.BuyPrice,
+			SellPrice:       effective.SellPrice,
+		}
+		if override, ok := book[location.ID][category.ID]; ok {
+			price.Override = &override
+			price.OverrideInvalid = !validTradePrices(override.Apply(category))
+		}
+		prices = append(prices, price)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": prices, "location": location})
+}
+
+type LocationPriceItem struct {
+	GoldCategoryID uint                     `json:"gold_category_id" binding:"required"`
+	Mode           models.LocationPriceMode `json:"mode" binding:"required"`
+	BuyPrice       float64                  `json:"buy_price"`
+	SellPrice      float64                  `json:"sell_price"`
+}
+
+type SetLocationPricesRequest struct {
+	Prices []LocationPriceItem `json:"prices" binding:"required,min=1,dive"`
+	Notes  string              `json:"notes"`
+}
+
+// SetLocationPrices creates or replaces price overrides of a location and logs the change with the location as scope
+func SetLocationPrices(c *gin.Context) {
+	var location models.Location
+	if err := database.DB.First(&location, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	var req SetLocationPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+	book := loadLocationPrices(database.DB, location.ID)
+
+	tx := database.DB.Begin()
+	priceUpdateLog, err := createLocationPriceLog(tx, location, currentUserID, req.Notes)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[uint]bool)
+	for _, item := range req.Prices {
+		if seen[item.GoldCategoryID] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Gold category %d is listed twice", item.GoldCategoryID)})
+			return
+		}
+		seen[item.GoldCategoryID] = true
+
+		if !item.Mode.IsValid() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid mode %s, use absolute or delta", item.Mode)})
+			return
+		}
+		var category models.GoldCategory
+		if err := tx.First(&category, item.GoldCategoryID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusNotFound, gin.H{"error": "Gold category not found", "gold_category_id": item.GoldCategoryID})
+			return
+		}
+
+		override, exists := book[location.ID][category.ID]
+		before := book.category(location.ID, category)
+		override.LocationID = location.ID
+		override.GoldCategoryID = category.ID
+		override.Mode = item.Mode
+		override.BuyPrice = item.BuyPrice
+		override.SellPrice = item.SellPrice
+		override.UpdatedByID = &currentUserID
+
+		after := override.Apply(category)
+		if !validTradePrices(after) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: effective prices must be positive and buy price cannot exceed sell price", category.Name)})
+			return
+		}
+
+		if exists {
+			err = tx.Save(&override).Error
+		} else {
+			err = tx.Create(&override).Error
+		}
+		if err == nil {
+			err = createLocationPriceDetail(tx, priceUpdateLog.ID, category.ID, before, after)
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	database.DB.Preload("UpdatedBy").Preload("Location").Preload("PriceDetails.GoldCategory").First(&priceUpdateLog, priceUpdateLog.ID)
+	c.JSON(http.StatusOK, gin.H{"data": priceUpdateLog, "message": "Location prices updated successfully"})
+}
+
+// DeleteLocationPrice removes the override of a category so the location follows the global price again
+func DeleteLocationPrice(c *gin.Context) {
+	var location models.Location
+	if err := database.DB.First(&location, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	var override models.LocationPrice
+	if err := database.DB.Preload("GoldCategory").
+		Where("location_id = ? AND gold_category_id = ?", location.ID, c.Param("category_id")).First(&override).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location price override not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	category := *override.GoldCategory
+
+	tx := database.DB.Begin()
+	priceUpdateLog, err := createLocationPriceLog(tx, location, userID.(uint), "Override harga dihapus")
+	if err == nil {
+		err = createLocationPriceDetail(tx, priceUpdateLog.ID, category.ID, override.Apply(category), category)
+	}
+	if err == nil {
+		err = tx.Delete(&override).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location price override deleted successfully"})
+}
+
+// createLocationPriceLog creates the price update log of a location-scoped change
+func createLocationPriceLog(tx *gorm.DB, location models.Location, userID uint, notes string) (models.PriceUpdateLog, error) {
+	if notes == "" {
+		notes = "Harga lokasi " + location.Name
+	}
+	priceUpdateLog := models.PriceUpdateLog{
+		UpdateDate:  time.Now(),
+		UpdatedByID: userID,
+		Notes:       notes,
+		Source:      models.PriceUpdateSourceManual,
+		LocationID:  &location.ID,
+	}
+	if err := tx.Create(&priceUpdateLog).Error; err != nil {
+		return priceUpdateLog, errors.New("Failed to create price update log")
+	}
+	return priceUpdateLog, nil
+}
+
+// createLocationPriceDetail records the effective price at the location before and after the change
+func createLocationPriceDetail(tx *gorm.DB, logID, categoryID uint, before, after models.GoldCategory) error {
+	return tx.Create(&models.PriceDetail{
+		PriceUpdateLogID: logID,
+		GoldCategoryID:   categoryID,
+		OldBuyPrice:      before.BuyPrice,
+		NewBuyPrice:      after.BuyPrice,
+		OldSellPrice:     before.SellPrice,
+		NewSellPrice:     after.SellPrice,
+	}).Error
+}
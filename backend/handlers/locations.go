@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ==================== LOCATIONS ====================
@@ -104,6 +107,7 @@ func UpdateLocation(c *gin.Context) {
 		return
 	}
 
+	codeChanged := req.Code != "" && req.Code != location.Code
 	if req.Code != "" {
 		location.Code = req.Code
 	}
@@ -126,11 +130,34 @@ func UpdateLocation(c *gin.Context) {
 		location.IsActive = *req.IsActive
 	}
 
-	if err := database.DB.Save(&location).Error; err != nil {
+	tx := database.DB.Begin()
+	if err := tx.Save(&location).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Storage box path codes start with the location code
+	if codeChanged {
+		var roots []models.StorageBox
+		if err := tx.Where("location_id = ? AND parent_id IS NULL", location.ID).Find(&roots).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range roots {
+			if err := refreshStorageBoxSubtree(tx, &roots[i]); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update storage box paths: " + err.Error()})
+				return
+			}
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": location})
 }
 
@@ -156,7 +183,26 @@ func GetStorageBoxes(c *gin.Context) {
 		query = query.Where("location_id = ?", locationID)
 	}
 
-	if err := query.Find(&boxes).Error; err != nil {
+	// Filter by parent_id ("root" = top level nodes only)
+	if parentID := c.Query("parent_id"); parentID != "" {
+		if parentID == "root" {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", parentID)
+		}
+	}
+
+	// Filter by node type
+	if boxType := c.Query("type"); boxType != "" {
+		query = query.Where("type = ?", boxType)
+	}
+
+	// Only leaf nodes (nodes that can hold stock)
+	if c.Query("leaf_only") == "true" {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM storage_boxes child WHERE child.parent_id = storage_boxes.id AND child.deleted_at IS NULL)")
+	}
+
+	if err := query.Order("path_code").Find(&boxes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -167,20 +213,277 @@ func GetStorageBoxes(c *gin.Context) {
 func GetStorageBox(c *gin.Context) {
 	id := c.Param("id")
 	var box models.StorageBox
-	if err := database.DB.Preload("Location").Preload("Stocks").First(&box, id).Error; err != nil {
+	if err := database.DB.Preload("Location").Preload("Parent").Preload("Children").Preload("Stocks").First(&box, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Storage box not found"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": box})
 }
 
+// GetStorageBoxTree returns the storage nodes of a location as a nested tree
+func GetStorageBoxTree(c *gin.Context) {
+	locationID := c.Query("location_id")
+	if locationID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location_id is required"})
+		return
+	}
+
+	var boxes []models.StorageBox
+	if err := database.DB.Where("location_id = ?", locationID).Order("path_code").Find(&boxes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Count available stock directly placed on each node
+	type boxCount struct {
+		StorageBoxID uint
+		Count        int64
+	}
+	var counts []boxCount
+	database.DB.Model(&models.Stock{}).
+		Select("storage_box_id, COUNT(*) as count").
+		Where("location_id = ? AND status = ?", locationID, models.StockStatusAvailable).
+		Group("storage_box_id").
+		Scan(&counts)
+	countMap := make(map[uint]int64)
+	for _, bc := range counts {
+		countMap[bc.StorageBoxID] = bc.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": buildStorageBoxTree(boxes, countMap)})
+}
+
+// StorageBoxTreeNode is a storage box with its nested children and stock count
+type StorageBoxTreeNode struct {
+	models.StorageBox
+	StockCount int64                 `json:"stock_count"` // Available stock in this node and all descendants
+	IsLeaf     bool                  `json:"is_leaf"`
+	Nodes      []*StorageBoxTreeNode `json:"nodes"`
+}
+
+// buildStorageBoxTree nests a flat list of boxes (sorted by path_code) into a tree
+func buildStorageBoxTree(boxes []models.StorageBox, countMap map[uint]int64) []*StorageBoxTreeNode {
+	nodes := make(map[uint]*StorageBoxTreeNode, len(boxes))
+	for _, b := range boxes {
+		nodes[b.ID] = &StorageBoxTreeNode{StorageBox: b, Nodes: []*StorageBoxTreeNode{}}
+	}
+
+	var roots []*StorageBoxTreeNode
+	for _, b := range boxes {
+		node := nodes[b.ID]
+		if b.ParentID != nil {
+			if parent, ok := nodes[*b.ParentID]; ok {
+				parent.Nodes = append(parent.Nodes, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var sumCounts func(n *StorageBoxTreeNode) int64
+	sumCounts = func(n *StorageBoxTreeNode) int64 {
+		total := countMap[n.ID]
+		for _, child := range n.Nodes {
+			total += sumCounts(child)
+		}
+		n.StockCount = total
+		n.IsLeaf = len(n.Nodes) == 0
+		return total
+	}
+	for _, root := range roots {
+		sumCounts(root)
+	}
+
+	return roots
+}
+
+// StorageBoxSummary represents aggregated stock of a storage node and its descendants
+type StorageBoxSummary struct {
+	StorageBoxID uint                        `json:"storage_box_id"`
+	Code         string                      `json:"code"`
+	PathCode     string                      `json:"path_code"`
+	Name         string                      `json:"name"`
+	Type         models.StorageBoxType       `json:"type"`
+	TotalItems   int64                       `json:"total_items"`
+	TotalWeight  float64                     `json:"total_weight"`
+	ByStatus     map[string]int64            `json:"by_status"`
+	ByCategory   []StorageBoxCategorySummary `json:"by_category"`
+	Children     []StorageBoxSummary         `json:"children,omitempty"`
+}
+
+// StorageBoxCategorySummary represents stock per gold category within a storage node
+type StorageBoxCategorySummary struct {
+	CategoryName string  `json:"category_name"`
+	Count        int64   `json:"count"`
+	Weight       float64 `json:"weight"`
+}
+
+// GetStorageBoxSummary returns stock aggregated over a storage node and everything below it
+func GetStorageBoxSummary(c *gin.Context) {
+	id := c.Param("id")
+	var box models.StorageBox
+	if err := database.DB.First(&box, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storage box not found"})
+		return
+	}
+
+	summary := summarizeStorageBox(box)
+
+	// Also summarize each direct child so the UI can drill down one level
+	var children []models.StorageBox
+	database.DB.Where("parent_id = ?", box.ID).Order("code").Find(&children)
+	for _, child := range children {
+		summary.Children = append(summary.Children, summarizeStorageBox(child))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
+
+// summarizeStorageBox aggregates stock in the subtree rooted at box
+func summarizeStorageBox(box models.StorageBox) StorageBoxSummary {
+	summary := StorageBoxSummary{
+		StorageBoxID: box.ID,
+		Code:         box.Code,
+		PathCode:     box.PathCode,
+		Name:         box.Name,
+		Type:         box.Type,
+		ByStatus:     make(map[string]int64),
+	}
+
+	type statusCount struct {
+		Status string
+		Count  int64
+	}
+	var statuses []statusCount
+	database.DB.Model(&models.Stock{}).
+		Scopes(InStorageSubtree(box)).
+		Select("stocks.status, COUNT(*) as count").
+		Group("stocks.status").
+		Scan(&statuses)
+	for _, sc := range statuses {
+		summary.ByStatus[sc.Status] = sc.Count
+	}
+	summary.TotalItems = summary.ByStatus[string(models.StockStatusAvailable)]
+
+	database.DB.Model(&models.Stock{}).
+		Scopes(InStorageSubtree(box)).
+		Select("gold_categories.name as category_name, COUNT(*) as count, COALESCE(SUM("+stockWeightExpr("stocks", "products")+"), 0) as weight").
+		Joins("JOIN products ON products.id = stocks.product_id").
+		Joins("JOIN gold_categories ON gold_categories.id = products.gold_category_id").
+		Where("stocks.status = ?", models.StockStatusAvailable).
+		Group("gold_categories.name").
+		Scan(&summary.ByCategory)
+	for _, cat := range summary.ByCategory {
+		summary.TotalWeight += cat.Weight
+	}
+
+	return summary
+}
+
+// InStorageSubtree is a query scope on stocks restricted to a storage node and all its descendants
+func InStorageSubtree(box models.StorageBox) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("stocks.storage_box_id IN (?)",
+			database.DB.Model(&models.StorageBox{}).Select("id").Where("path LIKE ?", box.Path+"%"))
+	}
+}
+
+// storageSubtreeScope resolves a storage_box_id query param to a subtree scope
+func storageSubtreeScope(boxID string) (func(db *gorm.DB) *gorm.DB, error) {
+	var box models.StorageBox
+	if err := database.DB.First(&box, boxID).Error; err != nil {
+		return nil, err
+	}
+	return InStorageSubtree(box), nil
+}
+
+// storageBoxHasChildren checks whether a storage node has child nodes (i.e. is not a leaf)
+func storageBoxHasChildren(db *gorm.DB, boxID uint) bool {
+	var count int64
+	db.Model(&models.StorageBox{}).Where("parent_id = ?", boxID).Count(&count)
+	return count > 0
+}
+
+// setStorageBoxPath computes Path, PathCode and Depth of a box from its location and parent.
+// The box must already have an ID.
+func setStorageBoxPath(db *gorm.DB, box *models.StorageBox) error {
+	if box.ParentID == nil {
+		var location models.Location
+		if err := db.First(&location, box.LocationID).Error; err != nil {
+			return err
+		}
+		box.Path = fmt.Sprintf("/%d/", box.ID)
+		box.PathCode = location.Code + "/" + box.Code
+		box.Depth = 0
+		return nil
+	}
+
+	var parent models.StorageBox
+	if err := db.First(&parent, *box.ParentID).Error; err != nil {
+		return err
+	}
+	box.Path = fmt.Sprintf("%s%d/", parent.Path, box.ID)
+	box.PathCode = parent.PathCode + "/" + box.Code
+	box.Depth = parent.Depth + 1
+	return nil
+}
+
+// refreshStorageBoxSubtree recomputes the paths of a box and all of its descendants
+func refreshStorageBoxSubtree(db *gorm.DB, box *models.StorageBox) error {
+	if err := setStorageBoxPath(db, box); err != nil {
+		return err
+	}
+	if err := db.Model(box).Updates(map[string]interface{}{
+		"path":      box.Path,
+		"path_code": box.PathCode,
+		"depth":     box.Depth,
+	}).Error; err != nil {
+		return err
+	}
+
+	var children []models.StorageBox
+	if err := db.Where("parent_id = ?", box.ID).Find(&children).Error; err != nil {
+		return err
+	}
+	for i := range children {
+		if err := refreshStorageBoxSubtree(db, &children[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStorageBoxParent checks that a box can be placed under parentID
+func validateStorageBoxParent(db *gorm.DB, boxID uint, locationID uint, parentID uint) error {
+	var parent models.StorageBox
+	if err := db.First(&parent, parentID).Error; err != nil {
+		return fmt.Errorf("Parent storage box not found")
+	}
+	if parent.LocationID != locationID {
+		return fmt.Errorf("Parent storage box belongs to another location")
+	}
+	if boxID != 0 && (parent.ID == boxID || strings.Contains(parent.Path, fmt.Sprintf("/%d/", boxID))) {
+		return fmt.Errorf("Storage box cannot be moved under itself")
+	}
+
+	// Stock only lives on leaf nodes, so a node holding stock cannot get children
+	var stockCount int64
+	db.Model(&models.Stock{}).Where("storage_box_id = ? AND status <> ?", parent.ID, models.StockStatusSold).Count(&stockCount)
+	if stockCount > 0 {
+		return fmt.Errorf("Parent storage box still holds stock, move the stock to a child node first")
+	}
+	return nil
+}
+
 type CreateStorageBoxRequest struct {
-	LocationID  uint   `json:"location_id" binding:"required"`
-	Code        string `json:"code" binding:"required"`
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Capacity    int    `json:"capacity"`
-	IsActive    *bool  `json:"is_active"`
+	LocationID  uint                  `json:"location_id" binding:"required"`
+	ParentID    *uint                 `json:"parent_id"`
+	Type        models.StorageBoxType `json:"type"`
+	Code        string                `json:"code" binding:"required"`
+	Name        string                `json:"name" binding:"required"`
+	Description string                `json:"description"`
+	Capacity    int                   `json:"capacity"`
+	IsActive    *bool                 `json:"is_active"`
 }
 
 // CreateStorageBox creates a new storage box
@@ -198,6 +501,29 @@ func CreateStorageBox(c *gin.Context) {
 		return
 	}
 
+	if strings.Contains(req.Code, "/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Storage box code cannot contain '/'"})
+		return
+	}
+
+	boxType := models.StorageBoxTypeBox
+	if req.Type != "" {
+		if !req.Type.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid storage box type"})
+			return
+		}
+		boxType = req.Type
+	}
+
+	if req.ParentID != nil && *req.ParentID > 0 {
+		if err := validateStorageBoxParent(database.DB, 0, req.LocationID, *req.ParentID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		req.ParentID = nil
+	}
+
 	isActive := true
 	if req.IsActive != nil {
 		isActive = *req.IsActive
@@ -205,6 +531,8 @@ func CreateStorageBox(c *gin.Context) {
 
 	box := models.StorageBox{
 		LocationID:  req.LocationID,
+		ParentID:    req.ParentID,
+		Type:        boxType,
 		Code:        req.Code,
 		Name:        req.Name,
 		Description: req.Description,
@@ -212,22 +540,34 @@ func CreateStorageBox(c *gin.Context) {
 		IsActive:    isActive,
 	}
 
-	if err := database.DB.Create(&box).Error; err != nil {
+	tx := database.DB.Begin()
+	if err := tx.Create(&box).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.DB.Preload("Location").First(&box, box.ID)
+	// Path needs the generated ID
+	if err := refreshStorageBoxSubtree(tx, &box); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("Parent").First(&box, box.ID)
 	c.JSON(http.StatusCreated, gin.H{"data": box})
 }
 
 type UpdateStorageBoxRequest struct {
-	LocationID  uint   `json:"location_id"`
-	Code        string `json:"code"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Capacity    int    `json:"capacity"`
-	IsActive    *bool  `json:"is_active"`
+	LocationID  uint                  `json:"location_id"`
+	ParentID    *uint                 `json:"parent_id"` // 0 = move to top level
+	Type        models.StorageBoxType `json:"type"`
+	Code        string                `json:"code"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Capacity    int                   `json:"capacity"`
+	IsActive    *bool                 `json:"is_active"`
 }
 
 // UpdateStorageBox updates an existing storage box
@@ -245,11 +585,51 @@ func UpdateStorageBox(c *gin.Context) {
 		return
 	}
 
-	if req.LocationID > 0 {
+	pathChanged := false
+
+	if req.LocationID > 0 && req.LocationID != box.LocationID {
+		if storageBoxHasChildren(database.DB, box.ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a storage box with children to another location"})
+			return
+		}
+		// Stok di kotak tercatat di lokasi lama; pindahkan stoknya dulu
+		var stockCount int64
+		database.DB.Model(&models.Stock{}).Where("storage_box_id = ? AND status <> ?", box.ID, models.StockStatusSold).Count(&stockCount)
+		if stockCount > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot move a storage box that still holds stock to another location"})
+			return
+		}
 		box.LocationID = req.LocationID
+		box.ParentID = nil
+		pathChanged = true
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			box.ParentID = nil
+		} else {
+			if err := validateStorageBoxParent(database.DB, box.ID, box.LocationID, *req.ParentID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			box.ParentID = req.ParentID
+		}
+		box.Parent = nil
+		pathChanged = true
 	}
-	if req.Code != "" {
+	if req.Type != "" {
+		if !req.Type.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid storage box type"})
+			return
+		}
+		box.Type = req.Type
+	}
+	if req.Code != "" && req.Code != box.Code {
+		if strings.Contains(req.Code, "/") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Storage box code cannot contain '/'"})
+			return
+		}
 		box.Code = req.Code
+		pathChanged = true
 	}
 	if req.Name != "" {
 		box.Name = req.Name
@@ -264,18 +644,37 @@ func UpdateStorageBox(c *gin.Context) {
 		box.IsActive = *req.IsActive
 	}
 
-	if err := database.DB.Save(&box).Error; err != nil {
+	tx := database.DB.Begin()
+	if err := tx.Save(&box).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.DB.Preload("Location").First(&box, box.ID)
+	if pathChanged {
+		if err := refreshStorageBoxSubtree(tx, &box); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("Parent").First(&box, box.ID)
 	c.JSON(http.StatusOK, gin.H{"data": box})
 }
 
 // DeleteStorageBox deletes a storage box
 func DeleteStorageBox(c *gin.Context) {
 	id := c.Param("id")
+
+	var childCount int64
+	database.DB.Model(&models.StorageBox{}).Where("parent_id = ?", id).Count(&childCount)
+	if childCount > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete storage box that still has child nodes"})
+		return
+	}
+
 	if err := database.DB.Delete(&models.StorageBox{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
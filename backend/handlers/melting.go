@@ -0,0 +1,679 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== MELT BATCHES (LEBUR) ====================
+
+// GetMeltBatches returns melt batches
+func GetMeltBatches(c *gin.Context) {
+	var batches []models.MeltBatch
+	query := database.DB.Preload("Location").Preload("CreatedBy")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if shipmentID := c.Query("refinery_shipment_id"); shipmentID != "" {
+		query = query.Where("refinery_shipment_id = ?", shipmentID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&batches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": batches})
+}
+
+// GetMeltBatch returns a melt batch with its raw materials
+func GetMeltBatch(c *gin.Context) {
+	id := c.Param("id")
+	var batch models.MeltBatch
+	if err := database.DB.Preload("Location").Preload("CreatedBy").Preload("MeltedBy").
+		Preload("Items").Preload("Items.RawMaterial").Preload("Items.RawMaterial.GoldCategory").
+		First(&batch, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Melt batch not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": batch})
+}
+
+type CreateMeltBatchRequest struct {
+	LocationID     uint   `json:"location_id" binding:"required"`
+	RawMaterialIDs []uint `json:"raw_material_ids" binding:"required,min=1"`
+	Notes          string `json:"notes"`
+}
+
+// CreateMeltBatch groups available raw materials into a draft melt batch
+func CreateMeltBatch(c *gin.Context) {
+	var req CreateMeltBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var rawMaterials []models.RawMaterial
+	if err := tx.Preload("GoldCategory").Where("id IN ?", req.RawMaterialIDs).Find(&rawMaterials).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rawMaterials) != len(req.RawMaterialIDs) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some raw materials were not found"})
+		return
+	}
+
+	batch := models.MeltBatch{
+		BatchNumber: generateTransactionCode("LB"),
+		LocationID:  req.LocationID,
+		Status:      models.MeltBatchStatusDraft,
+		CreatedByID: currentUserID,
+		Notes:       req.Notes,
+	}
+
+	var expectedShrinkage float64
+	for _, rm := range rawMaterials {
+		if rm.Status != models.RawMaterialStatusAvailable {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Raw material %s is not available", rm.Code)})
+			return
+		}
+		if rm.LocationID != req.LocationID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Raw material %s is at another location", rm.Code)})
+			return
+		}
+		if batchNumber := meltBatchOfRawMaterial(tx, rm.ID); batchNumber != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Raw material %s is already in batch %s", rm.Code, batchNumber)})
+			return
+		}
+		if orderNumber := productionOrderOfInput(tx, "raw_material_id", rm.ID); orderNumber != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Raw material %s is used by production order %s", rm.Code, orderNumber)})
+			return
+		}
+
+		item := models.MeltBatchItem{
+			RawMaterialID:      rm.ID,
+			WeightGross:        rm.WeightGross,
+			WeightGrams:        rm.WeightGrams,
+			ShrinkagePercent:   rm.ShrinkagePercent,
+			Purity:             rm.PurityFraction(),
+			ExpectedFineWeight: rm.FineWeight(),
+			Cost:               rm.TotalBuyPrice,
+		}
+		batch.Items = append(batch.Items, item)
+
+		batch.InputGrossWeight += item.WeightGross
+		batch.InputNetWeight += item.WeightGrams
+		batch.ExpectedFineWeight += item.ExpectedFineWeight
+		batch.TotalCost += item.Cost
+		expectedShrinkage += item.WeightGross * item.ShrinkagePercent / 100
+	}
+
+	if batch.InputGrossWeight > 0 {
+		batch.ExpectedShrinkagePercent = expectedShrinkage / batch.InputGrossWeight * 100
+	}
+
+	if err := tx.Create(&batch).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("Items").Preload("Items.RawMaterial").First(&batch, batch.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": batch})
+}
+
+type MeltBatchResultRequest struct {
+	OutputWeight  float64    `json:"output_weight" binding:"required,gt=0"`
+	AssayedPurity float64    `json:"assayed_purity" binding:"required,gt=0,lte=100"` // Kadar hasil uji (%)
+	MeltedAt      *time.Time `json:"melted_at"`
+	Notes         string     `json:"notes"`
+}
+
+// RecordMeltResult records the output weight and assay of a batch and marks its raw materials processed
+func RecordMeltResult(c *gin.Context) {
+	id := c.Param("id")
+	var req MeltBatchResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	// Kunci batch agar hasil lebur tidak dicatat dua kali
+	var batch models.MeltBatch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&batch, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Melt batch not found"})
+		return
+	}
+
+	if batch.Status != models.MeltBatchStatusDraft {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft melt batches can be melted"})
+		return
+	}
+	if req.OutputWeight > batch.InputGrossWeight {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Output weight cannot exceed the input gross weight"})
+		return
+	}
+
+	meltedAt := time.Now()
+	if req.MeltedAt != nil {
+		meltedAt = *req.MeltedAt
+	}
+
+	batch.Status = models.MeltBatchStatusMelted
+	batch.MeltedAt = &meltedAt
+	batch.MeltedByID = &currentUserID
+	batch.OutputWeight = req.OutputWeight
+	batch.AssayedPurity = req.AssayedPurity
+	if req.Notes != "" {
+		batch.Notes = req.Notes
+	}
+	calculateMeltShrinkage(&batch)
+
+	if err := tx.Omit("Items").Save(&batch).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rawMaterialIDs := make([]uint, len(batch.Items))
+	for i, item := range batch.Items {
+		rawMaterialIDs[i] = item.RawMaterialID
+	}
+
+	// Selama batch masih draft bahan baku bisa diubah, dijual atau dihapus; pastikan semuanya masih tersedia
+	var lockedMaterials []models.RawMaterial
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", rawMaterialIDs).Find(&lockedMaterials).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(lockedMaterials) != len(rawMaterialIDs) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some raw materials of this batch were deleted"})
+		return
+	}
+	for _, material := range lockedMaterials {
+		if material.Status != models.RawMaterialStatusAvailable {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Raw material %s is %s, not available", material.Code, material.Status)})
+			return
+		}
+	}
+
+	if err := tx.Model(&models.RawMaterial{}).Where("id IN ?", rawMaterialIDs).Updates(map[string]interface{}{
+		"status":       models.RawMaterialStatusProcessed,
+		"processed_at": meltedAt,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Bahan baku berubah menjadi hasil lebur; yang tercatat hanya susut/selisihnya
+	var rawMaterials []models.RawMaterial
+	tx.Preload("GoldCategory").Where("id IN ?", rawMaterialIDs).Find(&rawMaterials)
+	var inputWeight, inputFine float64
+	for i := range rawMaterials {
+		inputWeight += rawMaterials[i].WeightGrams
+		inputFine += rawMaterials[i].FineWeight()
+	}
+	if err := recordFineGold(tx, models.FineGoldMovement{
+		LocationID:      batch.LocationID,
+		Type:            models.FineGoldMovementMelt,
+		GrossWeight:     batch.OutputWeight - inputWeight,
+		Purity:          batch.AssayedPurity / 100,
+		FineWeight:      batch.ActualFineWeight - inputFine,
+		ReferenceType:   "melt_batch",
+		ReferenceID:     batch.ID,
+		ReferenceNumber: batch.BatchNumber,
+		OccurredAt:      meltedAt,
+		CreatedByID:     &currentUserID,
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": batch})
+}
+
+// CancelMeltBatch cancels a draft batch and frees its raw materials
+func CancelMeltBatch(c *gin.Context) {
+	id := c.Param("id")
+	var batch models.MeltBatch
+	if err := database.DB.First(&batch, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Melt batch not found"})
+		return
+	}
+
+	// Hanya batch yang masih draft saat diperbarui yang dibatalkan
+	result := database.DB.Model(&models.MeltBatch{}).
+		Where("id = ? AND status = ?", batch.ID, models.MeltBatchStatusDraft).
+		Update("status", models.MeltBatchStatusCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft melt batches can be cancelled"})
+		return
+	}
+	batch.Status = models.MeltBatchStatusCancelled
+	c.JSON(http.StatusOK, gin.H{"data": batch})
+}
+
+// calculateMeltShrinkage compares the actual melt loss with the shrinkage expected from the raw materials
+func calculateMeltShrinkage(batch *models.MeltBatch) {
+	batch.ActualFineWeight = batch.OutputWeight * batch.AssayedPurity / 100
+	if batch.InputGrossWeight > 0 {
+		batch.ActualShrinkagePercent = (batch.InputGrossWeight - batch.OutputWeight) / batch.InputGrossWeight * 100
+	}
+	// Berat bersih bahan baku = berat kotor - susut taksiran
+	batch.ShrinkageVarianceWeight = batch.OutputWeight - batch.InputNetWeight
+	batch.FineWeightVariance = batch.ActualFineWeight - batch.ExpectedFineWeight
+}
+
+// meltBatchOfRawMaterial returns the number of an open batch that already contains the raw material
+func meltBatchOfRawMaterial(db *gorm.DB, rawMaterialID uint) string {
+	var batchNumber string
+	db.Table("melt_batch_items").
+		Select("melt_batches.batch_number").
+		Joins("JOIN melt_batches ON melt_batches.id = melt_batch_items.melt_batch_id").
+		Where("melt_batch_items.raw_material_id = ? AND melt_batch_items.deleted_at IS NULL", rawMaterialID).
+		Where("melt_batches.status <> ? AND melt_batches.deleted_at IS NULL", models.MeltBatchStatusCancelled).
+		Limit(1).
+		Scan(&batchNumber)
+	return batchNumber
+}
+
+// ==================== REFINERY SHIPMENTS ====================
+
+// GetRefineryShipments returns refinery shipments
+func GetRefineryShipments(c *gin.Context) {
+	var shipments []models.RefineryShipment
+	query := database.DB.Preload("Location").Preload("Supplier").Preload("SentBy")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	if err := query.Order("sent_at DESC").Find(&shipments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shipments})
+}
+
+// GetRefineryShipment returns a refinery shipment with its batches and per-batch gain/loss
+func GetRefineryShipment(c *gin.Context) {
+	id := c.Param("id")
+	var shipment models.RefineryShipment
+	if err := database.DB.Preload("Location").Preload("Supplier").Preload("SentBy").
+		Preload("Batches").First(&shipment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refinery shipment not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shipment})
+}
+
+type CreateRefineryShipmentRequest struct {
+	SupplierID   *uint  `json:"supplier_id"`
+	RefineryName string `json:"refinery_name"`
+	LocationID   uint   `json:"location_id" binding:"required"`
+	MeltBatchIDs []uint `json:"melt_batch_ids" binding:"required,min=1"`
+	Notes        string `json:"notes"`
+}
+
+// CreateRefineryShipment sends melted batches to a refinery
+func CreateRefineryShipment(c *gin.Context) {
+	var req CreateRefineryShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	refineryName := req.RefineryName
+	if req.SupplierID != nil {
+		var supplier models.Supplier
+		if err := database.DB.First(&supplier, *req.SupplierID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier not found"})
+			return
+		}
+		if refineryName == "" {
+			refineryName = supplier.Name
+		}
+	}
+	if refineryName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refinery_name or supplier_id is required"})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var batches []models.MeltBatch
+	if err := tx.Where("id IN ?", req.MeltBatchIDs).Find(&batches).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(batches) != len(req.MeltBatchIDs) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some melt batches were not found"})
+		return
+	}
+
+	shipment := models.RefineryShipment{
+		ShipmentNumber: generateTransactionCode("RS"),
+		SupplierID:     req.SupplierID,
+		RefineryName:   refineryName,
+		LocationID:     req.LocationID,
+		Status:         models.RefineryShipmentStatusSent,
+		SentAt:         time.Now(),
+		SentByID:       currentUserID,
+		Notes:          req.Notes,
+	}
+
+	for _, batch := range batches {
+		if batch.Status != models.MeltBatchStatusMelted {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Melt batch %s is %s, only melted batches can be shipped", batch.BatchNumber, batch.Status)})
+			return
+		}
+		if batch.LocationID != req.LocationID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Melt batch %s is at another location", batch.BatchNumber)})
+			return
+		}
+		if orderNumber := productionOrderOfInput(tx, "melt_batch_id", batch.ID); orderNumber != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Melt batch %s is used by production order %s", batch.BatchNumber, orderNumber)})
+			return
+		}
+		shipment.TotalWeight += batch.OutputWeight
+		shipment.TotalFineWeight += batch.ActualFineWeight
+		shipment.TotalCost += batch.TotalCost
+	}
+
+	if err := tx.Create(&shipment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Model(&models.MeltBatch{}).Where("id IN ?", req.MeltBatchIDs).Updates(map[string]interface{}{
+		"status":               models.MeltBatchStatusShipped,
+		"refinery_shipment_id": shipment.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := recordRefineryFineGold(tx, shipment, -1, currentUserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("Supplier").Preload("Batches").First(&shipment, shipment.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": shipment})
+}
+
+type SettleRefineryShipmentRequest struct {
+	SettlementType       string  `json:"settlement_type" binding:"required,oneof=cash fine_gold mixed"`
+	CreditedFineWeight   float64 `json:"credited_fine_weight" binding:"required,gt=0"` // Emas murni menurut uji refinery
+	SettlementCash       float64 `json:"settlement_cash"`
+	SettlementFineWeight float64 `json:"settlement_fine_weight"`
+	FineGoldPricePerGram float64 `json:"fine_gold_price_per_gram"`
+	RefiningFee          float64 `json:"refining_fee"`     // Sudah dipotong dari uang/emas yang diterima, hanya dicatat
+	GoldCategoryID       *uint   `json:"gold_category_id"` // Kategori untuk emas murni yang diterima (24K)
+	Notes                string  `json:"notes"`
+}
+
+// SettleRefineryShipment records what the refinery paid and allocates the gain/loss to each batch
+func SettleRefineryShipment(c *gin.Context) {
+	id := c.Param("id")
+	var req SettleRefineryShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settlementType := models.SettlementType(req.SettlementType)
+	if req.RefiningFee < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refining_fee cannot be negative"})
+		return
+	}
+	if settlementType != models.SettlementTypeFineGold && req.SettlementCash <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "settlement_cash is required for cash settlements"})
+		return
+	}
+	if settlementType != models.SettlementTypeCash {
+		if req.SettlementFineWeight <= 0 || req.FineGoldPricePerGram <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "settlement_fine_weight and fine_gold_price_per_gram are required for fine gold settlements"})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	// Kunci pengiriman agar penyelesaian/pembatalan bersamaan tidak dibukukan dua kali
+	var shipment models.RefineryShipment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Batches").First(&shipment, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refinery shipment not found"})
+		return
+	}
+
+	if shipment.Status != models.RefineryShipmentStatusSent {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only sent shipments can be settled"})
+		return
+	}
+
+	now := time.Now()
+	shipment.Status = models.RefineryShipmentStatusSettled
+	shipment.SettlementType = settlementType
+	shipment.SettledAt = &now
+	shipment.SettledByID = &currentUserID
+	shipment.CreditedFineWeight = req.CreditedFineWeight
+	shipment.SettlementCash = req.SettlementCash
+	shipment.SettlementFineWeight = req.SettlementFineWeight
+	shipment.FineGoldPricePerGram = req.FineGoldPricePerGram
+	shipment.RefiningFee = req.RefiningFee
+	shipment.SettlementValue = req.SettlementCash + req.SettlementFineWeight*req.FineGoldPricePerGram
+	// Refinery memotong ongkosnya dari uang/emas yang diserahkan, jadi nilai penyelesaian sudah bersih;
+	// mengurangi RefiningFee lagi akan menghitung ongkos dua kali
+	shipment.GainLoss = shipment.SettlementValue - shipment.TotalCost
+	if req.Notes != "" {
+		shipment.Notes = req.Notes
+	}
+
+	// Emas murni yang diterima masuk sebagai bahan baku 24K
+	if req.SettlementFineWeight > 0 {
+		rawMaterial := models.RawMaterial{
+			Code:            GenerateRawMaterialCode(),
+			GoldCategoryID:  req.GoldCategoryID,
+			LocationID:      shipment.LocationID,
+			WeightGross:     req.SettlementFineWeight,
+			WeightGrams:     req.SettlementFineWeight,
+			Purity:          99.9,
+			BuyPricePerGram: req.FineGoldPricePerGram,
+			TotalBuyPrice:   req.SettlementFineWeight * req.FineGoldPricePerGram,
+			Condition:       models.RawMaterialConditionNew,
+			Status:          models.RawMaterialStatusAvailable,
+			SupplierName:    shipment.RefineryName,
+			ReceivedAt:      &now,
+			ReceivedByID:    &currentUserID,
+			WeightSource:    models.WeightSourceTyped,
+			Notes:           fmt.Sprintf("Penyelesaian refinery %s", shipment.ShipmentNumber),
+		}
+		if err := tx.Create(&rawMaterial).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create raw material: " + err.Error()})
+			return
+		}
+		shipment.RawMaterialID = &rawMaterial.ID
+
+		if err := recordRawMaterialFineGold(tx, rawMaterial, models.FineGoldMovementRefinery, 1, "refinery_shipment", shipment.ID, shipment.ShipmentNumber, &currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Alokasikan nilai dan emas murni yang diakui ke tiap batch sesuai porsi emas murninya
+	var batchIDs []uint
+	for i := range shipment.Batches {
+		batch := &shipment.Batches[i]
+		share := 1 / float64(len(shipment.Batches))
+		if shipment.TotalFineWeight > 0 {
+			share = batch.ActualFineWeight / shipment.TotalFineWeight
+		}
+		batch.Status = models.MeltBatchStatusSettled
+		batch.CreditedFineWeight = shipment.CreditedFineWeight * share
+		batch.SettlementValue = shipment.SettlementValue * share
+		batch.GainLoss = batch.SettlementValue - batch.TotalCost
+		if err := tx.Omit("Items").Save(batch).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		batchIDs = append(batchIDs, batch.ID)
+	}
+
+	if err := tx.Omit("Batches").Save(&shipment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Bahan baku yang dilebur dianggap terjual ke refinery
+	if len(batchIDs) > 0 {
+		if err := tx.Model(&models.RawMaterial{}).
+			Where("id IN (?)", tx.Model(&models.MeltBatchItem{}).Select("raw_material_id").Where("melt_batch_id IN ?", batchIDs)).
+			Update("status", models.RawMaterialStatusSold).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": shipment})
+}
+
+// CancelRefineryShipment returns the batches of an unsettled shipment to melted
+func CancelRefineryShipment(c *gin.Context) {
+	id := c.Param("id")
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	var shipment models.RefineryShipment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shipment, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refinery shipment not found"})
+		return
+	}
+
+	if shipment.Status != models.RefineryShipmentStatusSent {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only sent shipments can be cancelled"})
+		return
+	}
+
+	shipment.Status = models.RefineryShipmentStatusCancelled
+	if err := tx.Save(&shipment).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Model(&models.MeltBatch{}).Where("refinery_shipment_id = ?", shipment.ID).Updates(map[string]interface{}{
+		"status":               models.MeltBatchStatusMelted,
+		"refinery_shipment_id": nil,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := recordRefineryFineGold(tx, shipment, 1, currentUserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": shipment})
+}
+
+// recordRefineryFineGold books the melted gold of a shipment out of (sign -1) or back into (sign 1) the location
+func recordRefineryFineGold(tx *gorm.DB, shipment models.RefineryShipment, sign float64, userID uint) error {
+	return recordFineGold(tx, models.FineGoldMovement{
+		LocationID:      shipment.LocationID,
+		Type:            models.FineGoldMovementRefinery,
+		GrossWeight:     sign * shipment.TotalWeight,
+		FineWeight:      sign * shipment.TotalFineWeight,
+		ReferenceType:   "refinery_shipment",
+		ReferenceID:     shipment.ID,
+		ReferenceNumber: shipment.ShipmentNumber,
+		CreatedByID:     &userID,
+	})
+}
@@ -0,0 +1,487 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== DUPLICATE MEMBERS ====================
+
+// Bobot skor kemiripan pasangan member
+const (
+	duplicateScorePhone     = 40 // Nomor telepon sama (setelah dinormalisasi)
+	duplicateScoreIDNumber  = 50 // Nomor KTP sama
+	duplicateScoreBirthDate = 20 // Tanggal lahir sama
+	duplicateScoreName      = 30 // Dikalikan tingkat kemiripan nama
+	duplicateNameSimilarity = 0.8
+	duplicateDefaultMin     = 40
+)
+
+// normalizePhone keeps the digits of a phone number and writes the Indonesian prefix as 0 (+62 812 / 0812 / 812)
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	normalized := digits.String()
+	switch {
+	case strings.HasPrefix(normalized, "62"):
+		normalized = "0" + normalized[2:]
+	case strings.HasPrefix(normalized, "8"):
+		normalized = "0" + normalized
+	}
+	if len(normalized) < 8 {
+		return ""
+	}
+	return normalized
+}
+
+// normalizeIDNumber keeps the letters and digits of an ID number in upper case
+func normalizeIDNumber(idNumber string) string {
+	var normalized strings.Builder
+	for _, r := range strings.ToUpper(idNumber) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized.WriteRune(r)
+		}
+	}
+	return normalized.String()
+}
+
+// normalizeName lower-cases a name, drops punctuation and collapses spaces
+func normalizeName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// nameSimilarity returns 1 - edit distance / length of the longer name, ignoring word order
+func nameSimilarity(a, b string) float64 {
+	a, b = normalizeName(a), normalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	similarity := 1 - float64(levenshtein(a, b))/float64(max(len([]rune(a)), len([]rune(b))))
+
+	// "Siti Aminah" dan "Aminah Siti" dianggap sama
+	sortedA, sortedB := strings.Fields(a), strings.Fields(b)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	joinedA, joinedB := strings.Join(sortedA, " "), strings.Join(sortedB, " ")
+	if sorted := 1 - float64(levenshtein(joinedA, joinedB))/float64(max(len([]rune(joinedA)), len([]rune(joinedB)))); sorted > similarity {
+		similarity = sorted
+	}
+	return similarity
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// DuplicateMemberPair is a pair of members that are probably the same person
+type DuplicateMemberPair struct {
+	Score   int           `json:"score"`
+	Reasons []string      `json:"reasons"`
+	Member  models.Member `json:"member"`
+	Other   models.Member `json:"other"`
+}
+
+// scoreMemberPair scores how likely two members are the same person and explains the score
+func scoreMemberPair(a, b models.Member) (int, []string) {
+	score := 0
+	reasons := []string{}
+	if phone := normalizePhone(a.Phone); phone != "" && phone == normalizePhone(b.Phone) {
+		score += duplicateScorePhone
+		reasons = append(reasons, "phone")
+	}
+	if idNumber := normalizeIDNumber(a.IDNumber); idNumber != "" && idNumber == normalizeIDNumber(b.IDNumber) {
+		score += duplicateScoreIDNumber
+		reasons = append(reasons, "id_number")
+	}
+	if similarity := nameSimilarity(a.Name, b.Name); similarity >= duplicateNameSimilarity {
+		score += int(math.Round(duplicateScoreName * similarity))
+		reasons = append(reasons, fmt.Sprintf("name %.0f%%", similarity*100))
+	}
+	if a.BirthDate != nil && b.BirthDate != nil && a.BirthDate.Format("2006-01-02") == b.BirthDate.Format("2006-01-02") {
+		score += duplicateScoreBirthDate
+		reasons = append(reasons, "birth_date")
+	}
+	return score, reasons
+}
+
+// duplicateKeys returns the blocking keys of a member; only members sharing a key are compared
+func duplicateKeys(member models.Member) []string {
+	var keys []string
+	if phone := normalizePhone(member.Phone); phone != "" {
+		keys = append(keys, "phone:"+phone)
+	}
+	if idNumber := normalizeIDNumber(member.IDNumber); idNumber != "" {
+		keys = append(keys, "id:"+idNumber)
+	}
+	if member.BirthDate != nil {
+		keys = append(keys, "birth:"+member.BirthDate.Format("2006-01-02"))
+	}
+	// Awalan setiap kata nama, agar salah ketik di tengah/akhir nama tetap terbandingkan
+	for _, word := range strings.Fields(normalizeName(member.Name)) {
+		runes := []rune(word)
+		if len(runes) >= 3 {
+			keys = append(keys, "name:"+string(runes[:3]))
+		}
+	}
+	return keys
+}
+
+// findDuplicateMembers returns the candidate pairs with at least minScore, highest score first.
+// If memberID is not zero only pairs with that member are returned.
+func findDuplicateMembers(db *gorm.DB, memberID uint, minScore int) ([]DuplicateMemberPair, error) {
+	var members []models.Member
+	if err := db.Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string][]int)
+	for i, member := range members {
+		for _, key := range duplicateKeys(member) {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	pairs := []DuplicateMemberPair{}
+	seen := make(map[[2]int]bool)
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				pair := [2]int{block[x], block[y]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				a, b := members[pair[0]], members[pair[1]]
+				if memberID != 0 && a.ID != memberID && b.ID != memberID {
+					continue
+				}
+				score, reasons := scoreMemberPair(a, b)
+				if score < minScore {
+					continue
+				}
+				if b.ID == memberID {
+					a, b = b, a
+				}
+				pairs = append(pairs, DuplicateMemberPair{Score: score, Reasons: reasons, Member: a, Other: b})
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].Member.ID < pairs[j].Member.ID
+	})
+	return pairs, nil
+}
+
+// GetDuplicateMembers returns likely duplicate member pairs (?member_id, ?min_score, ?limit)
+func GetDuplicateMembers(c *gin.Context) {
+	minScore := duplicateDefaultMin
+	if value := c.Query("min_score"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_score"})
+			return
+		}
+		minScore = parsed
+	}
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	var memberID uint
+	if value := c.Query("member_id"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member_id"})
+			return
+		}
+		memberID = uint(parsed)
+	}
+
+	pairs, err := findDuplicateMembers(database.DB, memberID, minScore)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	total := len(pairs)
+	if len(pairs) > limit {
+		pairs = pairs[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pairs, "total": total})
+}
+
+type MergeMemberRequest struct {
+	DuplicateID uint   `json:"duplicate_id" binding:"required"` // Member yang digabung lalu dihapus
+	Notes       string `json:"notes"`
+}
+
+// MergeMember merges a duplicate into the member in the path: transactions, setor raw materials and quotations
+// move to the surviving member, the point balance moves through adjust entries on both ledgers, the stats
+// are recomputed and the duplicate is deleted
+func MergeMember(c *gin.Context) {
+	survivorID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return
+	}
+	var req MergeMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DuplicateID == uint(survivorID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A member cannot be merged into itself"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	rules, err := loadMembershipRules(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	// Kunci kedua member berurutan id agar dua penggabungan bersamaan tidak saling tunggu
+	var members []models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", []uint{uint(survivorID), req.DuplicateID}).
+		Order("id").Find(&members).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(members) != 2 {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	survivor, duplicate := members[0], members[1]
+	if survivor.ID != uint(survivorID) {
+		survivor, duplicate = duplicate, survivor
+	}
+
+	score, reasons := scoreMemberPair(survivor, duplicate)
+	merge := models.MemberMerge{
+		SurvivorID:   survivor.ID,
+		MergedID:     duplicate.ID,
+		MergedCode:   duplicate.MemberCode,
+		MergedName:   duplicate.Name,
+		Snapshot:     duplicate,
+		PointsMoved:  duplicate.Points,
+		Score:        score,
+		Reasons:      reasons,
+		FilledFields: []string{},
+		Notes:        req.Notes,
+		MergedByID:   currentUserID,
+	}
+
+	// Pindahkan semua data milik duplikat, termasuk yang sudah dihapus
+	moves := []struct {
+		model interface{}
+		count *int
+	}{
+		{&models.Transaction{}, &merge.TransactionsMoved},
+		{&models.RawMaterial{}, &merge.RawMaterialsMoved},
+		{&models.Quotation{}, &merge.QuotationsMoved},
+	}
+	for _, move := range moves {
+		result := tx.Unscoped().Model(move.model).Where("member_id = ?", duplicate.ID).Update("member_id", survivor.ID)
+		if result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+		*move.count = int(result.RowsAffected)
+	}
+
+	// Data kontak yang kosong di member utama diisi dari duplikat
+	if survivor.Phone == "" && duplicate.Phone != "" {
+		survivor.Phone = duplicate.Phone
+		merge.FilledFields = append(merge.FilledFields, "phone")
+	}
+	if survivor.Email == "" && duplicate.Email != "" {
+		survivor.Email = duplicate.Email
+		merge.FilledFields = append(merge.FilledFields, "email")
+	}
+	if survivor.Address == "" && duplicate.Address != "" {
+		survivor.Address = duplicate.Address
+		merge.FilledFields = append(merge.FilledFields, "address")
+	}
+	if survivor.IDNumber == "" && duplicate.IDNumber != "" {
+		survivor.IDNumber = duplicate.IDNumber
+		merge.FilledFields = append(merge.FilledFields, "id_number")
+	}
+	if survivor.BirthDate == nil && duplicate.BirthDate != nil {
+		survivor.BirthDate = duplicate.BirthDate
+		merge.FilledFields = append(merge.FilledFields, "birth_date")
+	}
+	if duplicate.JoinDate.Before(survivor.JoinDate) {
+		survivor.JoinDate = duplicate.JoinDate
+		merge.FilledFields = append(merge.FilledFields, "join_date")
+	}
+
+	if err := tx.Create(&merge).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ledger lama tidak diubah; saldo duplikat dipindahkan per lot agar masa berlakunya ikut
+	moved, err := moveMemberPoints(tx, duplicate, survivor, merge, currentUserID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	merge.PointEntriesMoved = moved
+	if err := tx.Model(&merge).Update("point_entries_moved", moved).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := recalculateMember(tx, rules, &survivor, time.Now()); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Save(&survivor).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Model(&duplicate).Updates(map[string]interface{}{"is_active": false, "points": 0}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Delete(&duplicate).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Survivor").Preload("MergedBy").First(&merge, merge.ID)
+	c.JSON(http.StatusOK, gin.H{"data": merge, "message": fmt.Sprintf("Member %s merged into %s", duplicate.MemberCode, survivor.MemberCode)})
+}
+
+// moveMemberPoints moves the point balance of a merged duplicate to the survivor. Each lot with points left
+// becomes an adjust pair: out of the duplicate referring to the lot, into the survivor with the lot's expiry.
+// It returns the number of lots moved.
+func moveMemberPoints(tx *gorm.DB, duplicate, survivor models.Member, merge models.MemberMerge, userID uint) (int, error) {
+	if duplicate.Points == 0 {
+		return 0, nil
+	}
+	lots, err := memberPointLots(tx, duplicate.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	notes := fmt.Sprintf("Penggabungan %s ke %s", duplicate.MemberCode, survivor.MemberCode)
+	move := func(points int, lot *models.MemberPointEntry) error {
+		out := models.MemberPointEntry{MemberID: duplicate.ID, Type: models.MemberPointAdjust, Points: -points,
+			MemberMergeID: &merge.ID, Notes: notes, CreatedByID: &userID}
+		in := models.MemberPointEntry{MemberID: survivor.ID, Type: models.MemberPointAdjust, Points: points,
+			MemberMergeID: &merge.ID, Notes: notes, CreatedByID: &userID}
+		if lot != nil {
+			out.ReferenceID = &lot.ID
+			in.ReferenceID = &lot.ID
+			in.ExpiresAt = lot.ExpiresAt
+		}
+		if _, err := postMemberPoints(tx, out); err != nil {
+			return err
+		}
+		_, err := postMemberPoints(tx, in)
+		return err
+	}
+
+	moved, left := 0, duplicate.Points
+	for i := range lots {
+		if left <= 0 {
+			break
+		}
+		points := min(lots[i].Remaining, left)
+		if points <= 0 {
+			continue
+		}
+		if err := move(points, &lots[i].Entry); err != nil {
+			return moved, err
+		}
+		moved++
+		left -= points
+	}
+	// Sisa saldo tanpa lot (contoh: saldo negatif dari koreksi) tetap dipindahkan apa adanya
+	if left != 0 {
+		if err := move(left, nil); err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+// GetMemberMerges returns the merge audit records, newest first (?member_id = surviving member)
+func GetMemberMerges(c *gin.Context) {
+	var merges []models.MemberMerge
+	query := database.DB.Preload("Survivor").Preload("MergedBy").Order("created_at DESC")
+	if memberID := c.Query("member_id"); memberID != "" {
+		query = query.Where("survivor_id = ?", memberID)
+	}
+	if err := query.Limit(100).Find(&merges).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": merges})
+}
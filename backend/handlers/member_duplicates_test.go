@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"starter/backend/models"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name  string
+		phone string
+		want  string
+	}{
+		{name: "plus 62 with separators", phone: "+62 812-3456-7890", want: "081234567890"},
+		{name: "62 without plus", phone: "6281234567890", want: "081234567890"},
+		{name: "leading zero", phone: "0812 3456 7890", want: "081234567890"},
+		{name: "without prefix", phone: "812345678", want: "0812345678"},
+		{name: "landline", phone: "(021) 555-1234", want: "0215551234"},
+		{name: "too short", phone: "+62 812", want: ""},
+		{name: "only 62", phone: "+62", want: ""},
+		{name: "empty", phone: "", want: ""},
+		{name: "no digits", phone: "tidak ada", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizePhone(tt.phone); got != tt.want {
+				t.Errorf("normalizePhone(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "siti", b: "", want: 4},
+		{a: "", b: "siti", want: 4},
+		{a: "siti", b: "siti", want: 0},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "santoso", b: "santosa", want: 1},
+		{a: "josé", b: "jose", want: 1},
+		{a: "日本", b: "日本語", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "same", a: "Siti Aminah", b: "Siti Aminah", want: 1},
+		{name: "case and punctuation", a: "SITI  AMINAH.", b: "siti aminah", want: 1},
+		{name: "word order", a: "Siti Aminah", b: "Aminah Siti", want: 1},
+		{name: "one typo", a: "Budi Santoso", b: "Budi Santosa", want: 1 - 1.0/12},
+		{name: "multibyte counted as runes", a: "José", b: "Jose", want: 0.75},
+		{name: "different", a: "Budi", b: "Siti", want: 0.25},
+		{name: "first empty", a: "", b: "Siti", want: 0},
+		{name: "second only punctuation", a: "Siti", b: " .- ", want: 0},
+		{name: "both empty", a: "", b: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreMemberPair(t *testing.T) {
+	birth := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	birthLater := time.Date(1990, 5, 17, 13, 30, 0, 0, time.UTC)
+	otherBirth := time.Date(1991, 5, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		a, b    models.Member
+		score   int
+		reasons []string
+	}{
+		{
+			name:    "phone with different prefixes",
+			a:       models.Member{Name: "Budi", Phone: "+62 812-3456-7890"},
+			b:       models.Member{Name: "Siti", Phone: "081234567890"},
+			score:   duplicateScorePhone,
+			reasons: []string{"phone"},
+		},
+		{
+			name:    "id number with separators",
+			a:       models.Member{Name: "Budi", IDNumber: "3201-0101-9000-0001"},
+			b:       models.Member{Name: "Siti", IDNumber: "3201010190000001"},
+			score:   duplicateScoreIDNumber,
+			reasons: []string{"id_number"},
+		},
+		{
+			name:    "name above threshold is weighted",
+			a:       models.Member{Name: "Budi Santoso"},
+			b:       models.Member{Name: "Budi Santosa"},
+			score:   28, // 30 x 0.917
+			reasons: []string{"name 92%"},
+		},
+		{
+			name:    "same birth date ignores time of day",
+			a:       models.Member{Name: "Budi", BirthDate: &birth},
+			b:       models.Member{Name: "Siti", BirthDate: &birthLater},
+			score:   duplicateScoreBirthDate,
+			reasons: []string{"birth_date"},
+		},
+		{
+			name:    "everything matches",
+			a:       models.Member{Name: "Siti Aminah", Phone: "+6281234567890", IDNumber: "3201010190000001", BirthDate: &birth},
+			b:       models.Member{Name: "Aminah Siti", Phone: "081234567890", IDNumber: "3201010190000001", BirthDate: &birth},
+			score:   duplicateScorePhone + duplicateScoreIDNumber + duplicateScoreName + duplicateScoreBirthDate,
+			reasons: []string{"phone", "id_number", "name 100%", "birth_date"},
+		},
+		{
+			name:    "empty fields never match",
+			a:       models.Member{},
+			b:       models.Member{},
+			score:   0,
+			reasons: []string{},
+		},
+		{
+			name:    "nothing in common",
+			a:       models.Member{Name: "Budi", Phone: "081234567890", BirthDate: &birth},
+			b:       models.Member{Name: "Siti", Phone: "081298765432", BirthDate: &otherBirth},
+			score:   0,
+			reasons: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reasons := scoreMemberPair(tt.a, tt.b)
+			if score != tt.score {
+				t.Errorf("score = %d, want %d", score, tt.score)
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("reasons = %v, want %v", reasons, tt.reasons)
+			}
+		})
+	}
+}
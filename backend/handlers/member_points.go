@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== MEMBER POINT LEDGER ====================
+
+// MemberPointSettings controls the value and lifetime of member points
+type MemberPointSettings struct {
+	RedeemValue  float64 // Nilai rupiah satu poin saat ditukar di penjualan, 0 = penukaran nonaktif
+	ExpiryMonths int     // Poin kedaluwarsa setelah sekian bulan, 0 = tidak pernah
+}
+
+var memberPointSettings = MemberPointSettings{RedeemValue: 1000, ExpiryMonths: 12}
+
+// SetMemberPointSettings sets the redemption value and expiry of member points
+func SetMemberPointSettings(settings MemberPointSettings) {
+	memberPointSettings = settings
+}
+
+var errInsufficientPoints = errors.New("Insufficient points")
+
+// postMemberPoints appends an entry to the member's point ledger and updates the member's balance.
+// Only redemptions are refused when the balance would go below zero.
+func postMemberPoints(tx *gorm.DB, entry models.MemberPointEntry) (models.MemberPointEntry, error) {
+	var member models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&member, entry.MemberID).Error; err != nil {
+		return entry, fmt.Errorf("Member ID %d not found", entry.MemberID)
+	}
+
+	entry.Balance = member.Points + entry.Points
+	if entry.Type == models.MemberPointRedeem && entry.Balance < 0 {
+		return entry, fmt.Errorf("%w: balance is %d", errInsufficientPoints, member.Points)
+	}
+	// Hanya poin baru yang mendapat masa berlaku; pembalikan membawa masa berlaku lot asalnya
+	newPoints := (entry.Type == models.MemberPointEarn || entry.Type == models.MemberPointAdjust) && entry.MemberMergeID == nil
+	if entry.Points > 0 && newPoints && entry.ExpiresAt == nil && memberPointSettings.ExpiryMonths > 0 {
+		expiresAt := time.Now().AddDate(0, memberPointSettings.ExpiryMonths, 0)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	if err := tx.Create(&entry).Error; err != nil {
+		return entry, err
+	}
+	if err := tx.Model(&member).Update("points", entry.Balance).Error; err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// pointLot is a positive ledger entry with the points not yet used, expired or reversed
+type pointLot struct {
+	Entry     models.MemberPointEntry `json:"entry"`
+	Remaining int                     `json:"remaining"`
+}
+
+// pointUse is the part of a lot used up by a negative ledger entry
+type pointUse struct {
+	Lot    models.MemberPointEntry
+	Points int
+}
+
+// memberPointLots replays the ledger of a member. Negative entries use up the lot they refer to first,
+// then the oldest lots (FIFO).
+func memberPointLots(db *gorm.DB, memberID uint) ([]pointLot, error) {
+	lots, _, err := replayMemberPoints(db, memberID)
+	return lots, err
+}
+
+// replayMemberPoints replays the ledger like memberPointLots and also returns, per negative entry,
+// the lots it used up
+func replayMemberPoints(db *gorm.DB, memberID uint) ([]pointLot, map[uint][]pointUse, error) {
+	var entries []models.MemberPointEntry
+	if err := db.Where("member_id = ?", memberID).Order("id").Find(&entries).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var lots []pointLot
+	uses := make(map[uint][]pointUse)
+	take := func(entryID uint, lot *pointLot, need int) int {
+		used := min(need, lot.Remaining)
+		if used > 0 {
+			lot.Remaining -= used
+			uses[entryID] = append(uses[entryID], pointUse{Lot: lot.Entry, Points: used})
+		}
+		return need - used
+	}
+	for _, entry := range entries {
+		if entry.Points > 0 {
+			lots = append(lots, pointLot{Entry: entry, Remaining: entry.Points})
+			continue
+		}
+		need := -entry.Points
+		if entry.ReferenceID != nil {
+			for i := range lots {
+				if lots[i].Entry.ID == *entry.ReferenceID {
+					need = take(entry.ID, &lots[i], need)
+					break
+				}
+			}
+		}
+		for i := range lots {
+			if need == 0 {
+				break
+			}
+			need = take(entry.ID, &lots[i], need)
+		}
+	}
+	return lots, uses, nil
+}
+
+// expireMemberPoints writes an expire entry for the unused points of every lot past its expiry date
+func expireMemberPoints(db *gorm.DB, now time.Time) (int, error) {
+	var memberIDs []uint
+	if err := db.Model(&models.MemberPointEntry{}).
+		Where("points > 0 AND expires_at <= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM member_point_entries e WHERE e.reference_id = member_point_entries.id AND e.type = ?)", models.MemberPointExpire).
+		Distinct().Pluck("member_id", &memberIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, memberID := range memberIDs {
+		// Member dikunci dulu agar replay ledger tidak bentrok dengan transaksi yang sedang berjalan
+		tx := db.Begin()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Member{}, memberID).Error; err != nil {
+			tx.Rollback()
+			continue
+		}
+		lots, err := memberPointLots(tx, memberID)
+		if err != nil {
+			tx.Rollback()
+			return expired, err
+		}
+		memberExpired := 0
+		for _, lot := range lots {
+			if lot.Remaining <= 0 || lot.Entry.ExpiresAt == nil || lot.Entry.ExpiresAt.After(now) {
+				continue
+			}
+			lotID := lot.Entry.ID
+			if _, err := postMemberPoints(tx, models.MemberPointEntry{
+				MemberID:    memberID,
+				Type:        models.MemberPointExpire,
+				Points:      -lot.Remaining,
+				ReferenceID: &lotID,
+				Notes:       fmt.Sprintf("Poin dari %s kedaluwarsa", lot.Entry.CreatedAt.Format("2006-01-02")),
+			}); err != nil {
+				tx.Rollback()
+				return expired, err
+			}
+			memberExpired += lot.Remaining
+		}
+		if err := tx.Commit().Error; err != nil {
+			return expired, err
+		}
+		expired += memberExpired
+	}
+	return expired, nil
+}
+
+// StartMemberPointExpiryJob expires member points at start and then periodically
+func StartMemberPointExpiryJob(interval time.Duration) {
+	go func() {
+		run := func() {
+			expired, err := expireMemberPoints(database.DB, time.Now())
+			if err != nil {
+				log.Println("Member point expiry job failed:", err)
+			}
+			if expired > 0 {
+				log.Printf("Member point expiry job expired %d points", expired)
+			}
+		}
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// reverseTransactionPoints reverses the points earned and redeemed by a cancelled transaction.
+// Redeemed points are given back with the expiry of the lots they came from. Earned points are taken
+// back only as far as they are still unused, and never below a zero balance.
+func reverseTransactionPoints(tx *gorm.DB, transaction models.Transaction, userID uint) error {
+	var entries []models.MemberPointEntry
+	if err := tx.Where("transaction_id = ? AND type IN ?", transaction.ID, []models.MemberPointEntryType{models.MemberPointEarn, models.MemberPointRedeem}).
+		Where("NOT EXISTS (SELECT 1 FROM member_point_entries e WHERE e.reference_id = member_point_entries.id AND e.type = ?)", models.MemberPointReverse).
+		Order("id").Find(&entries).Error; err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Pembalikan dibukukan ke member transaksi saat ini; setelah penggabungan itu member yang dipertahankan
+	memberID := entries[0].MemberID
+	if transaction.MemberID != nil {
+		memberID = *transaction.MemberID
+	}
+	// Member dikunci dulu agar replay ledger tidak bentrok dengan transaksi lain
+	var member models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&member, memberID).Error; err != nil {
+		return fmt.Errorf("Member ID %d not found", memberID)
+	}
+	notes := fmt.Sprintf("Pembatalan %s", transaction.TransactionCode)
+
+	// Poin yang ditukar dikembalikan dulu, per lot asal
+	for _, entry := range entries {
+		if entry.Type != models.MemberPointRedeem {
+			continue
+		}
+		_, uses, err := replayMemberPoints(tx, entry.MemberID)
+		if err != nil {
+			return err
+		}
+		entryID := entry.ID
+		left := -entry.Points
+		for _, use := range uses[entry.ID] {
+			if _, err := postMemberPoints(tx, models.MemberPointEntry{
+				MemberID:      memberID,
+				Type:          models.MemberPointReverse,
+				Points:        use.Points,
+				ExpiresAt:     use.Lot.ExpiresAt,
+				TransactionID: &transaction.ID,
+				ReferenceID:   &entryID,
+				Notes:         notes,
+				CreatedByID:   &userID,
+			}); err != nil {
+				return err
+			}
+			left -= use.Points
+		}
+		if left > 0 {
+			if _, err := postMemberPoints(tx, models.MemberPointEntry{
+				MemberID:      memberID,
+				Type:          models.MemberPointReverse,
+				Points:        left,
+				TransactionID: &transaction.ID,
+				ReferenceID:   &entryID,
+				Notes:         notes,
+				CreatedByID:   &userID,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Poin yang didapat ditarik sebatas sisa lotnya dan saldo member.
+	// Lot yang dipindahkan saat penggabungan dilacak lewat entri adjust yang merujuk lot asalnya.
+	for _, entry := range entries {
+		if entry.Type != models.MemberPointEarn {
+			continue
+		}
+		lots, err := memberPointLots(tx, memberID)
+		if err != nil {
+			return err
+		}
+		var lotID uint
+		remaining := 0
+		for _, lot := range lots {
+			moved := lot.Entry.MemberMergeID != nil && lot.Entry.ReferenceID != nil && *lot.Entry.ReferenceID == entry.ID
+			if lot.Entry.ID == entry.ID || moved {
+				lotID = lot.Entry.ID
+				remaining = lot.Remaining
+				break
+			}
+		}
+		if err := tx.Select("points").First(&member, memberID).Error; err != nil {
+			return err
+		}
+		points := min(remaining, member.Points)
+		if points <= 0 {
+			continue
+		}
+		if _, err := postMemberPoints(tx, models.MemberPointEntry{
+			MemberID:      memberID,
+			Type:          models.MemberPointReverse,
+			Points:        -points,
+			TransactionID: &transaction.ID,
+			ReferenceID:   &lotID,
+			Notes:         notes,
+			CreatedByID:   &userID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemberPointStatement is the point ledger of a member over a period
+type MemberPointStatement struct {
+	Member         models.Member             `json:"member"`
+	From           *time.Time                `json:"from,omitempty"`
+	To             *time.Time                `json:"to,omitempty"`
+	OpeningBalance int                       `json:"opening_balance"`
+	Entries        []models.MemberPointEntry `json:"entries"`
+	ClosingBalance int                       `json:"closing_balance"`
+	Expiring       []pointLot                `json:"expiring"` // Poin yang akan kedaluwarsa dalam 30 hari
+	RedeemValue    float64                   `json:"redeem_value"`
+}
+
+// memberPointStatement builds the point statement of a member between from and to (both optional)
+func memberPointStatement(db *gorm.DB, member models.Member, from, to *time.Time) (MemberPointStatement, error) {
+	statement := MemberPointStatement{Member: member, From: from, To: to, RedeemValue: memberPointSettings.RedeemValue}
+
+	query := db.Preload("Transaction").Preload("CreatedBy").Where("member_id = ?", member.ID)
+	if from != nil {
+		db.Model(&models.MemberPointEntry{}).Select("COALESCE(SUM(points), 0)").
+			Where("member_id = ? AND created_at < ?", member.ID, *from).Scan(&statement.OpeningBalance)
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+	if err := query.Order("id").Find(&statement.Entries).Error; err != nil {
+		return statement, err
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	if n := len(statement.Entries); n > 0 {
+		statement.ClosingBalance = statement.Entries[n-1].Balance
+	}
+
+	lots, err := memberPointLots(db, member.ID)
+	if err != nil {
+		return statement, err
+	}
+	soon := time.Now().AddDate(0, 0, 30)
+	statement.Expiring = []pointLot{}
+	for _, lot := range lots {
+		if lot.Remaining > 0 && lot.Entry.ExpiresAt != nil && lot.Entry.ExpiresAt.Before(soon) {
+			statement.Expiring = append(statement.Expiring, lot)
+		}
+	}
+	return statement, nil
+}
+
+// GetMemberPoints returns the point ledger of a member, optionally between ?from and ?to (YYYY-MM-DD)
+func GetMemberPoints(c *gin.Context) {
+	var member models.Member
+	if err := database.DB.First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	var from, to *time.Time
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		from = &parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		end := parsed.AddDate(0, 0, 1)
+		to = &end
+	}
+
+	statement, err := memberPointStatement(database.DB, member, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": statement})
+}
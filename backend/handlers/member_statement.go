@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+	"starter/backend/statement"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== MEMBER STATEMENT ====================
+
+// MemberStatementItem is one piece bought or sold on a statement line
+type MemberStatementItem struct {
+	ItemName     string  `json:"item_name"`
+	Barcode      string  `json:"barcode,omitempty"`
+	Karat        string  `json:"karat"`
+	Weight       float64 `json:"weight"`
+	PricePerGram float64 `json:"price_per_gram"`
+	SubTotal     float64 `json:"sub_total"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+// MemberStatementLine is one completed sale or setor of the member with the running totals after it
+type MemberStatementLine struct {
+	TransactionID   uint                   `json:"transaction_id"`
+	TransactionCode string                 `json:"transaction_code"`
+	TransactionDate time.Time              `json:"transaction_date"`
+	Type            models.TransactionType `json:"type"`
+	LocationName    string                 `json:"location_name"`
+	Items           []MemberStatementItem  `json:"items"`
+	TotalWeight     float64                `json:"total_weight"`
+	GrandTotal      float64                `json:"grand_total"`
+	PointsEarned    int                    `json:"points_earned"`
+	PointsUsed      int                    `json:"points_used"`
+
+	RunningPurchase  float64 `json:"running_purchase"` // Akumulasi beli dari toko (sale)
+	RunningSell      float64 `json:"running_sell"`     // Akumulasi jual ke toko (setor)
+	RunningWeightIn  float64 `json:"running_weight_in"`
+	RunningWeightOut float64 `json:"running_weight_out"`
+}
+
+// MemberStatement is the purchase and setor history of a member over a period
+type MemberStatement struct {
+	Member        models.Member         `json:"member"`
+	From          *time.Time            `json:"from,omitempty"`
+	To            *time.Time            `json:"to,omitempty"`
+	GeneratedAt   time.Time             `json:"generated_at"`
+	Lines         []MemberStatementLine `json:"lines"`
+	TotalPurchase float64               `json:"total_purchase"` // Total penjualan ke member dalam periode
+	TotalSell     float64               `json:"total_sell"`     // Total setor member dalam periode
+	WeightIn      float64               `json:"weight_in"`      // Berat yang dibeli member
+	WeightOut     float64               `json:"weight_out"`     // Berat yang disetor member
+	PointsEarned  int                   `json:"points_earned"`
+	PointsUsed    int                   `json:"points_used"`
+	OpeningPoints int                   `json:"opening_points"`
+	ClosingPoints int                   `json:"closing_points"`
+}
+
+// statementItemKarat returns the gold category name of a sold stock piece or a setor line
+func statementItemKarat(item models.TransactionItem) string {
+	if item.GoldCategory != nil {
+		return item.GoldCategory.Name
+	}
+	if item.Stock != nil && item.Stock.Product.GoldCategory.Name != "" {
+		return item.Stock.Product.GoldCategory.Name
+	}
+	return "-"
+}
+
+// memberStatement builds the statement of completed transactions of a member between from and to (both optional)
+func memberStatement(db *gorm.DB, member models.Member, from, to *time.Time) (MemberStatement, error) {
+	result := MemberStatement{Member: member, From: from, To: to, GeneratedAt: time.Now(), Lines: []MemberStatementLine{}}
+
+	query := db.Preload("Location").Preload("Items").Preload("Items.GoldCategory").
+		Preload("Items.Stock").Preload("Items.Stock.Product").Preload("Items.Stock.Product.GoldCategory").
+		Where("member_id = ? AND status = ?", member.ID, "completed")
+	points := db.Model(&models.MemberPointEntry{}).Select("COALESCE(SUM(points), 0)").Where("member_id = ?", member.ID)
+	if from != nil {
+		query = query.Where("transaction_date >= ?", *from)
+		db.Model(&models.MemberPointEntry{}).Select("COALESCE(SUM(points), 0)").
+			Where("member_id = ? AND created_at < ?", member.ID, *from).Scan(&result.OpeningPoints)
+	}
+	if to != nil {
+		query = query.Where("transaction_date < ?", *to)
+		points = points.Where("created_at < ?", *to)
+	}
+	if err := points.Scan(&result.ClosingPoints).Error; err != nil {
+		return result, err
+	}
+
+	var transactions []models.Transaction
+	if err := query.Order("transaction_date, id").Find(&transactions).Error; err != nil {
+		return result, err
+	}
+	if len(transactions) == 0 {
+		return result, nil
+	}
+
+	// Poin yang didapat dan ditukar per transaksi dari ledger
+	transactionIDs := make([]uint, len(transactions))
+	for i, transaction := range transactions {
+		transactionIDs[i] = transaction.ID
+	}
+	var pointRows []struct {
+		TransactionID uint
+		Type          models.MemberPointEntryType
+		Points        int
+	}
+	if err := db.Model(&models.MemberPointEntry{}).Select("transaction_id, type, SUM(points) as points").
+		Where("transaction_id IN ? AND type IN ?", transactionIDs, []models.MemberPointEntryType{models.MemberPointEarn, models.MemberPointRedeem}).
+		Group("transaction_id, type").Scan(&pointRows).Error; err != nil {
+		return result, err
+	}
+	earned := make(map[uint]int)
+	used := make(map[uint]int)
+	for _, row := range pointRows {
+		if row.Type == models.MemberPointEarn {
+			earned[row.TransactionID] += row.Points
+		} else {
+			used[row.TransactionID] -= row.Points
+		}
+	}
+
+	for _, transaction := range transactions {
+		line := MemberStatementLine{
+			TransactionID:   transaction.ID,
+			TransactionCode: transaction.TransactionCode,
+			TransactionDate: transaction.TransactionDate,
+			Type:            transaction.Type,
+			LocationName:    transaction.Location.Name,
+			Items:           []MemberStatementItem{},
+			GrandTotal:      transaction.GrandTotal,
+			PointsEarned:    earned[transaction.ID],
+			PointsUsed:      used[transaction.ID],
+		}
+		for _, item := range transaction.Items {
+			line.Items = append(line.Items, MemberStatementItem{
+				ItemName:     item.ItemName,
+				Barcode:      item.Barcode,
+				Karat:        statementItemKarat(item),
+				Weight:       item.Weight,
+				PricePerGram: item.PricePerGram,
+				SubTotal:     item.SubTotal,
+				Notes:        item.Notes,
+			})
+			line.TotalWeight += item.Weight
+		}
+
+		switch transaction.Type {
+		case models.TransactionTypeSale:
+			result.TotalPurchase += transaction.GrandTotal
+			result.WeightIn += line.TotalWeight
+		case models.TransactionTypePurchase:
+			result.TotalSell += transaction.GrandTotal
+			result.WeightOut += line.TotalWeight
+		}
+		result.PointsEarned += line.PointsEarned
+		result.PointsUsed += line.PointsUsed
+
+		line.RunningPurchase = result.TotalPurchase
+		line.RunningSell = result.TotalSell
+		line.RunningWeightIn = result.WeightIn
+		line.RunningWeightOut = result.WeightOut
+		result.Lines = append(result.Lines, line)
+	}
+	return result, nil
+}
+
+// memberStatementDocument lays the statement out for printing
+func memberStatementDocument(s MemberStatement) statement.Document {
+	period := "Semua transaksi"
+	switch {
+	case s.From != nil && s.To != nil:
+		period = fmt.Sprintf("%s s/d %s", s.From.Format("02-01-2006"), s.To.AddDate(0, 0, -1).Format("02-01-2006"))
+	case s.From != nil:
+		period = fmt.Sprintf("Sejak %s", s.From.Format("02-01-2006"))
+	case s.To != nil:
+		period = fmt.Sprintf("Sampai %s", s.To.AddDate(0, 0, -1).Format("02-01-2006"))
+	}
+
+	document := statement.Document{
+		Title: "Riwayat Transaksi Member",
+		Info: []string{
+			fmt.Sprintf("Member: %s (%s)", s.Member.Name, s.Member.MemberCode),
+			fmt.Sprintf("Telepon: %s", s.Member.Phone),
+			fmt.Sprintf("Periode: %s", period),
+			fmt.Sprintf("Dicetak: %s", s.GeneratedAt.Format("02-01-2006 15:04")),
+		},
+		Columns: []statement.Column{
+			{Title: "Barang", WidthMM: 70},
+			{Title: "Kadar", WidthMM: 25},
+			{Title: "Berat", WidthMM: 25, Right: true},
+			{Title: "Harga/gram", WidthMM: 32, Right: true},
+			{Title: "Subtotal", WidthMM: 34, Right: true},
+		},
+	}
+
+	for _, line := range s.Lines {
+		kind := "Pembelian"
+		if line.Type == models.TransactionTypePurchase {
+			kind = "Setor"
+		}
+		entry := statement.Entry{
+			Heading: fmt.Sprintf("%s   %s   %s   %s", line.TransactionDate.Format("02-01-2006 15:04"), line.TransactionCode, kind, line.LocationName),
+		}
+		for _, item := range line.Items {
+			entry.Rows = append(entry.Rows, []string{
+				item.ItemName,
+				item.Karat,
+				statement.Grams(item.Weight),
+				statement.Rupiah(item.PricePerGram),
+				statement.Rupiah(item.SubTotal),
+			})
+		}
+		entry.Footer = fmt.Sprintf("Total %s   Poin +%d / -%d   Akumulasi beli %s, setor %s",
+			statement.Rupiah(line.GrandTotal), line.PointsEarned, line.PointsUsed,
+			statement.Rupiah(line.RunningPurchase), statement.Rupiah(line.RunningSell))
+		document.Entries = append(document.Entries, entry)
+	}
+
+	document.Summary = []string{
+		fmt.Sprintf("Total pembelian: %s (%s)", statement.Rupiah(s.TotalPurchase), statement.Grams(s.WeightIn)),
+		fmt.Sprintf("Total setor: %s (%s)", statement.Rupiah(s.TotalSell), statement.Grams(s.WeightOut)),
+		fmt.Sprintf("Poin: saldo awal %d, didapat %d, ditukar %d, saldo akhir %d", s.OpeningPoints, s.PointsEarned, s.PointsUsed, s.ClosingPoints),
+	}
+	return document
+}
+
+// GetMemberStatement returns the sales and setor history of a member between ?from and ?to (YYYY-MM-DD).
+// ?format=pdf returns a printable PDF instead of JSON.
+func GetMemberStatement(c *gin.Context) {
+	var member models.Member
+	if err := database.DB.First(&member, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	var from, to *time.Time
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+		from = &parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+		end := parsed.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "From date must not be after to date"})
+		return
+	}
+
+	result, err := memberStatement(database.DB, member, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"data": result})
+	case "pdf":
+		output, err := statement.RenderPDF(memberStatementDocument(result))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=statement-%s.pdf", member.MemberCode))
+		c.Data(http.StatusOK, "application/pdf", output)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, use json or pdf"})
+	}
+}
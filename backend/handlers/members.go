@@ -188,7 +188,8 @@ func DeleteMember(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Member deleted successfully"})
 }
 
-// AddMemberPoints adds points to a member
+// AddMemberPoints posts points to a member's ledger: an adjustment of the given points (boleh negatif),
+// or the points a sale of the given amount would earn
 func AddMemberPoints(c *gin.Context) {
 	id := c.Param("id")
 	var member models.Member
@@ -198,68 +199,73 @@ func AddMemberPoints(c *gin.Context) {
 	}
 
 	var req struct {
-		Amount float64 `json:"amount" binding:"required"`
+		Amount float64 `json:"amount"`
+		Points int     `json:"points"`
+		Notes  string  `json:"notes"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	member.AddPoints(req.Amount)
-	if err := database.DB.Save(&member).Error; err != nil {
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+	entry := models.MemberPointEntry{MemberID: member.ID, Notes: req.Notes, CreatedByID: &currentUserID}
+	switch {
+	case req.Points != 0:
+		entry.Type = models.MemberPointAdjust
+		entry.Points = req.Points
+	case req.Amount > 0:
+		rules, err := loadMembershipRules(database.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		entry.Type = models.MemberPointEarn
+		entry.Points = rules.Points(models.TransactionTypeSale, req.Amount)
+		if entry.Points == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount is too small to earn points"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Points or amount is required"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if _, err := postMemberPoints(tx, entry); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	tx.Commit()
 
+	database.DB.First(&member, member.ID)
 	c.JSON(http.StatusOK, gin.H{"data": member})
 }
 
-// RecalculateMemberStats recalculates transaction stats for all members
+// RecalculateMemberStats recalculates transaction stats, points and tier for all members with the membership rules
 func RecalculateMemberStats(c *gin.Context) {
+	rules, err := loadMembershipRules(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var members []models.Member
 	if err := database.DB.Find(&members).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	now := time.Now()
 	updatedCount := 0
-	for _, member := range members {
-		var transactions []models.Transaction
-		database.DB.Where("member_id = ? AND status = ?", member.ID, "completed").Find(&transactions)
-
-		var totalPurchase float64 = 0 // Sale: member beli dari toko
-		var totalSell float64 = 0     // Purchase: member jual ke toko
-		transactionCount := len(transactions)
-
-		for _, tx := range transactions {
-			if tx.Type == models.TransactionTypeSale {
-				totalPurchase += tx.GrandTotal
-			} else if tx.Type == models.TransactionTypePurchase {
-				totalSell += tx.GrandTotal
-			}
+	for i := range members {
+		if err := recalculateMember(database.DB, rules, &members[i], now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-
-		// Calculate points: 1 per 100k purchase + 1 per 200k sell
-		points := int(totalPurchase/100000) + int(totalSell/200000)
-
-		// Update member
-		member.TotalPurchase = totalPurchase
-		member.TotalSell = totalSell
-		member.TransactionCount = transactionCount
-		member.Points = points
-
-		// Update member type based on total purchase
-		if totalPurchase >= 100000000 {
-			member.Type = models.MemberTypePlatinum
-		} else if totalPurchase >= 50000000 {
-			member.Type = models.MemberTypeGold
-		} else if totalPurchase >= 20000000 {
-			member.Type = models.MemberTypeSilver
-		} else {
-			member.Type = models.MemberTypeRegular
-		}
-
-		database.DB.Save(&member)
+		database.DB.Save(&members[i])
 		updatedCount++
 	}
 
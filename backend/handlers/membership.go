@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== MEMBERSHIP TIERS AND POINTS ====================
+
+// loadMembershipRules loads the tier thresholds, evaluation window and point rates
+func loadMembershipRules(db *gorm.DB) (models.MembershipRules, error) {
+	rules := models.MembershipRules{Window: models.MemberTierWindowLifetime}
+	if err := db.Order("min_spend").Find(&rules.Tiers).Error; err != nil {
+		return rules, err
+	}
+	if err := db.Order("transaction_type").Find(&rules.PointRules).Error; err != nil {
+		return rules, err
+	}
+	var setting models.Setting
+	if err := db.Where("key = ?", models.MemberTierWindowSettingKey).First(&setting).Error; err == nil && models.MemberTierWindow(setting.Value).IsValid() {
+		rules.Window = models.MemberTierWindow(setting.Value)
+	}
+	return rules, nil
+}
+
+// updateMemberTier sets the member's tier from the spending in the rules' window at time t
+func updateMemberTier(db *gorm.DB, rules models.MembershipRules, member *models.Member, t time.Time) error {
+	spend := member.TotalPurchase
+	if start := rules.WindowStart(t); start != nil {
+		if err := db.Model(&models.Transaction{}).Select("COALESCE(SUM(grand_total), 0)").
+			Where("member_id = ? AND type = ? AND status = ? AND transaction_date > ? AND transaction_date <= ?",
+				member.ID, models.TransactionTypeSale, "completed", *start, t).
+			Scan(&spend).Error; err != nil {
+			return err
+		}
+	}
+	member.Type = rules.TierFor(spend).Type
+	return nil
+}
+
+// recordMemberTransaction adds a completed transaction to the member's totals and tier and posts
+// the points it earns to the point ledger. It returns the points earned by the transaction.
+func recordMemberTransaction(tx *gorm.DB, memberID uint, transaction models.Transaction) (int, error) {
+	rules, err := loadMembershipRules(tx)
+	if err != nil {
+		return 0, err
+	}
+	var member models.Member
+	if err := tx.First(&member, memberID).Error; err != nil {
+		return 0, fmt.Errorf("Member ID %d not found", memberID)
+	}
+
+	switch transaction.Type {
+	case models.TransactionTypeSale:
+		member.TotalPurchase += transaction.GrandTotal
+	case models.TransactionTypePurchase:
+		member.TotalSell += transaction.GrandTotal
+	}
+	member.TransactionCount++
+
+	if err := updateMemberTier(tx, rules, &member, transaction.TransactionDate); err != nil {
+		return 0, err
+	}
+	// Saldo poin hanya berubah lewat ledger
+	if err := tx.Model(&member).Updates(map[string]interface{}{
+		"total_purchase":    member.TotalPurchase,
+		"total_sell":        member.TotalSell,
+		"transaction_count": member.TransactionCount,
+		"type":              member.Type,
+	}).Error; err != nil {
+		return 0, err
+	}
+
+	points := rules.Points(transaction.Type, transaction.GrandTotal)
+	if points > 0 {
+		if _, err := postMemberPoints(tx, models.MemberPointEntry{
+			MemberID:      member.ID,
+			Type:          models.MemberPointEarn,
+			Points:        points,
+			TransactionID: &transaction.ID,
+			Notes:         transaction.TransactionCode,
+			CreatedByID:   &transaction.CashierID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return points, nil
+}
+
+// reverseMemberTransaction takes a cancelled transaction out of the member's totals and re-evaluates the tier.
+// It is not idempotent: call it only in the transaction that claimed the cancel with a conditional status update
+// (see CancelTransaction). Its points are reversed by reverseTransactionPoints.
+func reverseMemberTransaction(tx *gorm.DB, memberID uint, transaction models.Transaction) error {
+	// Pastikan pembatalan sudah diklaim di transaksi database ini
+	var cancelled int64
+	if err := tx.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", transaction.ID, "cancelled").
+		Count(&cancelled).Error; err != nil {
+		return err
+	}
+	if cancelled == 0 {
+		return fmt.Errorf("Transaction %s is not cancelled", transaction.TransactionCode)
+	}
+
+	rules, err := loadMembershipRules(tx)
+	if err != nil {
+		return err
+	}
+	// Kunci member agar pembatalan transaksi lain milik member yang sama tidak saling menimpa total
+	var member models.Member
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&member, memberID).Error; err != nil {
+		return fmt.Errorf("Member ID %d not found", memberID)
+	}
+
+	switch transaction.Type {
+	case models.TransactionTypeSale:
+		member.TotalPurchase = max(member.TotalPurchase-transaction.GrandTotal, 0)
+	case models.TransactionTypePurchase:
+		member.TotalSell = max(member.TotalSell-transaction.GrandTotal, 0)
+	}
+	member.TransactionCount = max(member.TransactionCount-1, 0)
+
+	if err := updateMemberTier(tx, rules, &member, time.Now()); err != nil {
+		return err
+	}
+	return tx.Model(&member).Updates(map[string]interface{}{
+		"total_purchase":    member.TotalPurchase,
+		"total_sell":        member.TotalSell,
+		"transaction_count": member.TransactionCount,
+		"type":              member.Type,
+	}).Error
+}
+
+// memberTierDiscount returns the sale discount percent of the member's current tier
+func memberTierDiscount(db *gorm.DB, memberID uint) (float64, error) {
+	rules, err := loadMembershipRules(db)
+	if err != nil {
+		return 0, err
+	}
+	var member models.Member
+	if err := db.First(&member, memberID).Error; err != nil {
+		return 0, fmt.Errorf("Member ID %d not found", memberID)
+	}
+	tier, _ := rules.Tier(member.Type)
+	return tier.DiscountPercent, nil
+}
+
+// recalculateMember recomputes a member's totals and tier from its completed transactions
+// and its point balance from the point ledger
+func recalculateMember(db *gorm.DB, rules models.MembershipRules, member *models.Member, now time.Time) error {
+	var transactions []models.Transaction
+	if err := db.Where("member_id = ? AND status = ?", member.ID, "completed").Find(&transactions).Error; err != nil {
+		return err
+	}
+
+	member.TotalPurchase = 0 // Sale: member beli dari toko
+	member.TotalSell = 0     // Purchase: member jual ke toko
+	member.TransactionCount = len(transactions)
+	for _, transaction := range transactions {
+		switch transaction.Type {
+		case models.TransactionTypeSale:
+			member.TotalPurchase += transaction.GrandTotal
+		case models.TransactionTypePurchase:
+			member.TotalSell += transaction.GrandTotal
+		}
+	}
+
+	if err := db.Model(&models.MemberPointEntry{}).Select("COALESCE(SUM(points), 0)").
+		Where("member_id = ?", member.ID).Scan(&member.Points).Error; err != nil {
+		return err
+	}
+
+	return updateMemberTier(db, rules, member, now)
+}
+
+// GetMembershipRules returns the tier thresholds, evaluation window and point rates
+func GetMembershipRules(c *gin.Context) {
+	rules, err := loadMembershipRules(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+type MemberTierItem struct {
+	Type            models.MemberType `json:"type" binding:"required"`
+	Name            string            `json:"name" binding:"required"`
+	MinSpend        float64           `json:"min_spend"`
+	DiscountPercent float64           `json:"discount_percent"`
+}
+
+type MemberPointRuleItem struct {
+	TransactionType models.TransactionType `json:"transaction_type" binding:"required"`
+	AmountPerPoint  float64                `json:"amount_per_point"`
+}
+
+type UpdateMembershipRulesRequest struct {
+	Window     models.MemberTierWindow `json:"window" binding:"required"`
+	Tiers      []MemberTierItem        `json:"tiers" binding:"required,min=1,dive"`
+	PointRules []MemberPointRuleItem   `json:"point_rules" binding:"dive"`
+}
+
+// UpdateMembershipRules replaces the tiers and point rates. Member tiers change on their next transaction
+// or on recalculation.
+func UpdateMembershipRules(c *gin.Context) {
+	var req UpdateMembershipRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Window.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid window %s, use lifetime or rolling_12_months", req.Window)})
+		return
+	}
+
+	rules := models.MembershipRules{Window: req.Window}
+	seenTiers := make(map[models.MemberType]bool)
+	for _, item := range req.Tiers {
+		if seenTiers[item.Type] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Tier %s is listed twice", item.Type)})
+			return
+		}
+		seenTiers[item.Type] = true
+		if item.MinSpend < 0 || item.DiscountPercent < 0 || item.DiscountPercent >= 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Tier %s: minimum spend must be zero or more and discount between 0 and 100 percent", item.Type)})
+			return
+		}
+		rules.Tiers = append(rules.Tiers, models.MemberTier{Type: item.Type, Name: item.Name, MinSpend: item.MinSpend, DiscountPercent: item.DiscountPercent})
+	}
+	rules.SortTiers()
+	if rules.Tiers[0].MinSpend != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The lowest tier must start at a minimum spend of 0"})
+		return
+	}
+	for i := 1; i < len(rules.Tiers); i++ {
+		if rules.Tiers[i].MinSpend == rules.Tiers[i-1].MinSpend {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Tiers %s and %s have the same minimum spend", rules.Tiers[i-1].Type, rules.Tiers[i].Type)})
+			return
+		}
+	}
+
+	seenTypes := make(map[models.TransactionType]bool)
+	for _, item := range req.PointRules {
+		if item.TransactionType != models.TransactionTypeSale && item.TransactionType != models.TransactionTypePurchase {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid transaction type %s", item.TransactionType)})
+			return
+		}
+		if seenTypes[item.TransactionType] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Point rule for %s is listed twice", item.TransactionType)})
+			return
+		}
+		seenTypes[item.TransactionType] = true
+		if item.AmountPerPoint < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Amount per point cannot be negative"})
+			return
+		}
+		rules.PointRules = append(rules.PointRules, models.MemberPointRule{TransactionType: item.TransactionType, AmountPerPoint: item.AmountPerPoint})
+	}
+
+	// Aturan lama diganti seluruhnya
+	tx := database.DB.Begin()
+	if err := tx.Unscoped().Where("1 = 1").Delete(&models.MemberTier{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Unscoped().Where("1 = 1").Delete(&models.MemberPointRule{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Create(&rules.Tiers).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rules.PointRules) > 0 {
+		if err := tx.Create(&rules.PointRules).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	setting := models.Setting{Key: models.MemberTierWindowSettingKey}
+	tx.Where("key = ?", setting.Key).First(&setting)
+	setting.Value = string(req.Window)
+	if err := tx.Save(&setting).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": rules, "message": "Membership rules updated successfully"})
+}
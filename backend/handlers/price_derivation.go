@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== PRICE DERIVATION (HARGA DASAR 24K) ====================
+
+// DerivePricesRequest contains the 24K (999) reference prices per gram
+type DerivePricesRequest struct {
+	BaseBuyPrice  float64 `json:"base_buy_price" binding:"required,gt=0"`
+	BaseSellPrice float64 `json:"base_sell_price" binding:"required,gt=0"`
+	Notes         string  `json:"notes"`
+}
+
+// PriceChangePreview shows the old and new price of one gold category before it is committed
+type PriceChangePreview struct {
+	GoldCategoryID    uint     `json:"gold_category_id"`
+	Code              string   `json:"code"`
+	Name              string   `json:"name"`
+	Purity            *float64 `json:"purity"`
+	Derived           bool     `json:"derived"` // false = harga manual, tidak berubah
+	OldBuyPrice       float64  `json:"old_buy_price"`
+	NewBuyPrice       float64  `json:"new_buy_price"`
+	OldSellPrice      float64  `json:"old_sell_price"`
+	NewSellPrice      float64  `json:"new_sell_price"`
+	BuyChangePercent  float64  `json:"buy_change_percent"`
+	SellChangePercent float64  `json:"sell_change_percent"`
+}
+
+// priceChangePercent returns the relative change in percent, rounded to two decimals
+func priceChangePercent(oldPrice, newPrice float64) float64 {
+	if oldPrice == 0 {
+		return 0
+	}
+	return math.Round((newPrice-oldPrice)/oldPrice*10000) / 100
+}
+
+// derivePriceUpdate derives the prices of all active categories using derivation from the base prices.
+// It returns the preview of every active category and the update request for the derived ones.
+func derivePriceUpdate(db *gorm.DB, baseBuyPrice, baseSellPrice float64, notes string) ([]PriceChangePreview, BulkUpdatePriceRequest, error) {
+	update := BulkUpdatePriceRequest{Notes: notes}
+	if baseBuyPrice > baseSellPrice {
+		return nil, update, errors.New("Base buy price cannot exceed base sell price")
+	}
+
+	var categories []models.GoldCategory
+	if err := db.Where("is_active = ?", true).Order("purity DESC NULLS LAST, code").Find(&categories).Error; err != nil {
+		return nil, update, err
+	}
+
+	previews := make([]PriceChangePreview, 0, len(categories))
+	for _, category := range categories {
+		preview := PriceChangePreview{
+			GoldCategoryID: category.ID,
+			Code:           category.Code,
+			Name:           category.Name,
+			Purity:         category.Purity,
+			OldBuyPrice:    category.BuyPrice,
+			NewBuyPrice:    category.BuyPrice,
+			OldSellPrice:   category.SellPrice,
+			NewSellPrice:   category.SellPrice,
+		}
+		buyPrice, sellPrice, err := category.DerivePrices(baseBuyPrice, baseSellPrice)
+		if err == nil {
+			if buyPrice > sellPrice {
+				return nil, update, fmt.Errorf("Derived buy price of %s exceeds its sell price, check the markup and spread", category.Name)
+			}
+			preview.Derived = true
+			preview.NewBuyPrice = buyPrice
+			preview.NewSellPrice = sellPrice
+			preview.BuyChangePercent = priceChangePercent(category.BuyPrice, buyPrice)
+			preview.SellChangePercent = priceChangePercent(category.SellPrice, sellPrice)
+			update.Prices = append(update.Prices, PriceUpdateItem{
+				GoldCategoryID: category.ID,
+				BuyPrice:       buyPrice,
+				SellPrice:      sellPrice,
+			})
+		}
+		previews = append(previews, preview)
+	}
+
+	if len(update.Prices) == 0 {
+		return previews, update, errors.New("No active gold category is set to derive its price from the base price")
+	}
+	return previews, update, nil
+}
+
+// PreviewDerivedPrices shows the prices derived from a 24K base price without saving them
+func PreviewDerivedPrices(c *gin.Context) {
+	var req DerivePricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previews, update, err := derivePriceUpdate(database.DB, req.BaseBuyPrice, req.BaseSellPrice, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"base_buy_price":  req.BaseBuyPrice,
+		"base_sell_price": req.BaseSellPrice,
+		"categories":      previews,
+		"update":          update,
+	}})
+}
+
+// ApplyDerivedPrices derives the prices from a 24K base price and commits them as a price update
+func ApplyDerivedPrices(c *gin.Context) {
+	var req DerivePricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, update, err := derivePriceUpdate(database.DB, req.BaseBuyPrice, req.BaseSellPrice, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	updatedLog, err := applyPriceUpdate(models.PriceUpdateLog{
+		UpdatedByID:   userID.(uint),
+		Source:        models.PriceUpdateSourceDerived,
+		BaseBuyPrice:  &req.BaseBuyPrice,
+		BaseSellPrice: &req.BaseSellPrice,
+	}, update)
+	if err != nil {
+		respondPriceUpdateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("%d prices derived from base price", len(update.Prices)),
+		"data":    updatedLog,
+	})
+}
+
+// validatePricingRule checks the derivation settings of a gold category
+func validatePricingRule(category *models.GoldCategory) error {
+	if category.RoundingMode == "" {
+		category.RoundingMode = models.PriceRoundingNearest
+	}
+	if !category.RoundingMode.IsValid() {
+		return fmt.Errorf("Invalid rounding mode %s, use nearest, up or down", category.RoundingMode)
+	}
+	if category.RoundingStep < 0 {
+		return errors.New("Rounding step cannot be negative")
+	}
+	if category.SellMarkupPercent < 0 || category.BuySpreadPercent < 0 || category.BuySpreadPercent >= 100 {
+		return errors.New("Markup must be zero or more and spread between 0 and 100 percent")
+	}
+	if category.DerivePrice && (category.Purity == nil || *category.Purity <= 0 || *category.Purity > 1) {
+		return errors.New("Purity between 0 and 1 is required to derive the price from the base price")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"starter/backend/models"
+	"testing"
+)
+
+func TestPriceChangePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldPrice float64
+		newPrice float64
+		want     float64
+	}{
+		{name: "no old price", oldPrice: 0, newPrice: 1000000, want: 0},
+		{name: "unchanged", oldPrice: 1000000, newPrice: 1000000, want: 0},
+		{name: "increase", oldPrice: 1000000, newPrice: 1100000, want: 10},
+		{name: "decrease", oldPrice: 3, newPrice: 1, want: -66.67},
+		{name: "half of the last decimal rounds up", oldPrice: 1000000, newPrice: 1000050, want: 0.01},
+		{name: "below half of the last decimal", oldPrice: 1000000, newPrice: 1000049, want: 0},
+		{name: "half of the last decimal going down", oldPrice: 1000000, newPrice: 999950, want: -0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := priceChangePercent(tt.oldPrice, tt.newPrice); got != tt.want {
+				t.Errorf("priceChangePercent(%v, %v) = %v, want %v", tt.oldPrice, tt.newPrice, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePricingRule(t *testing.T) {
+	purity := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name     string
+		category models.GoldCategory
+		wantErr  bool
+	}{
+		{name: "defaults", category: models.GoldCategory{}},
+		{name: "derived", category: models.GoldCategory{DerivePrice: true, Purity: purity(0.75), RoundingStep: 1000, RoundingMode: models.PriceRoundingUp, SellMarkupPercent: 10, BuySpreadPercent: 5}},
+		{name: "invalid mode", category: models.GoldCategory{RoundingMode: "half"}, wantErr: true},
+		{name: "negative step", category: models.GoldCategory{RoundingStep: -1}, wantErr: true},
+		{name: "negative markup", category: models.GoldCategory{SellMarkupPercent: -1}, wantErr: true},
+		{name: "spread of 100 percent", category: models.GoldCategory{BuySpreadPercent: 100}, wantErr: true},
+		{name: "derived without purity", category: models.GoldCategory{DerivePrice: true}, wantErr: true},
+		{name: "derived with purity above 1", category: models.GoldCategory{DerivePrice: true, Purity: purity(75)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category := tt.category
+			err := validatePricingRule(&category)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validatePricingRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && category.RoundingMode == "" {
+				t.Error("rounding mode was not defaulted")
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+	"starter/backend/pricefeed"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== EXTERNAL PRICE FEED ====================
+
+// PriceFeedSettings controls what happens with prices pulled from the feed
+type PriceFeedSettings struct {
+	AutoApply        bool    // Terapkan otomatis jika perubahan di bawah batas
+	MaxChangePercent float64 // Perubahan di atas batas ini selalu menunggu persetujuan
+	Username         string  // User yang tercatat pada update otomatis
+}
+
+var (
+	// priceFeed is the configured reference price provider, if any
+	priceFeed         pricefeed.Provider
+	priceFeedSettings PriceFeedSettings
+)
+
+var errPriceUnchanged = errors.New("Feed price gives the same prices as today, nothing to propose")
+
+// SetPriceFeed sets the price feed provider and its settings
+func SetPriceFeed(provider pricefeed.Provider, settings PriceFeedSettings) {
+	priceFeed = provider
+	priceFeedSettings = settings
+}
+
+// StartPriceFeedJob pulls the price feed periodically in the background
+func StartPriceFeedJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			proposal, err := runPriceFeed(context.Background())
+			if err != nil {
+				log.Println("Price feed job:", err)
+				continue
+			}
+			log.Printf("Price feed job created proposal %d (%s)", proposal.ID, proposal.Status)
+		}
+	}()
+}
+
+// runPriceFeed fetches the reference price, derives a price update from it and records it as a proposal.
+// The proposal is applied at once when auto-apply is on and no price moves more than the threshold.
+func runPriceFeed(ctx context.Context) (models.PriceProposal, error) {
+	var proposal models.PriceProposal
+	if priceFeed == nil {
+		return proposal, errors.New("Price feed is not configured")
+	}
+
+	quote, err := priceFeed.Fetch(ctx)
+	if err != nil {
+		return proposal, fmt.Errorf("Failed to fetch price feed: %v", err)
+	}
+
+	previews, update, err := derivePriceUpdate(database.DB, quote.BuyPrice, quote.SellPrice, "Harga dari feed "+quote.Source)
+	if err != nil {
+		return proposal, err
+	}
+
+	changed := false
+	proposal = models.PriceProposal{
+		Source:        quote.Source,
+		FetchedAt:     quote.At,
+		BaseBuyPrice:  quote.BuyPrice,
+		BaseSellPrice: quote.SellPrice,
+		Status:        models.PriceProposalStatusPending,
+	}
+	for _, preview := range previews {
+		if !preview.Derived {
+			continue
+		}
+		change := math.Max(math.Abs(preview.BuyChangePercent), math.Abs(preview.SellChangePercent))
+		if preview.OldBuyPrice == 0 || preview.OldSellPrice == 0 {
+			change = 100 // Belum ada harga sebelumnya, selalu perlu dicek
+		}
+		proposal.MaxChangePercent = math.Max(proposal.MaxChangePercent, change)
+		if preview.NewBuyPrice != preview.OldBuyPrice || preview.NewSellPrice != preview.OldSellPrice {
+			changed = true
+		}
+		proposal.Items = append(proposal.Items, models.PriceProposalItem{
+			GoldCategoryID: preview.GoldCategoryID,
+			Code:           preview.Code,
+			Name:           preview.Name,
+			OldBuyPrice:    preview.OldBuyPrice,
+			NewBuyPrice:    preview.NewBuyPrice,
+			OldSellPrice:   preview.OldSellPrice,
+			NewSellPrice:   preview.NewSellPrice,
+			ChangePercent:  change,
+		})
+	}
+	if !changed {
+		return proposal, errPriceUnchanged
+	}
+
+	policy := pricefeed.Policy{AutoApply: priceFeedSettings.AutoApply, MaxChangePercent: priceFeedSettings.MaxChangePercent}
+	proposal.RequiresApproval, proposal.ApprovalReason = policy.RequiresApproval(proposal.MaxChangePercent)
+
+	var feedUser models.User
+	if !proposal.RequiresApproval {
+		if err := database.DB.Where("username = ?", priceFeedSettings.Username).First(&feedUser).Error; err != nil {
+			proposal.RequiresApproval = true
+			proposal.ApprovalReason = fmt.Sprintf("Price feed user %s not found", priceFeedSettings.Username)
+		}
+	}
+
+	// Usulan lama yang belum diproses digantikan usulan terbaru
+	tx := database.DB.Begin()
+	if err := tx.Model(&models.PriceProposal{}).Where("status = ?", models.PriceProposalStatusPending).
+		Update("status", models.PriceProposalStatusSuperseded).Error; err != nil {
+		tx.Rollback()
+		return proposal, err
+	}
+	if err := tx.Create(&proposal).Error; err != nil {
+		tx.Rollback()
+		return proposal, err
+	}
+	tx.Commit()
+
+	if proposal.RequiresApproval {
+		return proposal, nil
+	}
+
+	update.Notes = fmt.Sprintf("Otomatis dari feed harga (usulan #%d)", proposal.ID)
+	updatedLog, err := applyPriceUpdate(models.PriceUpdateLog{
+		UpdatedByID:   feedUser.ID,
+		Source:        models.PriceUpdateSourceFeed,
+		BaseBuyPrice:  &proposal.BaseBuyPrice,
+		BaseSellPrice: &proposal.BaseSellPrice,
+	}, update)
+	if err != nil {
+		database.DB.Model(&proposal).Updates(map[string]interface{}{
+			"requires_approval": true,
+			"approval_reason":   "Auto-apply failed: " + err.Error(),
+		})
+		return proposal, err
+	}
+
+	now := time.Now()
+	database.DB.Model(&proposal).Updates(map[string]interface{}{
+		"status":              models.PriceProposalStatusApplied,
+		"price_update_log_id": updatedLog.ID,
+		"reviewed_at":         now,
+	})
+	proposal.Status = models.PriceProposalStatusApplied
+	proposal.PriceUpdateLogID = &updatedLog.ID
+	proposal.ReviewedAt = &now
+	return proposal, nil
+}
+
+// FetchPriceFeed pulls the price feed now and returns the resulting proposal
+func FetchPriceFeed(c *gin.Context) {
+	proposal, err := runPriceFeed(c.Request.Context())
+	if errors.Is(err, errPriceUnchanged) {
+		c.JSON(http.StatusOK, gin.H{"message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": proposal})
+}
+
+// GetPriceProposals returns price proposals, newest first
+func GetPriceProposals(c *gin.Context) {
+	var proposals []models.PriceProposal
+	query := database.DB.Preload("ReviewedBy").Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Limit(50).Find(&proposals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": proposals})
+}
+
+// GetPriceProposal returns a single price proposal
+func GetPriceProposal(c *gin.Context) {
+	var proposal models.PriceProposal
+	if err := database.DB.Preload("ReviewedBy").Preload("PriceUpdateLog").First(&proposal, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price proposal not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": proposal})
+}
+
+type ReviewPriceProposalRequest struct {
+	Notes string `json:"notes"`
+}
+
+// ApprovePriceProposal applies a pending proposal as a price update by the current user.
+// The proposal is locked and claimed in the same transaction as the price update, so it is applied once.
+func ApprovePriceProposal(c *gin.Context) {
+	var req ReviewPriceProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	var proposal models.PriceProposal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&proposal, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price proposal not found"})
+		return
+	}
+	if proposal.Status != models.PriceProposalStatusPending {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Proposal is already %s", proposal.Status)})
+		return
+	}
+
+	// Usulan dihitung dari harga saat itu; jika harga sudah diubah setelahnya, usulan kedaluwarsa
+	var newer int64
+	if err := tx.Model(&models.PriceUpdateLog{}).Where("created_at > ? AND location_id IS NULL", proposal.CreatedAt).Count(&newer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if newer > 0 {
+		if err := tx.Model(&proposal).Update("status", models.PriceProposalStatusSuperseded).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		tx.Commit()
+		c.JSON(http.StatusConflict, gin.H{"error": "Prices were updated after this proposal was made, fetch the feed again"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	update := BulkUpdatePriceRequest{Notes: fmt.Sprintf("Feed harga disetujui (usulan #%d)", proposal.ID)}
+	if req.Notes != "" {
+		update.Notes += ": " + req.Notes
+	}
+	for _, item := range proposal.Items {
+		update.Prices = append(update.Prices, PriceUpdateItem{
+			GoldCategoryID: item.GoldCategoryID,
+			BuyPrice:       item.NewBuyPrice,
+			SellPrice:      item.NewSellPrice,
+		})
+	}
+	priceUpdateLog, err := applyPriceUpdateTx(tx, models.PriceUpdateLog{
+		UpdatedByID:   userID.(uint),
+		Source:        models.PriceUpdateSourceFeed,
+		BaseBuyPrice:  &proposal.BaseBuyPrice,
+		BaseSellPrice: &proposal.BaseSellPrice,
+	}, update)
+	if err != nil {
+		tx.Rollback()
+		respondPriceUpdateError(c, err)
+		return
+	}
+
+	now := time.Now()
+	reviewerID := userID.(uint)
+	proposal.Status = models.PriceProposalStatusApplied
+	proposal.PriceUpdateLogID = &priceUpdateLog.ID
+	proposal.ReviewedByID = &reviewerID
+	proposal.ReviewedAt = &now
+	proposal.ReviewNotes = req.Notes
+	if err := tx.Save(&proposal).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update proposal: " + err.Error()})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		return
+	}
+
+	var updatedLog models.PriceUpdateLog
+	database.DB.Preload("UpdatedBy").Preload("PriceDetails.GoldCategory").First(&updatedLog, priceUpdateLog.ID)
+	c.JSON(http.StatusOK, gin.H{"data": proposal, "price_update": updatedLog})
+}
+
+// RejectPriceProposal rejects a pending proposal
+func RejectPriceProposal(c *gin.Context) {
+	var proposal models.PriceProposal
+	if err := database.DB.First(&proposal, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price proposal not found"})
+		return
+	}
+	if proposal.Status != models.PriceProposalStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Proposal is already %s", proposal.Status)})
+		return
+	}
+	var req ReviewPriceProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	userID, _ := c.Get("user_id")
+	reviewerID := userID.(uint)
+	result := database.DB.Model(&models.PriceProposal{}).
+		Where("id = ? AND status = ?", proposal.ID, models.PriceProposalStatusPending).
+		Updates(map[string]interface{}{
+			"status":         models.PriceProposalStatusRejected,
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    now,
+			"review_notes":   req.Notes,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update proposal: " + result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Proposal was processed by someone else"})
+		return
+	}
+	proposal.Status = models.PriceProposalStatusRejected
+	proposal.ReviewedByID = &reviewerID
+	proposal.ReviewedAt = &now
+	proposal.ReviewNotes = req.Notes
+
+	c.JSON(http.StatusOK, gin.H{"data": proposal})
+}
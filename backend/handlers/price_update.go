@@ -1,20 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CheckPriceUpdateNeededResponse contains the response for price update check
 type CheckPriceUpdateNeededResponse struct {
-	NeedsUpdate    bool                   `json:"needs_update"`
-	LastUpdate     *time.Time             `json:"last_update"`
-	LastUpdatedBy  *models.User           `json:"last_updated_by,omitempty"`
-	GoldCategories []models.GoldCategory  `json:"gold_categories"`
+	NeedsUpdate     bool                  `json:"needs_update"`
+	LastUpdate      *time.Time            `json:"last_update"`
+	LastUpdatedBy   *models.User          `json:"last_updated_by,omitempty"`
+	GoldCategories  []models.GoldCategory `json:"gold_categories"`
+	PendingProposal *models.PriceProposal `json:"pending_proposal,omitempty"` // Usulan feed harga yang menunggu persetujuan
+
+	// Harga terjadwal berikutnya; jika berlaku hari ini, update hari ini tidak perlu diketik lagi
+	NextScheduled *models.ScheduledPriceUpdate `json:"next_scheduled,omitempty"`
 }
 
 // CheckPriceUpdateNeeded checks if gold price update is needed for today
@@ -52,6 +58,19 @@ func CheckPriceUpdateNeeded(c *gin.Context) {
 		}
 	}
 
+	var scheduled models.ScheduledPriceUpdate
+	if err := database.DB.Where("status = ?", models.ScheduledPriceStatusScheduled).Order("effective_at").First(&scheduled).Error; err == nil {
+		response.NextScheduled = &scheduled
+		if scheduled.EffectiveAt.Before(endOfDay) {
+			response.NeedsUpdate = false
+		}
+	}
+
+	var proposal models.PriceProposal
+	if err := database.DB.Where("status = ?", models.PriceProposalStatusPending).Order("created_at DESC").First(&proposal).Error; err == nil {
+		response.PendingProposal = &proposal
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": response})
 }
 
@@ -83,6 +102,40 @@ func BulkUpdatePrices(c *gin.Context) {
 		return
 	}
 
+	updatedLog, err := applyPriceUpdate(models.PriceUpdateLog{UpdatedByID: userID.(uint), Source: models.PriceUpdateSourceManual}, req)
+	if err != nil {
+		respondPriceUpdateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prices updated successfully",
+		"data":    updatedLog,
+	})
+}
+
+// priceCategoryNotFoundError is returned when a price update names an unknown gold category
+type priceCategoryNotFoundError struct {
+	GoldCategoryID uint
+}
+
+func (e *priceCategoryNotFoundError) Error() string {
+	return "Gold category not found"
+}
+
+// respondPriceUpdateError writes the response for an error of applyPriceUpdate
+func respondPriceUpdateError(c *gin.Context, err error) {
+	var notFound *priceCategoryNotFoundError
+	if errors.As(err, &notFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "gold_category_id": notFound.GoldCategoryID})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// applyPriceUpdate writes a PriceUpdateLog with its PriceDetails and updates the gold categories
+// in one transaction. header carries who made the update, its source and the base price if derived.
+func applyPriceUpdate(header models.PriceUpdateLog, req BulkUpdatePriceRequest) (models.PriceUpdateLog, error) {
 	// Start transaction
 	tx := database.DB.Begin()
 	defer func() {
@@ -91,27 +144,42 @@ func BulkUpdatePrices(c *gin.Context) {
 		}
 	}()
 
+	priceUpdateLog, err := applyPriceUpdateTx(tx, header, req)
+	if err != nil {
+		tx.Rollback()
+		return priceUpdateLog, err
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return priceUpdateLog, errors.New("Failed to commit transaction")
+	}
+
+	// Reload the price update log with relations
+	var updatedLog models.PriceUpdateLog
+	database.DB.Preload("UpdatedBy").Preload("PriceDetails.GoldCategory").First(&updatedLog, priceUpdateLog.ID)
+	return updatedLog, nil
+}
+
+// applyPriceUpdateTx does the work of applyPriceUpdate inside the caller's transaction.
+// UpdateDate defaults to now; scheduled updates pass their effective time.
+func applyPriceUpdateTx(tx *gorm.DB, header models.PriceUpdateLog, req BulkUpdatePriceRequest) (models.PriceUpdateLog, error) {
 	// Create price update log
-	now := time.Now()
-	priceUpdateLog := models.PriceUpdateLog{
-		UpdateDate:  now,
-		UpdatedByID: userID.(uint),
-		Notes:       req.Notes,
+	priceUpdateLog := header
+	if priceUpdateLog.UpdateDate.IsZero() {
+		priceUpdateLog.UpdateDate = time.Now()
 	}
+	priceUpdateLog.Notes = req.Notes
 
 	if err := tx.Create(&priceUpdateLog).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create price update log"})
-		return
+		return priceUpdateLog, errors.New("Failed to create price update log")
 	}
 
 	// Update each gold category
 	for _, item := range req.Prices {
 		var category models.GoldCategory
 		if err := tx.First(&category, item.GoldCategoryID).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "Gold category not found", "gold_category_id": item.GoldCategoryID})
-			return
+			return priceUpdateLog, &priceCategoryNotFoundError{GoldCategoryID: item.GoldCategoryID}
 		}
 
 		// Create price detail record
@@ -125,9 +193,7 @@ func BulkUpdatePrices(c *gin.Context) {
 		}
 
 		if err := tx.Create(&priceDetail).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create price detail"})
-			return
+			return priceUpdateLog, errors.New("Failed to create price detail")
 		}
 
 		// Update the gold category prices
@@ -135,33 +201,24 @@ func BulkUpdatePrices(c *gin.Context) {
 			"buy_price":  item.BuyPrice,
 			"sell_price": item.SellPrice,
 		}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update gold category"})
-			return
+			return priceUpdateLog, errors.New("Failed to update gold category")
 		}
 	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
-		return
-	}
-
-	// Reload the price update log with relations
-	var updatedLog models.PriceUpdateLog
-	database.DB.Preload("UpdatedBy").Preload("PriceDetails.GoldCategory").First(&updatedLog, priceUpdateLog.ID)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Prices updated successfully",
-		"data":    updatedLog,
-	})
+	return priceUpdateLog, nil
 }
 
 // GetPriceUpdateLogs returns history of price updates
 func GetPriceUpdateLogs(c *gin.Context) {
 	var logs []models.PriceUpdateLog
 
-	query := database.DB.Preload("UpdatedBy").Preload("PriceDetails.GoldCategory").Order("update_date DESC, created_at DESC")
+	query := database.DB.Preload("UpdatedBy").Preload("Location").Preload("PriceDetails.GoldCategory").Order("update_date DESC, created_at DESC")
+
+	// Filter by scope: location_id=global untuk harga global, atau ID lokasi
+	if locationID := c.Query("location_id"); locationID == "global" {
+		query = query.Where("location_id IS NULL")
+	} else if locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
 
 	// Optional date filtering
 	if startDate := c.Query("start_date"); startDate != "" {
@@ -186,7 +243,7 @@ func GetPriceUpdateLog(c *gin.Context) {
 	id := c.Param("id")
 
 	var log models.PriceUpdateLog
-	if err := database.DB.Preload("UpdatedBy").Preload("PriceDetails.GoldCategory").First(&log, id).Error; err != nil {
+	if err := database.DB.Preload("UpdatedBy").Preload("Location").Preload("PriceDetails.GoldCategory").First(&log, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Price update log not found"})
 		return
 	}
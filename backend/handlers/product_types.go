@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"starter/backend/database"
+	"starter/backend/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== PRODUCT TYPES ====================
+
+// codePattern is the format of product type and attribute codes (snake_case)
+var codePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// GetProductTypes returns product types with their attribute schema
+func GetProductTypes(c *gin.Context) {
+	var types []models.ProductTypeDefinition
+	query := database.DB.Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, id")
+	})
+
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("sort_order, name").Find(&types).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": types})
+}
+
+// GetProductType returns a product type by ID
+func GetProductType(c *gin.Context) {
+	id := c.Param("id")
+	var productType models.ProductTypeDefinition
+	if err := database.DB.Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order, id")
+	}).First(&productType, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product type not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": productType})
+}
+
+type ProductAttributeRequest struct {
+	Code          string                   `json:"code" binding:"required"`
+	Name          string                   `json:"name" binding:"required"`
+	DataType      models.AttributeDataType `json:"data_type" binding:"required"`
+	Unit          string                   `json:"unit"`
+	Required      bool                     `json:"required"`
+	AllowedValues []string                 `json:"allowed_values"`
+	MinValue      *float64                 `json:"min_value"`
+	MaxValue      *float64                 `json:"max_value"`
+}
+
+type ProductTypeRequest struct {
+	Code          models.ProductType        `json:"code" binding:"required"`
+	Name          string                    `json:"name" binding:"required"`
+	BarcodePrefix string                    `json:"barcode_prefix"`
+	Description   string                    `json:"description"`
+	SortOrder     int                       `json:"sort_order"`
+	IsActive      *bool                     `json:"is_active"`
+	Attributes    []ProductAttributeRequest `json:"attributes"`
+}
+
+// validate checks codes, data types and allowed values of the schema
+func (req ProductTypeRequest) validate() error {
+	if !codePattern.MatchString(string(req.Code)) {
+		return fmt.Errorf("Code must be lowercase letters, digits and underscores")
+	}
+	if len(req.BarcodePrefix) > 5 {
+		return fmt.Errorf("Barcode prefix can be at most 5 characters")
+	}
+	seen := make(map[string]bool)
+	for _, attr := range req.Attributes {
+		if !codePattern.MatchString(attr.Code) {
+			return fmt.Errorf("Attribute code %q must be lowercase letters, digits and underscores", attr.Code)
+		}
+		if seen[attr.Code] {
+			return fmt.Errorf("Attribute %s is defined twice", attr.Code)
+		}
+		seen[attr.Code] = true
+		if !attr.DataType.IsValid() {
+			return fmt.Errorf("Attribute %s has invalid data type %s", attr.Code, attr.DataType)
+		}
+		if attr.DataType == models.AttributeTypeSelect && len(attr.AllowedValues) == 0 {
+			return fmt.Errorf("Attribute %s of type select needs allowed_values", attr.Code)
+		}
+		if attr.MinValue != nil && attr.MaxValue != nil && *attr.MinValue > *attr.MaxValue {
+			return fmt.Errorf("Attribute %s has min_value greater than max_value", attr.Code)
+		}
+	}
+	return nil
+}
+
+// saveProductTypeAttributes upserts the attribute schema by code and removes attributes no longer listed
+func saveProductTypeAttributes(tx *gorm.DB, productTypeID uint, attrs []ProductAttributeRequest) error {
+	var existing []models.ProductAttributeDefinition
+	tx.Where("product_type_id = ?", productTypeID).Find(&existing)
+	byCode := make(map[string]models.ProductAttributeDefinition)
+	for _, e := range existing {
+		byCode[e.Code] = e
+	}
+
+	keep := make(map[string]bool)
+	for i, attr := range attrs {
+		def := byCode[attr.Code]
+		def.ProductTypeID = productTypeID
+		def.Code = attr.Code
+		def.Name = attr.Name
+		def.DataType = attr.DataType
+		def.Unit = attr.Unit
+		def.Required = attr.Required
+		def.AllowedValues = attr.AllowedValues
+		def.MinValue = attr.MinValue
+		def.MaxValue = attr.MaxValue
+		def.SortOrder = i
+		if err := tx.Save(&def).Error; err != nil {
+			return err
+		}
+		keep[attr.Code] = true
+	}
+
+	for _, e := range existing {
+		if keep[e.Code] {
+			continue
+		}
+		if err := tx.Delete(&e).Error; err != nil {
+			return err
+		}
+		// Nilai atribut yang dihapus dari skema ikut dihapus
+		if err := tx.Where("attribute_id = ?", e.ID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateProductType creates a product type with its attribute schema
+func CreateProductType(c *gin.Context) {
+	var req ProductTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	productType := models.ProductTypeDefinition{
+		Code:          req.Code,
+		Name:          req.Name,
+		BarcodePrefix: strings.ToUpper(req.BarcodePrefix),
+		Description:   req.Description,
+		SortOrder:     req.SortOrder,
+		IsActive:      isActive,
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&productType).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := saveProductTypeAttributes(tx, productType.ID, req.Attributes); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	database.DB.Preload("Attributes").First(&productType, productType.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": productType})
+}
+
+// UpdateProductType updates a product type and its attribute schema.
+// Kode tipe tidak bisa diubah karena dipakai oleh produk yang sudah ada.
+func UpdateProductType(c *gin.Context) {
+	id := c.Param("id")
+	var productType models.ProductTypeDefinition
+	if err := database.DB.First(&productType, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product type not found"})
+		return
+	}
+
+	var req ProductTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code != productType.Code {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product type code cannot be changed"})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productType.Name = req.Name
+	productType.BarcodePrefix = strings.ToUpper(req.BarcodePrefix)
+	productType.Description = req.Description
+	productType.SortOrder = req.SortOrder
+	if req.IsActive != nil {
+		productType.IsActive = *req.IsActive
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Omit("Attributes").Save(&productType).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := saveProductTypeAttributes(tx, productType.ID, req.Attributes); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	database.DB.Preload("Attributes").First(&productType, productType.ID)
+	c.JSON(http.StatusOK, gin.H{"data": productType})
+}
+
+// DeleteProductType deletes a product type that no product uses
+func DeleteProductType(c *gin.Context) {
+	id := c.Param("id")
+	var productType models.ProductTypeDefinition
+	if err := database.DB.First(&productType, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product type not found"})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.Product{}).Where("type = ?", productType.Code).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product type is used by %d products, deactivate it instead", count)})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("product_type_id = ?", productType.ID).Delete(&models.ProductAttributeDefinition{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := tx.Delete(&productType).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Product type deleted successfully"})
+}
+
+// ==================== ATTRIBUTE VALUES ====================
+
+// findProductType loads an active product type with its schema by code
+func findProductType(db *gorm.DB, code models.ProductType) (models.ProductTypeDefinition, error) {
+	var productType models.ProductTypeDefinition
+	if err := db.Preload("Attributes").Where("code = ?", code).First(&productType).Error; err != nil {
+		return productType, fmt.Errorf("Product type %s not found", code)
+	}
+	if !productType.IsActive {
+		return productType, fmt.Errorf("Product type %s is inactive", code)
+	}
+	return productType, nil
+}
+
+// validateProductAttributes checks input values against the schema of a product type
+// and returns the normalized values to store
+func validateProductAttributes(productType models.ProductTypeDefinition, input map[string]interface{}) ([]models.ProductAttributeValue, error) {
+	defs := make(map[string]models.ProductAttributeDefinition)
+	for _, def := range productType.Attributes {
+		defs[def.Code] = def
+	}
+	for code := range input {
+		if _, ok := defs[code]; !ok {
+			return nil, fmt.Errorf("Attribute %s is not defined for product type %s", code, productType.Name)
+		}
+	}
+
+	var values []models.ProductAttributeValue
+	for _, def := range productType.Attributes {
+		raw, ok := input[def.Code]
+		if !ok || raw == nil || fmt.Sprint(raw) == "" {
+			if def.Required {
+				return nil, fmt.Errorf("%s is required", def.Name)
+			}
+			continue
+		}
+
+		value := models.ProductAttributeValue{AttributeID: def.ID, Code: def.Code, Unit: def.Unit}
+		switch def.DataType {
+		case models.AttributeTypeNumber, models.AttributeTypeInteger:
+			n, err := attributeNumber(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", def.Name)
+			}
+			if def.DataType == models.AttributeTypeInteger && n != float64(int64(n)) {
+				return nil, fmt.Errorf("%s must be a whole number", def.Name)
+			}
+			if def.MinValue != nil && n < *def.MinValue {
+				return nil, fmt.Errorf("%s must be at least %g", def.Name, *def.MinValue)
+			}
+			if def.MaxValue != nil && n > *def.MaxValue {
+				return nil, fmt.Errorf("%s must be at most %g", def.Name, *def.MaxValue)
+			}
+			value.NumberValue = &n
+			value.Value = strconv.FormatFloat(n, 'f', -1, 64)
+		case models.AttributeTypeBoolean:
+			b, err := strconv.ParseBool(fmt.Sprint(raw))
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", def.Name)
+			}
+			value.Value = strconv.FormatBool(b)
+		default:
+			s := strings.TrimSpace(fmt.Sprint(raw))
+			if len(def.AllowedValues) > 0 {
+				matched := ""
+				for _, allowed := range def.AllowedValues {
+					if strings.EqualFold(allowed, s) {
+						matched = allowed
+					}
+				}
+				if matched == "" {
+					return nil, fmt.Errorf("%s must be one of: %s", def.Name, strings.Join(def.AllowedValues, ", "))
+				}
+				s = matched
+			}
+			if len(s) > 255 {
+				return nil, fmt.Errorf("%s is too long", def.Name)
+			}
+			value.Value = s
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// attributeNumber converts a JSON number or numeric string to float64
+func attributeNumber(raw interface{}) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	default:
+		return strconv.ParseFloat(strings.Replace(strings.TrimSpace(fmt.Sprint(v)), ",", ".", 1), 64)
+	}
+}
+
+// attributeValueMap turns stored values back into an input map (used when re-validating after a type change)
+func attributeValueMap(values []models.ProductAttributeValue) map[string]interface{} {
+	result := make(map[string]interface{})
+	for _, v := range values {
+		if v.NumberValue != nil {
+			result[v.Code] = *v.NumberValue
+		} else {
+			result[v.Code] = v.Value
+		}
+	}
+	return result
+}
+
+// replaceProductAttributes stores the validated attribute values of a product
+func replaceProductAttributes(tx *gorm.DB, productID uint, values []models.ProductAttributeValue) error {
+	if err := tx.Unscoped().Where("product_id = ?", productID).Delete(&models.ProductAttributeValue{}).Error; err != nil {
+		return err
+	}
+	for i := range values {
+		values[i].ID = 0
+		values[i].ProductID = productID
+		if err := tx.Create(&values[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// productAttributeFilters applies attr.<code>=value, attr.<code>.min and attr.<code>.max query params
+func productAttributeFilters(c *gin.Context, query *gorm.DB) *gorm.DB {
+	params := c.Request.URL.Query()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if strings.HasPrefix(key, "attr.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		code := strings.TrimPrefix(key, "attr.")
+		value := params.Get(key)
+		sub := database.DB.Model(&models.ProductAttributeValue{}).Select("product_id")
+		switch {
+		case strings.HasSuffix(code, ".min"):
+			sub = sub.Where("code = ? AND number_value >= ?", strings.TrimSuffix(code, ".min"), value)
+		case strings.HasSuffix(code, ".max"):
+			sub = sub.Where("code = ? AND number_value <= ?", strings.TrimSuffix(code, ".max"), value)
+		default:
+			sub = sub.Where("code = ? AND LOWER(value) = LOWER(?)", code, value)
+		}
+		query = query.Where("products.id IN (?)", sub)
+	}
+	return query
+}
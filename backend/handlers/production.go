@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fineGoldTolerance is the rounding margin (gram of pure gold) allowed when reconciling production
+const fineGoldTolerance = 0.01
+
+// ProductionYieldLossApprovePermission lets a supervisor approve yield loss above the limit
+const ProductionYieldLossApprovePermission = "production.approve-yield-loss"
+
+// maxYieldLossPercent is the production yield loss (persen emas murni bahan) accepted without approval
+var maxYieldLossPercent = 3.0
+
+// SetMaxYieldLossPercent sets the yield loss accepted without supervisor approval
+func SetMaxYieldLossPercent(percent float64) {
+	maxYieldLossPercent = percent
+}
+
+// GetProductionOrders returns production orders
+func GetProductionOrders(c *gin.Context) {
+	var orders []models.ProductionOrder
+	query := database.DB.Preload("Location").Preload("GoldCategory").Preload("CreatedBy")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orders})
+}
+
+// GetProductionOrder returns a production order with its inputs, outputs and produced stocks
+func GetProductionOrder(c *gin.Context) {
+	id := c.Param("id")
+	var order models.ProductionOrder
+	if err := database.DB.Preload("Location").Preload("GoldCategory").Preload("CreatedBy").Preload("YieldLossApprovedBy").
+		Preload("Inputs").Preload("Inputs.RawMaterial").Preload("Inputs.MeltBatch").
+		Preload("Outputs").Preload("Outputs.Product").
+		First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Production order not found"})
+		return
+	}
+
+	var stocks []models.Stock
+	database.DB.Preload("Product").Where("production_order_id = ?", order.ID).Order("serial_number").Find(&stocks)
+
+	c.JSON(http.StatusOK, gin.H{"data": order, "stocks": stocks})
+}
+
+type ProductionInputRequest struct {
+	RawMaterialID *uint   `json:"raw_material_id"`
+	MeltBatchID   *uint   `json:"melt_batch_id"` // Hasil lebur dipakai seluruhnya
+	Weight        float64 `json:"weight"`        // Berat bahan baku yang dipakai, kosong = seluruhnya
+}
+
+type CreateProductionOrderRequest struct {
+	LocationID     uint                     `json:"location_id" binding:"required"`
+	GoldCategoryID uint                     `json:"gold_category_id" binding:"required"`
+	CraftsmanName  string                   `json:"craftsman_name"`
+	Inputs         []ProductionInputRequest `json:"inputs" binding:"required,min=1"`
+	Notes          string                   `json:"notes"`
+}
+
+// CreateProductionOrder reserves raw material for a craftsman in a draft production order
+func CreateProductionOrder(c *gin.Context) {
+	var req CreateProductionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	var goldCategory models.GoldCategory
+	if err := database.DB.First(&goldCategory, req.GoldCategoryID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gold category not found"})
+		return
+	}
+	if goldCategory.Purity == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Gold category %s has no purity, fine gold cannot be reconciled", goldCategory.Name)})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	order := models.ProductionOrder{
+		OrderNumber:    generateTransactionCode("PO"),
+		LocationID:     req.LocationID,
+		GoldCategoryID: req.GoldCategoryID,
+		Status:         models.ProductionOrderStatusDraft,
+		CraftsmanName:  req.CraftsmanName,
+		CreatedByID:    currentUserID,
+		Notes:          req.Notes,
+	}
+
+	for _, inputReq := range req.Inputs {
+		input, err := buildProductionInput(tx, req.LocationID, inputReq)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		order.Inputs = append(order.Inputs, input)
+		order.InputWeight += input.Weight
+		order.InputFineWeight += input.FineWeight
+		order.InputCost += input.Cost
+	}
+
+	if err := tx.Create(&order).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("GoldCategory").Preload("Inputs").First(&order, order.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": order})
+}
+
+// buildProductionInput validates one input line and values it at its share of the raw material cost
+func buildProductionInput(db *gorm.DB, locationID uint, req ProductionInputRequest) (models.ProductionInput, error) {
+	if (req.RawMaterialID == nil) == (req.MeltBatchID == nil) {
+		return models.ProductionInput{}, fmt.Errorf("Each input needs either raw_material_id or melt_batch_id")
+	}
+
+	if req.MeltBatchID != nil {
+		var batch models.MeltBatch
+		if err := db.First(&batch, *req.MeltBatchID).Error; err != nil {
+			return models.ProductionInput{}, fmt.Errorf("Melt batch %d not found", *req.MeltBatchID)
+		}
+		if batch.Status != models.MeltBatchStatusMelted {
+			return models.ProductionInput{}, fmt.Errorf("Melt batch %s is %s, only melted batches can be used", batch.BatchNumber, batch.Status)
+		}
+		if batch.LocationID != locationID {
+			return models.ProductionInput{}, fmt.Errorf("Melt batch %s is at another location", batch.BatchNumber)
+		}
+		if orderNumber := productionOrderOfInput(db, "melt_batch_id", batch.ID); orderNumber != "" {
+			return models.ProductionInput{}, fmt.Errorf("Melt batch %s is already used by production order %s", batch.BatchNumber, orderNumber)
+		}
+		return models.ProductionInput{
+			MeltBatchID: &batch.ID,
+			Weight:      batch.OutputWeight,
+			Purity:      batch.AssayedPurity / 100,
+			FineWeight:  batch.ActualFineWeight,
+			Cost:        batch.TotalCost,
+		}, nil
+	}
+
+	var rm models.RawMaterial
+	if err := db.Preload("GoldCategory").First(&rm, *req.RawMaterialID).Error; err != nil {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %d not found", *req.RawMaterialID)
+	}
+	if rm.Status != models.RawMaterialStatusAvailable {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s is not available", rm.Code)
+	}
+	if rm.LocationID != locationID {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s is at another location", rm.Code)
+	}
+	if rm.PurityFraction() <= 0 {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s has no purity, fine gold cannot be reconciled", rm.Code)
+	}
+	if batchNumber := meltBatchOfRawMaterial(db, rm.ID); batchNumber != "" {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s is in melt batch %s", rm.Code, batchNumber)
+	}
+	if orderNumber := productionOrderOfInput(db, "raw_material_id", rm.ID); orderNumber != "" {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s is already used by production order %s", rm.Code, orderNumber)
+	}
+
+	weight := req.Weight
+	if weight <= 0 {
+		weight = rm.WeightGrams
+	}
+	if weight > rm.WeightGrams+fineGoldTolerance {
+		return models.ProductionInput{}, fmt.Errorf("Raw material %s only has %.3f g", rm.Code, rm.WeightGrams)
+	}
+
+	cost := rm.TotalBuyPrice
+	if rm.WeightGrams > 0 {
+		cost = rm.TotalBuyPrice * weight / rm.WeightGrams
+	}
+
+	return models.ProductionInput{
+		RawMaterialID: &rm.ID,
+		Weight:        weight,
+		Purity:        rm.PurityFraction(),
+		FineWeight:    weight * rm.PurityFraction(),
+		Cost:          cost,
+	}, nil
+}
+
+// productionOrderOfInput returns the number of a draft production order already using the raw material or batch
+func productionOrderOfInput(db *gorm.DB, column string, id uint) string {
+	var orderNumber string
+	db.Table("production_inputs").
+		Select("production_orders.order_number").
+		Joins("JOIN production_orders ON production_orders.id = production_inputs.production_order_id").
+		Where("production_inputs."+column+" = ? AND production_inputs.deleted_at IS NULL", id).
+		Where("production_orders.status = ? AND production_orders.deleted_at IS NULL", models.ProductionOrderStatusDraft).
+		Limit(1).
+		Scan(&orderNumber)
+	return orderNumber
+}
+
+type ProductionOutputRequest struct {
+	ProductID    uint      `json:"product_id" binding:"required"`
+	StorageBoxID uint      `json:"storage_box_id" binding:"required"`
+	PieceWeights []float64 `json:"piece_weights" binding:"required,min=1"` // Berat aktual tiap buah
+}
+
+type CompleteProductionOrderRequest struct {
+	Outputs     []ProductionOutputRequest `json:"outputs" binding:"required,min=1"`
+	LaborCost   float64                   `json:"labor_cost"`
+	OtherCost   float64                   `json:"other_cost"`
+	ScrapWeight float64                   `json:"scrap_weight"` // Sisa/serbuk emas yang dikembalikan pengrajin
+	ScrapPurity float64                   `json:"scrap_purity"` // Kadar sisa (%), default kadar barang jadi
+	Notes       string                    `json:"notes"`
+
+	// Wajib jika susut melebihi batas
+	SupervisorUsername string `json:"supervisor_username"`
+	SupervisorPassword string `json:"supervisor_password"`
+}
+
+// CompleteProductionOrder records the finished pieces, reconciles fine gold and creates the stock
+func CompleteProductionOrder(c *gin.Context) {
+	id := c.Param("id")
+	var req CompleteProductionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	var order models.ProductionOrder
+	if err := tx.Preload("GoldCategory").Preload("Inputs").First(&order, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Production order not found"})
+		return
+	}
+
+	// Klaim order secara kondisional agar penyelesaian bersamaan tidak membuat stok dan mutasi dua kali
+	claim := tx.Model(&models.ProductionOrder{}).
+		Where("id = ? AND status = ?", order.ID, models.ProductionOrderStatusDraft).
+		Update("status", models.ProductionOrderStatusCompleted)
+	if claim.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": claim.Error.Error()})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft production orders can be completed"})
+		return
+	}
+	if order.GoldCategory.Purity == nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Gold category %s has no purity, fine gold cannot be reconciled", order.GoldCategory.Name)})
+		return
+	}
+	purity := *order.GoldCategory.Purity
+
+	// Hitung hasil produksi
+	var outputs []models.ProductionOutput
+	products := make(map[uint]models.Product)
+	for _, outputReq := range req.Outputs {
+		var product models.Product
+		if err := tx.First(&product, outputReq.ProductID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product ID %d not found", outputReq.ProductID)})
+			return
+		}
+		if product.GoldCategoryID != order.GoldCategoryID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Product %s is not in gold category %s", product.Name, order.GoldCategory.Name)})
+			return
+		}
+
+		var box models.StorageBox
+		if err := tx.First(&box, outputReq.StorageBoxID).Error; err != nil || box.LocationID != order.LocationID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Storage box %d not found at this location", outputReq.StorageBoxID)})
+			return
+		}
+		if storageBoxHasChildren(tx, box.ID) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+			return
+		}
+
+		output := models.ProductionOutput{
+			ProductID:    product.ID,
+			StorageBoxID: box.ID,
+			Quantity:     len(outputReq.PieceWeights),
+			PieceWeights: outputReq.PieceWeights,
+		}
+		for _, weight := range outputReq.PieceWeights {
+			if weight <= 0 {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Piece weights must be greater than zero"})
+				return
+			}
+			output.TotalWeight += weight
+		}
+		output.FineWeight = output.TotalWeight * purity
+
+		products[product.ID] = product
+		outputs = append(outputs, output)
+		order.OutputWeight += output.TotalWeight
+		order.OutputFineWeight += output.FineWeight
+		order.OutputQuantity += output.Quantity
+	}
+
+	scrapPurity := purity
+	if req.ScrapPurity > 0 {
+		scrapPurity = req.ScrapPurity / 100
+	}
+	order.ScrapWeight = req.ScrapWeight
+	order.ScrapFineWeight = req.ScrapWeight * scrapPurity
+
+	// Neraca emas murni: bahan = barang jadi + sisa + susut
+	order.YieldLossFineWeight = order.InputFineWeight - order.OutputFineWeight - order.ScrapFineWeight
+	if order.YieldLossFineWeight < -fineGoldTolerance {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Fine gold does not reconcile: input %.3f g, output %.3f g, scrap %.3f g",
+				order.InputFineWeight, order.OutputFineWeight, order.ScrapFineWeight),
+		})
+		return
+	}
+	if order.InputFineWeight > 0 {
+		order.YieldLossPercent = order.YieldLossFineWeight / order.InputFineWeight * 100
+	}
+	// Susut di atas batas tidak boleh dihapus begitu saja, harus disetujui supervisor
+	if order.YieldLossPercent > maxYieldLossPercent {
+		supervisor, err := verifySupervisor(tx, req.SupervisorUsername, req.SupervisorPassword, currentUserID,
+			ProductionYieldLossApprovePermission, "yield loss above the limit")
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":             fmt.Sprintf("Yield loss %.2f%% (%.3f g fine gold) exceeds the limit of %.2f%%: %s", order.YieldLossPercent, order.YieldLossFineWeight, maxYieldLossPercent, err.Error()),
+				"requires_approval": true,
+				"loss_percent":      order.YieldLossPercent,
+				"max_loss_percent":  maxYieldLossPercent,
+			})
+			return
+		}
+		order.YieldLossApprovedByID = &supervisor.ID
+	}
+
+	// Biaya bahan dibagi ke sisa sesuai porsi emas murninya, sisanya + ongkos masuk ke barang jadi
+	if order.InputFineWeight > 0 {
+		order.ScrapCost = order.InputCost * order.ScrapFineWeight / order.InputFineWeight
+	}
+	order.LaborCost = req.LaborCost
+	order.OtherCost = req.OtherCost
+	order.TotalCost = order.InputCost - order.ScrapCost + order.LaborCost + order.OtherCost
+	order.CostPerGram = order.TotalCost / order.OutputWeight
+
+	// Pakai bahan
+	if err := consumeProductionInputs(tx, order.Inputs); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	// Sisa dicatat kembali sebagai bahan baku
+	if order.ScrapWeight > 0 {
+		scrap := models.RawMaterial{
+			Code:            GenerateRawMaterialCode(),
+			GoldCategoryID:  &order.GoldCategoryID,
+			LocationID:      order.LocationID,
+			WeightGross:     order.ScrapWeight,
+			WeightGrams:     order.ScrapWeight,
+			Purity:          scrapPurity * 100,
+			BuyPricePerGram: order.ScrapCost / order.ScrapWeight,
+			TotalBuyPrice:   order.ScrapCost,
+			Condition:       models.RawMaterialConditionDamaged,
+			Status:          models.RawMaterialStatusAvailable,
+			ReceivedAt:      &now,
+			ReceivedByID:    &currentUserID,
+			WeightSource:    models.WeightSourceTyped,
+			Notes:           fmt.Sprintf("Sisa produksi %s", order.OrderNumber),
+		}
+		if err := tx.Create(&scrap).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create raw material: " + err.Error()})
+			return
+		}
+		order.ScrapRawMaterialID = &scrap.ID
+	}
+
+	// Buat stok barang jadi dengan berat aktual dan harga modal hasil alokasi
+	timestamp := now.Unix()
+	serialIndex := 0
+	var stocks []models.Stock
+	for i := range outputs {
+		output := &outputs[i]
+		output.ProductionOrderID = order.ID
+		output.TotalCost = order.CostPerGram * output.TotalWeight
+		if err := tx.Create(output).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		product := products[output.ProductID]
+		for _, weight := range output.PieceWeights {
+			stock := models.Stock{
+				ProductID:          output.ProductID,
+				LocationID:         order.LocationID,
+				StorageBoxID:       output.StorageBoxID,
+				SerialNumber:       generateSerialNumber(product.Barcode, timestamp, serialIndex),
+				Status:             models.StockStatusAvailable,
+				SupplierName:       "Produksi sendiri",
+				ProductionOrderID:  &order.ID,
+				ReceivedAt:         &now,
+				GrossWeight:        weight,
+				NetWeight:          weight,
+				WeightVerifiedAt:   &now,
+				WeightVerifiedByID: &currentUserID,
+				WeightSource:       models.WeightSourceTyped,
+				CostPrice:          order.CostPerGram * weight,
+				CostPerGram:        order.CostPerGram,
+				Notes:              fmt.Sprintf("Produksi %s", order.OrderNumber),
+			}
+			serialIndex++
+			if err := tx.Create(&stock).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			stocks = append(stocks, stock)
+		}
+	}
+
+	order.Status = models.ProductionOrderStatusCompleted
+	order.CompletedAt = &now
+	order.CompletedByID = &currentUserID
+	if req.Notes != "" {
+		order.Notes = req.Notes
+	}
+	if err := tx.Omit("Inputs", "Outputs", "GoldCategory").Save(&order).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Bahan menjadi barang jadi dan sisa; yang tercatat hanya susut produksi
+	if err := recordFineGold(tx, models.FineGoldMovement{
+		LocationID:      order.LocationID,
+		Type:            models.FineGoldMovementProduction,
+		GoldCategoryID:  &order.GoldCategoryID,
+		GrossWeight:     order.OutputWeight + order.ScrapWeight - order.InputWeight,
+		Purity:          purity,
+		FineWeight:      -order.YieldLossFineWeight,
+		ReferenceType:   "production_order",
+		ReferenceID:     order.ID,
+		ReferenceNumber: order.OrderNumber,
+		OccurredAt:      now,
+		CreatedByID:     &currentUserID,
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   order,
+		"stocks": stocks,
+		"fine_gold_balance": gin.H{
+			"input":            order.InputFineWeight,
+			"output":           order.OutputFineWeight,
+			"scrap":            order.ScrapFineWeight,
+			"loss":             order.YieldLossFineWeight,
+			"loss_percent":     order.YieldLossPercent,
+			"max_loss_percent": maxYieldLossPercent,
+			"approved_by_id":   order.YieldLossApprovedByID,
+		},
+	})
+}
+
+// consumeProductionInputs takes the used weight out of the raw materials and marks used melt batches consumed
+func consumeProductionInputs(tx *gorm.DB, inputs []models.ProductionInput) error {
+	now := time.Now()
+	for _, input := range inputs {
+		if input.MeltBatchID != nil {
+			// Batch bisa sudah dipakai order lain sejak order ini dibuat
+			result := tx.Model(&models.MeltBatch{}).
+				Where("id = ? AND status = ?", *input.MeltBatchID, models.MeltBatchStatusMelted).
+				Update("status", models.MeltBatchStatusConsumed)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("Melt batch %d is no longer melted", *input.MeltBatchID)
+			}
+			continue
+		}
+
+		var rm models.RawMaterial
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rm, *input.RawMaterialID).Error; err != nil {
+			return fmt.Errorf("Raw material %d not found", *input.RawMaterialID)
+		}
+		if rm.Status != models.RawMaterialStatusAvailable {
+			return fmt.Errorf("Raw material %s is no longer available", rm.Code)
+		}
+
+		remaining := rm.WeightGrams - input.Weight
+		if remaining <= fineGoldTolerance {
+			if err := tx.Model(&rm).Updates(map[string]interface{}{
+				"status":       models.RawMaterialStatusProcessed,
+				"processed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Dipakai sebagian: kurangi berat dan nilai secara proporsional
+		ratio := remaining / rm.WeightGrams
+		if err := tx.Model(&rm).Updates(map[string]interface{}{
+			"weight_grams":    remaining,
+			"weight_gross":    rm.WeightGross * ratio,
+			"total_buy_price": rm.TotalBuyPrice * ratio,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CancelProductionOrder cancels a draft production order and releases its inputs
+func CancelProductionOrder(c *gin.Context) {
+	id := c.Param("id")
+	var order models.ProductionOrder
+	if err := database.DB.First(&order, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Production order not found"})
+		return
+	}
+
+	result := database.DB.Model(&models.ProductionOrder{}).
+		Where("id = ? AND status = ?", order.ID, models.ProductionOrderStatusDraft).
+		Update("status", models.ProductionOrderStatusCancelled)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft production orders can be cancelled"})
+		return
+	}
+	order.Status = models.ProductionOrderStatusCancelled
+	c.JSON(http.StatusOK, gin.H{"data": order})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +14,18 @@ import (
 // GetProducts returns all products
 func GetProducts(c *gin.Context) {
 	var products []models.Product
-	query := database.DB.Preload("GoldCategory")
+	query := database.DB.Preload("GoldCategory").Preload("Attributes").Preload("Stones")
 
 	// Filter by type if provided
 	if productType := c.Query("type"); productType != "" {
 		query = query.Where("type = ?", productType)
 	}
 
+	// Filter by kind (jewelry / bullion)
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
 	// Filter by category if provided
 	if category := c.Query("category"); category != "" {
 		query = query.Where("category = ?", category)
@@ -30,6 +36,16 @@ func GetProducts(c *gin.Context) {
 		query = query.Where("gold_category_id = ?", goldCategoryID)
 	}
 
+	// Search by name, barcode or any attribute value
+	if search := c.Query("search"); search != "" {
+		pattern := "%" + search + "%"
+		query = query.Where("products.name ILIKE ? OR products.barcode ILIKE ? OR products.id IN (?)", pattern, pattern,
+			database.DB.Model(&models.ProductAttributeValue{}).Select("product_id").Where("value ILIKE ?", pattern))
+	}
+
+	// Filter by attribute values (attr.ring_size=12, attr.bracelet_length.min=16)
+	query = productAttributeFilters(c, query)
+
 	if err := query.Find(&products).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -41,7 +57,7 @@ func GetProducts(c *gin.Context) {
 func GetProduct(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
-	if err := database.DB.Preload("GoldCategory").Preload("Stocks").First(&product, id).Error; err != nil {
+	if err := database.DB.Preload("GoldCategory").Preload("Attributes").Preload("Stones").Preload("Images").Preload("Stocks").First(&product, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -52,7 +68,7 @@ func GetProduct(c *gin.Context) {
 func GetProductByBarcode(c *gin.Context) {
 	barcode := c.Param("barcode")
 	var product models.Product
-	if err := database.DB.Preload("GoldCategory").Where("barcode = ?", barcode).First(&product).Error; err != nil {
+	if err := database.DB.Preload("GoldCategory").Preload("Attributes").Preload("Stones").Preload("Images").Where("barcode = ?", barcode).First(&product).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -66,10 +82,13 @@ type CreateProductRequest struct {
 	GoldCategoryID uint                   `json:"gold_category_id" binding:"required"`
 	Weight         float64                `json:"weight" binding:"required"`
 	Description    string                 `json:"description"`
-	RingSize       string                 `json:"ring_size"`
-	BraceletLength float64                `json:"bracelet_length"`
-	NecklaceLength float64                `json:"necklace_length"`
-	EarringType    string                 `json:"earring_type"`
+	MakingCharge   float64                `json:"making_charge"` // Ongkos pembuatan per buah
+	Stones         []StoneRequest         `json:"stones"`
+	Kind           models.ProductKind     `json:"kind"` // jewelry (default) atau bullion
+	Manufacturer   string                 `json:"manufacturer"`
+	BullionBuy     float64                `json:"bullion_buy_price"`  // Harga buyback per keping (logam mulia)
+	BullionSell    float64                `json:"bullion_sell_price"` // Harga jual per keping (logam mulia)
+	Attributes     map[string]interface{} `json:"attributes"`         // Nilai atribut sesuai skema tipe produk, key = kode atribut
 	ImageURL       string                 `json:"image_url"`
 	IsActive       *bool                  `json:"is_active"`
 }
@@ -82,8 +101,28 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
+	productType, err := findProductType(database.DB, req.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	attributes, err := validateProductAttributes(productType, req.Attributes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stones, err := buildStoneComponents(req.Stones)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MakingCharge < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Making charge cannot be negative"})
+		return
+	}
+
 	// Generate barcode
-	barcode := generateBarcode(req.Type)
+	barcode := generateBarcode(productType)
 
 	isActive := true
 	if req.IsActive != nil {
@@ -98,37 +137,54 @@ func CreateProduct(c *gin.Context) {
 		GoldCategoryID: req.GoldCategoryID,
 		Weight:         req.Weight,
 		Description:    req.Description,
-		RingSize:       req.RingSize,
-		BraceletLength: req.BraceletLength,
-		NecklaceLength: req.NecklaceLength,
-		EarringType:    req.EarringType,
+		MakingCharge:   req.MakingCharge,
+		Kind:           req.Kind,
+		Manufacturer:   strings.TrimSpace(req.Manufacturer),
 		ImageURL:       req.ImageURL,
 		IsActive:       isActive,
 	}
+	if err := validateProductKind(&product, stones); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if product.IsBullion() {
+		if req.BullionBuy > req.BullionSell {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bullion buy price cannot exceed sell price"})
+			return
+		}
+		now := time.Now()
+		product.BullionBuyPrice = req.BullionBuy
+		product.BullionSellPrice = req.BullionSell
+		product.BullionPriceUpdatedAt = &now
+	}
 
-	if err := database.DB.Create(&product).Error; err != nil {
+	tx := database.DB.Begin()
+	if err := tx.Omit("Attributes", "Stones").Create(&product).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := replaceProductAttributes(tx, product.ID, attributes); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := replaceStones(tx, "product_id", product.ID, stones); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
 
-	database.DB.Preload("GoldCategory").First(&product, product.ID)
+	database.DB.Preload("GoldCategory").Preload("Attributes").Preload("Stones").First(&product, product.ID)
 	c.JSON(http.StatusCreated, gin.H{"data": product})
 }
 
-// generateBarcode generates a unique barcode for product
-func generateBarcode(productType models.ProductType) string {
+// generateBarcode generates a unique barcode for product using the barcode prefix of its type
+func generateBarcode(productType models.ProductTypeDefinition) string {
 	prefix := "GLD"
-	switch productType {
-	case models.ProductTypeGelang:
-		prefix = "GLG"
-	case models.ProductTypeCincin:
-		prefix = "CIN"
-	case models.ProductTypeKalung:
-		prefix = "KLG"
-	case models.ProductTypeAnting:
-		prefix = "ANT"
-	case models.ProductTypeLiontin:
-		prefix = "LNT"
+	if productType.BarcodePrefix != "" {
+		prefix = productType.BarcodePrefix
 	}
 	timestamp := time.Now().UnixNano() / 1000000
 	return fmt.Sprintf("%s%d", prefix, timestamp)
@@ -141,10 +197,11 @@ type UpdateProductRequest struct {
 	GoldCategoryID uint                   `json:"gold_category_id"`
 	Weight         float64                `json:"weight"`
 	Description    string                 `json:"description"`
-	RingSize       string                 `json:"ring_size"`
-	BraceletLength float64                `json:"bracelet_length"`
-	NecklaceLength float64                `json:"necklace_length"`
-	EarringType    string                 `json:"earring_type"`
+	MakingCharge   *float64               `json:"making_charge"`
+	Kind           models.ProductKind     `json:"kind"`
+	Manufacturer   string                 `json:"manufacturer"`
+	Stones         []StoneRequest         `json:"stones"`     // nil = tidak diubah, [] = hapus semua batu
+	Attributes     map[string]interface{} `json:"attributes"` // Nilai atribut sesuai skema tipe produk, key = kode atribut
 	ImageURL       string                 `json:"image_url"`
 	IsActive       *bool                  `json:"is_active"`
 }
@@ -153,7 +210,7 @@ type UpdateProductRequest struct {
 func UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
 	var product models.Product
-	if err := database.DB.First(&product, id).Error; err != nil {
+	if err := database.DB.Preload("Attributes").Preload("Stones").First(&product, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
@@ -164,6 +221,7 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
+	typeBefore := product.Type
 	if req.Name != "" {
 		product.Name = req.Name
 	}
@@ -182,17 +240,18 @@ func UpdateProduct(c *gin.Context) {
 	if req.Description != "" {
 		product.Description = req.Description
 	}
-	if req.RingSize != "" {
-		product.RingSize = req.RingSize
-	}
-	if req.BraceletLength > 0 {
-		product.BraceletLength = req.BraceletLength
+	if req.MakingCharge != nil {
+		if *req.MakingCharge < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Making charge cannot be negative"})
+			return
+		}
+		product.MakingCharge = *req.MakingCharge
 	}
-	if req.NecklaceLength > 0 {
-		product.NecklaceLength = req.NecklaceLength
+	if req.Kind != "" {
+		product.Kind = req.Kind
 	}
-	if req.EarringType != "" {
-		product.EarringType = req.EarringType
+	if req.Manufacturer != "" {
+		product.Manufacturer = strings.TrimSpace(req.Manufacturer)
 	}
 	if req.ImageURL != "" {
 		product.ImageURL = req.ImageURL
@@ -201,12 +260,62 @@ func UpdateProduct(c *gin.Context) {
 		product.IsActive = *req.IsActive
 	}
 
-	if err := database.DB.Save(&product).Error; err != nil {
+	// Atribut divalidasi ulang bila diisi atau bila tipe produk berubah
+	var attributes []models.ProductAttributeValue
+	typeChanged := req.Type != "" && req.Type != typeBefore
+	if req.Attributes != nil || typeChanged {
+		productType, err := findProductType(database.DB, product.Type)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input := req.Attributes
+		if input == nil {
+			input = attributeValueMap(product.Attributes)
+		}
+		attributes, err = validateProductAttributes(productType, input)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	stones := product.Stones
+	if req.Stones != nil {
+		var err error
+		if stones, err = buildStoneComponents(req.Stones); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := validateProductKind(&product, stones); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Omit("Attributes", "Stones").Save(&product).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Attributes != nil || typeChanged {
+		if err := replaceProductAttributes(tx, product.ID, attributes); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Stones != nil {
+		if err := replaceStones(tx, "product_id", product.ID, stones); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
 
-	database.DB.Preload("GoldCategory").First(&product, product.ID)
+	database.DB.Preload("GoldCategory").Preload("Attributes").Preload("Stones").First(&product, product.ID)
 	c.JSON(http.StatusOK, gin.H{"data": product})
 }
 
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== QUOTATIONS (PENAWARAN HARGA) ====================
+
+var (
+	// quotationValidity is the default validity window of a new quotation
+	quotationValidity = time.Hour
+	// quotationMaxValidity is the longest validity a cashier may ask for
+	quotationMaxValidity = 24 * time.Hour
+)
+
+// SetQuotationValidity sets the default and the maximum validity window of new quotations
+func SetQuotationValidity(validity, maxValidity time.Duration) {
+	quotationValidity = validity
+	quotationMaxValidity = maxValidity
+}
+
+// stockQuotation returns the valid quotation holding a stock piece, other than exceptID
+func stockQuotation(db *gorm.DB, stockID, exceptID uint) (models.Quotation, bool) {
+	var quotation models.Quotation
+	err := db.Joins("JOIN quotation_items qi ON qi.quotation_id = quotations.id AND qi.deleted_at IS NULL").
+		Where("qi.stock_id = ? AND quotations.status = ? AND quotations.valid_until > ? AND quotations.id <> ?",
+			stockID, models.QuotationStatusActive, time.Now(), exceptID).
+		First(&quotation).Error
+	return quotation, err == nil
+}
+
+// quotedItem returns the line of a stock piece in the quotation, if any
+func quotedItem(quotation *models.Quotation, stockID uint) (models.QuotationItem, bool) {
+	if quotation == nil {
+		return models.QuotationItem{}, false
+	}
+	for _, item := range quotation.Items {
+		if item.StockID == stockID {
+			return item, true
+		}
+	}
+	return models.QuotationItem{}, false
+}
+
+// loadSaleQuotation locks a quotation for a sale and checks that it is still valid at the sale location
+func loadSaleQuotation(tx *gorm.DB, quotationID, locationID uint) (models.Quotation, error) {
+	var quotation models.Quotation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&quotation, quotationID).Error; err != nil {
+		return quotation, fmt.Errorf("Quotation ID %d not found", quotationID)
+	}
+	if quotation.Status != models.QuotationStatusActive {
+		return quotation, fmt.Errorf("Quotation %s is %s", quotation.QuotationNumber, quotation.Status)
+	}
+	if !quotation.IsValidAt(time.Now()) {
+		return quotation, fmt.Errorf("Quotation %s expired at %s", quotation.QuotationNumber, quotation.ValidUntil.Format("2006-01-02 15:04"))
+	}
+	if quotation.LocationID != locationID {
+		return quotation, fmt.Errorf("Quotation %s is for another location", quotation.QuotationNumber)
+	}
+	if err := tx.Where("quotation_id = ?", quotation.ID).Find(&quotation.Items).Error; err != nil {
+		return quotation, err
+	}
+	return quotation, nil
+}
+
+// expireQuotations closes active quotations past their validity window so their pieces are released
+func expireQuotations(db *gorm.DB) (int64, error) {
+	result := db.Model(&models.Quotation{}).
+		Where("status = ? AND valid_until <= ?", models.QuotationStatusActive, time.Now()).
+		Updates(map[string]interface{}{
+			"status":    models.QuotationStatusExpired,
+			"closed_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// StartQuotationExpiryJob expires quotations at start and then periodically
+func StartQuotationExpiryJob(interval time.Duration) {
+	go func() {
+		run := func() {
+			expired, err := expireQuotations(database.DB)
+			if err != nil {
+				log.Println("Quotation expiry job failed:", err)
+			}
+			if expired > 0 {
+				log.Printf("Quotation expiry job expired %d quotations", expired)
+			}
+		}
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+type CreateQuotationRequest struct {
+	LocationID    uint   `json:"location_id" binding:"required"`
+	MemberID      *uint  `json:"member_id"`
+	CustomerName  string `json:"customer_name"`
+	CustomerPhone string `json:"customer_phone"`
+	StockIDs      []uint `json:"stock_ids" binding:"required,min=1"`
+	ValidMinutes  int    `json:"valid_minutes"` // Kosong = masa berlaku default
+	Notes         string `json:"notes"`
+}
+
+// CreateQuotation quotes stock pieces at the current price and holds them until the quotation expires
+func CreateQuotation(c *gin.Context) {
+	var req CreateQuotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ValidMinutes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid minutes cannot be negative"})
+		return
+	}
+	// Dicek sebelum dikalikan agar nilai besar tidak overflow
+	if maxMinutes := int(quotationMaxValidity / time.Minute); req.ValidMinutes > maxMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Valid minutes cannot exceed %d", maxMinutes)})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	validity := quotationValidity
+	if req.ValidMinutes > 0 {
+		validity = time.Duration(req.ValidMinutes) * time.Minute
+	}
+
+	quotation := models.Quotation{
+		QuotationNumber: generateTransactionCode("QT"),
+		LocationID:      req.LocationID,
+		MemberID:        req.MemberID,
+		CustomerName:    req.CustomerName,
+		CustomerPhone:   req.CustomerPhone,
+		ValidUntil:      time.Now().Add(validity),
+		Status:          models.QuotationStatusActive,
+		Notes:           req.Notes,
+		CreatedByID:     currentUserID,
+	}
+
+	tx := database.DB.Begin()
+	locationPrices := loadLocationPrices(tx, req.LocationID)
+	seen := make(map[uint]bool)
+
+	for _, stockID := range req.StockIDs {
+		if seen[stockID] {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock ID %d is listed twice", stockID)})
+			return
+		}
+		seen[stockID] = true
+
+		// Kunci baris stok agar buah yang sama tidak masuk dua penawaran sekaligus
+		var stock models.Stock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Stock{}, stockID).Error
+		if err == nil {
+			err = tx.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Product.Images").
+				Preload("Stones").Preload("Images").First(&stock, stockID).Error
+		}
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock ID %d not found", stockID)})
+			return
+		}
+		if stock.Status != models.StockStatusAvailable {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is not available", stock.SerialNumber)})
+			return
+		}
+		if stock.LocationID != req.LocationID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is not in this location", stock.SerialNumber)})
+			return
+		}
+		if held, ok := stockQuotation(tx, stock.ID, 0); ok {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is already reserved by quotation %s", stock.SerialNumber, held.QuotationNumber)})
+			return
+		}
+
+		line := saleLine(stock, locationPrices)
+		quotation.SubTotal += line.UnitPrice
+		quotation.Items = append(quotation.Items, models.QuotationItem{
+			StockID:      stock.ID,
+			ItemName:     line.ItemName,
+			Barcode:      line.Barcode,
+			SerialNumber: stock.SerialNumber,
+			Weight:       line.Weight,
+			PricePerGram: line.PricePerGram,
+			UnitPrice:    line.UnitPrice,
+			GoldValue:    line.GoldValue,
+			StoneValue:   line.StoneValue,
+			MakingCharge: line.MakingCharge,
+		})
+	}
+
+	if err := tx.Create(&quotation).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	database.DB.Preload("Location").Preload("Member").Preload("CreatedBy").Preload("Items").First(&quotation, quotation.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": quotation})
+}
+
+// GetQuotations returns quotations, newest first
+func GetQuotations(c *gin.Context) {
+	var quotations []models.Quotation
+	query := database.DB.Preload("Location").Preload("Member").Preload("CreatedBy").Order("created_at DESC")
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Limit(100).Find(&quotations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quotations})
+}
+
+// GetQuotation returns a quotation with its pieces and quoted prices
+func GetQuotation(c *gin.Context) {
+	var quotation models.Quotation
+	if err := database.DB.Preload("Location").Preload("Member").Preload("CreatedBy").Preload("Transaction").
+		Preload("Items").Preload("Items.Stock").Preload("Items.Stock.Images").
+		First(&quotation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quotation not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quotation})
+}
+
+// CancelQuotation cancels an active quotation and releases its pieces
+func CancelQuotation(c *gin.Context) {
+	var quotation models.Quotation
+	if err := database.DB.First(&quotation, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quotation not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, quotation.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	now := time.Now()
+	result := database.DB.Model(&models.Quotation{}).
+		Where("id = ? AND status = ?", quotation.ID, models.QuotationStatusActive).
+		Updates(map[string]interface{}{
+			"status":    models.QuotationStatusCancelled,
+			"closed_at": now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quotation is no longer active"})
+		return
+	}
+
+	database.DB.First(&quotation, quotation.ID)
+	c.JSON(http.StatusOK, gin.H{"data": quotation})
+}
@@ -114,6 +114,7 @@ type CreateRawMaterialRequest struct {
 	MemberID         *uint   `json:"member_id"`
 	TransactionID    *uint   `json:"transaction_id"`
 	Notes            string  `json:"notes"`
+	ScaleReadingID   *uint   `json:"scale_reading_id"` // Reading timbangan untuk berat kotor; kosong = diketik
 }
 
 // CreateRawMaterial creates a new raw material
@@ -146,6 +147,15 @@ func CreateRawMaterial(c *gin.Context) {
 		condition = models.RawMaterialCondition(req.Condition)
 	}
 
+	tx := database.DB.Begin()
+
+	reading, weightSource, err := claimScaleReading(tx, req.ScaleReadingID, weightGross, req.LocationID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	now := time.Now()
 
 	rawMaterial := models.RawMaterial{
@@ -166,13 +176,30 @@ func CreateRawMaterial(c *gin.Context) {
 		ReceivedAt:       &now,
 		ReceivedByID:     &currentUser.ID,
 		Notes:            req.Notes,
+		WeightSource:     weightSource,
+		ScaleReadingID:   req.ScaleReadingID,
 	}
 
-	if err := database.DB.Create(&rawMaterial).Error; err != nil {
+	if err := tx.Create(&rawMaterial).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if err := useScaleReading(tx, reading, models.ScaleReadingTargetRawMaterial, rawMaterial.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := recordRawMaterialFineGold(tx, rawMaterial, models.FineGoldMovementRawMaterial, 1, "raw_material", rawMaterial.ID, rawMaterial.Code, &currentUser.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
 	// Reload with associations
 	database.DB.
 		Preload("GoldCategory").
@@ -229,6 +256,10 @@ func UpdateRawMaterial(c *gin.Context) {
 	}
 	if req.WeightGross > 0 {
 		updates["weight_gross"] = req.WeightGross
+		// Berat kotor yang diubah manual tidak lagi berasal dari timbangan
+		if req.WeightGross != rawMaterial.WeightGross {
+			updates["weight_source"] = models.WeightSourceTyped
+		}
 	}
 	if req.ShrinkagePercent >= 0 {
 		updates["shrinkage_percent"] = req.ShrinkagePercent
@@ -273,11 +304,40 @@ func UpdateRawMaterial(c *gin.Context) {
 	}
 	updates["total_buy_price"] = weight * price
 
-	if err := database.DB.Model(&rawMaterial).Updates(updates).Error; err != nil {
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+	before := rawMaterial
+
+	tx := database.DB.Begin()
+
+	if err := tx.Model(&rawMaterial).Updates(updates).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Koreksi berat, kadar, kategori atau lokasi dicatat sebagai penyesuaian emas murni
+	var after models.RawMaterial
+	tx.First(&after, rawMaterial.ID)
+	if fineGoldChanged(before, after) {
+		if before.Status == models.RawMaterialStatusAvailable {
+			if err := recordRawMaterialFineGold(tx, before, models.FineGoldMovementAdjustment, -1, "raw_material", before.ID, before.Code, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if after.Status == models.RawMaterialStatusAvailable {
+			if err := recordRawMaterialFineGold(tx, after, models.FineGoldMovementAdjustment, 1, "raw_material", after.ID, after.Code, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	tx.Commit()
+
 	// Reload with associations
 	database.DB.
 		Preload("GoldCategory").
@@ -289,6 +349,23 @@ func UpdateRawMaterial(c *gin.Context) {
 	c.JSON(http.StatusOK, rawMaterial)
 }
 
+// fineGoldChanged reports whether an edit moved fine gold between locations or changed its amount
+func fineGoldChanged(before, after models.RawMaterial) bool {
+	return before.Status != after.Status ||
+		before.LocationID != after.LocationID ||
+		before.WeightGrams != after.WeightGrams ||
+		before.Purity != after.Purity ||
+		!sameUintPtr(before.GoldCategoryID, after.GoldCategoryID)
+}
+
+// sameUintPtr compares two optional IDs
+func sameUintPtr(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // DeleteRawMaterial deletes a raw material
 func DeleteRawMaterial(c *gin.Context) {
 	id := c.Param("id")
@@ -309,11 +386,25 @@ func DeleteRawMaterial(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&rawMaterial).Error; err != nil {
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&rawMaterial).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := recordRawMaterialFineGold(tx, rawMaterial, models.FineGoldMovementRawMaterial, -1, "raw_material", rawMaterial.ID, rawMaterial.Code, &currentUserID); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	tx.Commit()
+
 	c.JSON(http.StatusOK, gin.H{"message": "Raw material deleted successfully"})
 }
 
@@ -0,0 +1,496 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== REPLENISHMENT RULES ====================
+
+// GetReplenishmentRules returns min/max rules
+func GetReplenishmentRules(c *gin.Context) {
+	var rules []models.ReplenishmentRule
+	query := database.DB.Preload("Location").Preload("SourceLocation").Preload("GoldCategory").Preload("TargetBox")
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Order("location_id, product_type, product_category").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+type ReplenishmentRuleRequest struct {
+	LocationID         uint                   `json:"location_id" binding:"required"`
+	SourceLocationID   *uint                  `json:"source_location_id"`
+	ProductType        models.ProductType     `json:"product_type" binding:"required"`
+	ProductCategory    models.ProductCategory `json:"product_category"`
+	GoldCategoryID     *uint                  `json:"gold_category_id"`
+	MinQty             int                    `json:"min_qty" binding:"min=0"`
+	MaxQty             int                    `json:"max_qty" binding:"required,min=1"`
+	LeadTimeDays       int                    `json:"lead_time_days"`
+	VelocityWindowDays int                    `json:"velocity_window_days"`
+	TargetBoxID        *uint                  `json:"target_box_id"`
+	IsActive           *bool                  `json:"is_active"`
+	Notes              string                 `json:"notes"`
+}
+
+// apply validates the request and copies it into a rule
+func (req ReplenishmentRuleRequest) apply(rule *models.ReplenishmentRule) error {
+	if req.MaxQty < req.MinQty {
+		return fmt.Errorf("max_qty cannot be less than min_qty")
+	}
+	if _, err := findProductType(database.DB, req.ProductType); err != nil {
+		return err
+	}
+	if req.SourceLocationID != nil && *req.SourceLocationID == req.LocationID {
+		return fmt.Errorf("Source location must differ from the replenished location")
+	}
+	if req.TargetBoxID != nil {
+		var box models.StorageBox
+		if err := database.DB.Where("id = ? AND location_id = ?", *req.TargetBoxID, req.LocationID).First(&box).Error; err != nil {
+			return fmt.Errorf("Target box not found in this location")
+		}
+		if storageBoxHasChildren(database.DB, box.ID) {
+			return fmt.Errorf("Stock can only be placed in a leaf storage box")
+		}
+	}
+
+	rule.LocationID = req.LocationID
+	rule.SourceLocationID = req.SourceLocationID
+	rule.ProductType = req.ProductType
+	rule.ProductCategory = req.ProductCategory
+	rule.GoldCategoryID = req.GoldCategoryID
+	rule.MinQty = req.MinQty
+	rule.MaxQty = req.MaxQty
+	rule.LeadTimeDays = req.LeadTimeDays
+	if rule.LeadTimeDays <= 0 {
+		rule.LeadTimeDays = 3
+	}
+	rule.VelocityWindowDays = req.VelocityWindowDays
+	if rule.VelocityWindowDays <= 0 {
+		rule.VelocityWindowDays = 30
+	}
+	rule.TargetBoxID = req.TargetBoxID
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	rule.Notes = req.Notes
+	return nil
+}
+
+// CreateReplenishmentRule creates a min/max rule
+func CreateReplenishmentRule(c *gin.Context) {
+	var req ReplenishmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.ReplenishmentRule{IsActive: true}
+	if err := req.apply(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Location").Preload("SourceLocation").Preload("GoldCategory").Preload("TargetBox").First(&rule, rule.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": rule})
+}
+
+// UpdateReplenishmentRule updates a min/max rule
+func UpdateReplenishmentRule(c *gin.Context) {
+	id := c.Param("id")
+	var rule models.ReplenishmentRule
+	if err := database.DB.First(&rule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Replenishment rule not found"})
+		return
+	}
+
+	var req ReplenishmentRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.apply(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Omit("Location", "SourceLocation", "GoldCategory", "TargetBox").Save(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Location").Preload("SourceLocation").Preload("GoldCategory").Preload("TargetBox").First(&rule, rule.ID)
+	c.JSON(http.StatusOK, gin.H{"data": rule})
+}
+
+// DeleteReplenishmentRule deletes a min/max rule
+func DeleteReplenishmentRule(c *gin.Context) {
+	id := c.Param("id")
+	if err := database.DB.Delete(&models.ReplenishmentRule{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Replenishment rule deleted successfully"})
+}
+
+// ==================== SUGGESTIONS ====================
+
+// GetReplenishmentSuggestions returns generated suggestions
+func GetReplenishmentSuggestions(c *gin.Context) {
+	var suggestions []models.ReplenishmentSuggestion
+	query := database.DB.Preload("Rule").Preload("Rule.GoldCategory").
+		Preload("Location").Preload("SourceLocation").Preload("DecidedBy")
+
+	status := c.DefaultQuery("status", string(models.ReplenishmentSuggestionPending))
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	// Yang paling cepat habis di atas; tanpa penjualan (-1) di paling bawah
+	if err := query.Order("CASE WHEN days_of_cover < 0 THEN 1 ELSE 0 END, days_of_cover ASC, generated_at DESC").Find(&suggestions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suggestions})
+}
+
+// GenerateReplenishmentSuggestions runs the velocity calculation now
+func GenerateReplenishmentSuggestions(c *gin.Context) {
+	suggestions, err := generateReplenishmentSuggestions(database.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suggestions, "count": len(suggestions)})
+}
+
+// replenishmentSegment limits a stocks query to the products covered by a rule
+func replenishmentSegment(rule models.ReplenishmentRule) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		products := database.DB.Model(&models.Product{}).Select("id").Where("type = ?", rule.ProductType)
+		if rule.ProductCategory != "" {
+			products = products.Where("category = ?", rule.ProductCategory)
+		}
+		if rule.GoldCategoryID != nil {
+			products = products.Where("gold_category_id = ?", *rule.GoldCategoryID)
+		}
+		return db.Where("stocks.product_id IN (?)", products)
+	}
+}
+
+// generateReplenishmentSuggestions computes sales velocity per active rule and proposes gudang→toko transfers
+// for every location whose projected stock after the lead time falls below its minimum.
+func generateReplenishmentSuggestions(db *gorm.DB) ([]models.ReplenishmentSuggestion, error) {
+	var rules []models.ReplenishmentRule
+	if err := db.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	var warehouses []models.Location
+	db.Where("type = ? AND is_active = ?", models.LocationTypeGudang, true).Find(&warehouses)
+
+	now := time.Now()
+	var created []models.ReplenishmentSuggestion
+
+	for _, rule := range rules {
+		segment := replenishmentSegment(rule)
+
+		// Stok toko + yang sedang dalam draft transfer ke toko
+		var onHand, incoming int64
+		db.Model(&models.Stock{}).Scopes(segment).
+			Where("stocks.location_id = ? AND stocks.status IN ?", rule.LocationID, []models.StockStatus{models.StockStatusAvailable, models.StockStatusReserved}).
+			Count(&onHand)
+		db.Model(&models.Stock{}).Scopes(segment).
+			Joins("JOIN stock_transfers ON stock_transfers.stock_id = stocks.id AND stock_transfers.deleted_at IS NULL").
+			Where("stock_transfers.to_location_id = ? AND stock_transfers.status = ?", rule.LocationID, "pending").
+			Count(&incoming)
+
+		// Kecepatan jual dari riwayat item transaksi
+		since := now.AddDate(0, 0, -rule.VelocityWindowDays)
+		var sold int64
+		db.Model(&models.TransactionItem{}).
+			Joins("JOIN transactions ON transactions.id = transaction_items.transaction_id").
+			Joins("JOIN stocks ON stocks.id = transaction_items.stock_id").
+			Scopes(segment).
+			Where("transactions.type = ? AND transactions.status = ? AND transactions.location_id = ? AND transactions.transaction_date >= ?",
+				models.TransactionTypeSale, "completed", rule.LocationID, since).
+			Count(&sold)
+
+		velocity := float64(sold) / float64(rule.VelocityWindowDays)
+		position := float64(onHand + incoming)
+		projected := position - velocity*float64(rule.LeadTimeDays)
+		if projected >= float64(rule.MinQty) {
+			continue
+		}
+		qty := int(math.Ceil(float64(rule.MaxQty) - projected))
+		if qty <= 0 {
+			continue
+		}
+
+		// Gudang sumber dengan barang terbanyak, ambil yang paling lama (FIFO)
+		sources := warehouses
+		if rule.SourceLocationID != nil {
+			sources = []models.Location{{ID: *rule.SourceLocationID}}
+		}
+		var sourceID uint
+		var pieces []models.Stock
+		for _, source := range sources {
+			if source.ID == rule.LocationID {
+				continue
+			}
+			var candidates []models.Stock
+			db.Model(&models.Stock{}).Scopes(segment).
+				Where("stocks.location_id = ? AND stocks.status = ?", source.ID, models.StockStatusAvailable).
+				Where("stocks.id NOT IN (?)", db.Model(&models.StockTransfer{}).Select("stock_id").Where("status = ?", "pending")).
+				Order("COALESCE(stocks.received_at, stocks.created_at) ASC").
+				Limit(qty).
+				Find(&candidates)
+			if sourceID == 0 || len(candidates) > len(pieces) {
+				sourceID = source.ID
+				pieces = candidates
+			}
+		}
+		if sourceID == 0 {
+			continue
+		}
+
+		stockIDs := make([]uint, len(pieces))
+		for i, p := range pieces {
+			stockIDs[i] = p.ID
+		}
+
+		daysOfCover := -1.0
+		if velocity > 0 {
+			daysOfCover = position / velocity
+		}
+
+		suggestion := models.ReplenishmentSuggestion{
+			RuleID:            rule.ID,
+			LocationID:        rule.LocationID,
+			SourceLocationID:  sourceID,
+			OnHand:            int(onHand + incoming),
+			SoldInWindow:      int(sold),
+			Velocity:          velocity,
+			DaysOfCover:       daysOfCover,
+			SuggestedQty:      qty,
+			AvailableAtSource: len(pieces),
+			StockIDs:          stockIDs,
+			Status:            models.ReplenishmentSuggestionPending,
+			GeneratedAt:       now,
+		}
+		if len(pieces) < qty {
+			suggestion.Notes = fmt.Sprintf("Gudang hanya punya %d dari %d yang dibutuhkan", len(pieces), qty)
+		}
+
+		tx := db.Begin()
+		if err := tx.Model(&models.ReplenishmentSuggestion{}).
+			Where("rule_id = ? AND status = ?", rule.ID, models.ReplenishmentSuggestionPending).
+			Update("status", models.ReplenishmentSuggestionSuperseded).Error; err != nil {
+			tx.Rollback()
+			return created, err
+		}
+		if err := tx.Create(&suggestion).Error; err != nil {
+			tx.Rollback()
+			return created, err
+		}
+		tx.Commit()
+		created = append(created, suggestion)
+	}
+
+	return created, nil
+}
+
+type AcceptReplenishmentRequest struct {
+	ToBoxID  *uint  `json:"to_box_id"` // Kosong = box tujuan dari aturan
+	StockIDs []uint `json:"stock_ids"` // Kosong = barang yang diusulkan
+	Notes    string `json:"notes"`
+}
+
+// AcceptReplenishmentSuggestion turns a suggestion into draft (pending) stock transfers
+func AcceptReplenishmentSuggestion(c *gin.Context) {
+	id := c.Param("id")
+	var req AcceptReplenishmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	var suggestion models.ReplenishmentSuggestion
+	if err := tx.Preload("Rule").First(&suggestion, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Replenishment suggestion not found"})
+		return
+	}
+	if suggestion.Status != models.ReplenishmentSuggestionPending {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending suggestions can be accepted"})
+		return
+	}
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, suggestion.LocationID) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	toBoxID := suggestion.Rule.TargetBoxID
+	if req.ToBoxID != nil {
+		toBoxID = req.ToBoxID
+	}
+	if toBoxID == nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to_box_id is required, the rule has no target box"})
+		return
+	}
+	var toBox models.StorageBox
+	if err := tx.Where("id = ? AND location_id = ?", *toBoxID, suggestion.LocationID).First(&toBox).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination box not found in specified location"})
+		return
+	}
+	if storageBoxHasChildren(tx, toBox.ID) {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+		return
+	}
+
+	stockIDs := suggestion.StockIDs
+	if len(req.StockIDs) > 0 {
+		stockIDs = req.StockIDs
+	}
+	if len(stockIDs) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No stock available at the source location for this suggestion"})
+		return
+	}
+
+	baseNumber := fmt.Sprintf("TRF%d", time.Now().UnixNano()/1000000)
+	var transfers []models.StockTransfer
+	for i, stockID := range stockIDs {
+		var stock models.Stock
+		if err := tx.First(&stock, stockID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock ID %d not found", stockID)})
+			return
+		}
+		if stock.Status != models.StockStatusAvailable || stock.LocationID != suggestion.SourceLocationID {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is no longer available at the source location", stock.SerialNumber)})
+			return
+		}
+		if transferNumber := pendingTransferOf(tx, stock.ID); transferNumber != "" {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s already has draft transfer %s", stock.SerialNumber, transferNumber)})
+			return
+		}
+		if held, ok := stockQuotation(tx, stock.ID, 0); ok {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is reserved by quotation %s", stock.SerialNumber, held.QuotationNumber)})
+			return
+		}
+
+		transfer := models.StockTransfer{
+			TransferNumber:  fmt.Sprintf("%s-%02d", baseNumber, i+1),
+			StockID:         stock.ID,
+			FromLocationID:  stock.LocationID,
+			FromBoxID:       stock.StorageBoxID,
+			ToLocationID:    suggestion.LocationID,
+			ToBoxID:         toBox.ID,
+			TransferredByID: currentUserID,
+			TransferredAt:   time.Now(),
+			Notes:           req.Notes,
+			Status:          "pending",
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	now := time.Now()
+	suggestion.Status = models.ReplenishmentSuggestionAccepted
+	suggestion.DecidedByID = &currentUserID
+	suggestion.DecidedAt = &now
+	suggestion.TransferNumber = baseNumber
+	if err := tx.Omit("Rule").Save(&suggestion).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": suggestion, "transfers": transfers})
+}
+
+// DismissReplenishmentSuggestion marks a suggestion as ignored
+func DismissReplenishmentSuggestion(c *gin.Context) {
+	id := c.Param("id")
+	var suggestion models.ReplenishmentSuggestion
+	if err := database.DB.First(&suggestion, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Replenishment suggestion not found"})
+		return
+	}
+	if suggestion.Status != models.ReplenishmentSuggestionPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending suggestions can be dismissed"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+	now := time.Now()
+	suggestion.Status = models.ReplenishmentSuggestionDismissed
+	suggestion.DecidedByID = &currentUserID
+	suggestion.DecidedAt = &now
+	if err := database.DB.Save(&suggestion).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suggestion})
+}
+
+// StartReplenishmentJob regenerates suggestions periodically in the background
+func StartReplenishmentJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			suggestions, err := generateReplenishmentSuggestions(database.DB)
+			if err != nil {
+				log.Println("Replenishment job failed:", err)
+				continue
+			}
+			log.Printf("Replenishment job generated %d suggestions", len(suggestions))
+		}
+	}()
+}
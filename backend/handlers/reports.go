@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // ==================== TRANSACTION REPORTS ====================
@@ -293,7 +295,8 @@ func GetStockLocationReport(c *gin.Context) {
 	var results []StockLocationReport
 
 	// Get stock counts and values by location
-	// Harga dihitung dari gold_category.buy_price * product.weight dan gold_category.sell_price * product.weight
+	// Harga dihitung dari gold_category.buy_price * berat dan gold_category.sell_price * berat (+ batu dan ongkos pembuatan)
+	// Berat = berat aktual per buah (stocks.net_weight), fallback ke product.weight
 	query := `
 		SELECT 
 			l.id as location_id,
@@ -303,9 +306,9 @@ func GetStockLocationReport(c *gin.Context) {
 			SUM(CASE WHEN s.status = 'available' THEN 1 ELSE 0 END) as available_stock,
 			SUM(CASE WHEN s.status = 'sold' THEN 1 ELSE 0 END) as sold_stock,
 			SUM(CASE WHEN s.status = 'reserved' THEN 1 ELSE 0 END) as reserved_stock,
-			COALESCE(SUM(p.weight), 0) as total_weight,
-			COALESCE(SUM(CASE WHEN s.status = 'available' THEN gc.buy_price * p.weight ELSE 0 END), 0) as total_buy_value,
-			COALESCE(SUM(CASE WHEN s.status = 'available' THEN gc.sell_price * p.weight ELSE 0 END), 0) as total_sell_value
+			COALESCE(SUM(` + stockWeightExpr("s", "p") + `), 0) as total_weight,
+			COALESCE(SUM(CASE WHEN s.status = 'available' THEN ` + stockBuyValueExpr("s", "p", "gc") + ` ELSE 0 END), 0) as total_buy_value,
+			COALESCE(SUM(CASE WHEN s.status = 'available' THEN ` + stockSellValueExpr("s", "p", "gc") + ` ELSE 0 END), 0) as total_sell_value
 		FROM locations l
 		LEFT JOIN stocks s ON s.location_id = l.id AND s.deleted_at IS NULL
 		LEFT JOIN products p ON p.id = s.product_id
@@ -344,7 +347,8 @@ func GetStockCategoryReport(c *gin.Context) {
 
 	var results []StockCategoryReport
 
-	// Harga dihitung dari gold_category.buy_price * product.weight dan gold_category.sell_price * product.weight
+	// Harga dihitung dari gold_category.buy_price * berat dan gold_category.sell_price * berat (+ batu dan ongkos pembuatan)
+	// Berat = berat aktual per buah (stocks.net_weight), fallback ke product.weight
 	query := `
 		SELECT 
 			gc.id as category_id,
@@ -353,11 +357,11 @@ func GetStockCategoryReport(c *gin.Context) {
 			COUNT(s.id) as total_stock,
 			SUM(CASE WHEN s.status = 'available' THEN 1 ELSE 0 END) as available_stock,
 			SUM(CASE WHEN s.status = 'sold' THEN 1 ELSE 0 END) as sold_stock,
-			COALESCE(SUM(CASE WHEN s.status = 'available' THEN p.weight ELSE 0 END), 0) as total_weight,
+			COALESCE(SUM(CASE WHEN s.status = 'available' THEN ` + stockWeightExpr("s", "p") + ` ELSE 0 END), 0) as total_weight,
 			gc.buy_price as avg_buy_price,
 			gc.sell_price as avg_sell_price,
-			COALESCE(SUM(CASE WHEN s.status = 'available' THEN gc.buy_price * p.weight ELSE 0 END), 0) as total_buy_value,
-			COALESCE(SUM(CASE WHEN s.status = 'available' THEN gc.sell_price * p.weight ELSE 0 END), 0) as total_sell_value
+			COALESCE(SUM(CASE WHEN s.status = 'available' THEN ` + stockBuyValueExpr("s", "p", "gc") + ` ELSE 0 END), 0) as total_buy_value,
+			COALESCE(SUM(CASE WHEN s.status = 'available' THEN ` + stockSellValueExpr("s", "p", "gc") + ` ELSE 0 END), 0) as total_sell_value
 		FROM gold_categories gc
 		LEFT JOIN products p ON p.gold_category_id = gc.id AND p.deleted_at IS NULL
 		LEFT JOIN stocks s ON s.product_id = p.id AND s.deleted_at IS NULL
@@ -378,6 +382,17 @@ func GetStockCategoryReport(c *gin.Context) {
 		return
 	}
 
+	// Harga per gram yang berlaku di lokasi yang difilter
+	if id, err := strconv.ParseUint(locationID, 10, 64); err == nil {
+		locationPrices := loadLocationPrices(database.DB, uint(id))
+		for i := range results {
+			effective := locationPrices.category(uint(id), models.GoldCategory{
+				ID: results[i].CategoryID, BuyPrice: results[i].AvgBuyPrice, SellPrice: results[i].AvgSellPrice,
+			})
+			results[i].AvgBuyPrice, results[i].AvgSellPrice = effective.BuyPrice, effective.SellPrice
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": results})
 }
 
@@ -469,6 +484,7 @@ type RawMaterialReport struct {
 	MemberName       string     `json:"member_name"`
 	ReceivedAt       *time.Time `json:"received_at"`
 	ReceivedByName   string     `json:"received_by_name"`
+	WeightSource     string     `json:"weight_source"` // typed / weighed
 }
 
 // GetRawMaterialReport returns raw material inventory report
@@ -502,6 +518,7 @@ func GetRawMaterialReport(c *gin.Context) {
 	var reports []RawMaterialReport
 	var totalWeight float64 = 0
 	var totalValue float64 = 0
+	var typedCount int = 0
 
 	for _, m := range materials {
 		categoryName := ""
@@ -538,8 +555,13 @@ func GetRawMaterialReport(c *gin.Context) {
 			MemberName:       memberName,
 			ReceivedAt:       m.ReceivedAt,
 			ReceivedByName:   receivedByName,
+			WeightSource:     string(m.WeightSource),
 		})
 
+		if m.WeightSource != models.WeightSourceWeighed {
+			typedCount++
+		}
+
 		if m.Status == models.RawMaterialStatusAvailable {
 			totalWeight += m.WeightGrams
 			totalValue += m.TotalBuyPrice
@@ -552,10 +574,149 @@ func GetRawMaterialReport(c *gin.Context) {
 			"total_items":            len(reports),
 			"total_available_weight": totalWeight,
 			"total_available_value":  totalValue,
+			"typed_weight_count":     typedCount,
 		},
 	})
 }
 
+// WeightSourceReport compares typed vs weighed entries for one kind of record
+type WeightSourceReport struct {
+	Source         string  `json:"source"` // stock, purchase_item, raw_material
+	TypedCount     int64   `json:"typed_count"`
+	TypedWeight    float64 `json:"typed_weight"`
+	WeighedCount   int64   `json:"weighed_count"`
+	WeighedWeight  float64 `json:"weighed_weight"`
+	WeighedPercent float64 `json:"weighed_percent"`
+}
+
+// WeightSourceUserReport shows per user how many weights were typed instead of weighed
+type WeightSourceUserReport struct {
+	UserID       uint    `json:"user_id"`
+	UserName     string  `json:"user_name"`
+	Source       string  `json:"source"`
+	TypedCount   int64   `json:"typed_count"`
+	TypedWeight  float64 `json:"typed_weight"`
+	WeighedCount int64   `json:"weighed_count"`
+}
+
+// GetWeightSourceReport returns an audit of weights typed by hand vs read from a scale
+func GetWeightSourceReport(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	locationID := c.Query("location_id")
+
+	type sourceRow struct {
+		UserID       uint
+		UserName     string
+		WeightSource string
+		Count        int64
+		Weight       float64
+	}
+
+	// Berat yang dijumlahkan adalah berat yang dibaca timbangan (berat kotor)
+	type sourceQuery struct {
+		name string
+		base *gorm.DB
+	}
+
+	stockQuery := database.DB.Table("stocks s").
+		Select("s.weight_verified_by_id as user_id, COALESCE(u.full_name, '') as user_name, s.weight_source, COUNT(*) as count, COALESCE(SUM(s.gross_weight), 0) as weight").
+		Joins("LEFT JOIN users u ON u.id = s.weight_verified_by_id").
+		Where("s.deleted_at IS NULL AND s.weight_verified_at IS NOT NULL").
+		Group("s.weight_verified_by_id, u.full_name, s.weight_source")
+	if startDate != "" {
+		stockQuery = stockQuery.Where("s.weight_verified_at >= ?", startDate)
+	}
+	if endDate != "" {
+		stockQuery = stockQuery.Where("s.weight_verified_at <= ?", endDate+" 23:59:59")
+	}
+	if locationID != "" {
+		stockQuery = stockQuery.Where("s.location_id = ?", locationID)
+	}
+
+	purchaseQuery := database.DB.Table("transaction_items ti").
+		Select("t.cashier_id as user_id, u.full_name as user_name, ti.weight_source, COUNT(*) as count, COALESCE(SUM(COALESCE(NULLIF(ti.weight_gross, 0), ti.weight)), 0) as weight").
+		Joins("JOIN transactions t ON t.id = ti.transaction_id").
+		Joins("JOIN users u ON u.id = t.cashier_id").
+		Where("t.type = ? AND t.status != ? AND t.deleted_at IS NULL AND ti.deleted_at IS NULL", models.TransactionTypePurchase, "cancelled").
+		Group("t.cashier_id, u.full_name, ti.weight_source")
+	if startDate != "" {
+		purchaseQuery = purchaseQuery.Where("t.transaction_date >= ?", startDate)
+	}
+	if endDate != "" {
+		purchaseQuery = purchaseQuery.Where("t.transaction_date <= ?", endDate+" 23:59:59")
+	}
+	if locationID != "" {
+		purchaseQuery = purchaseQuery.Where("t.location_id = ?", locationID)
+	}
+
+	rawMaterialQuery := database.DB.Table("raw_materials rm").
+		Select("rm.received_by_id as user_id, COALESCE(u.full_name, '') as user_name, rm.weight_source, COUNT(*) as count, COALESCE(SUM(rm.weight_gross), 0) as weight").
+		Joins("LEFT JOIN users u ON u.id = rm.received_by_id").
+		Where("rm.deleted_at IS NULL").
+		Group("rm.received_by_id, u.full_name, rm.weight_source")
+	if startDate != "" {
+		rawMaterialQuery = rawMaterialQuery.Where("rm.received_at >= ?", startDate)
+	}
+	if endDate != "" {
+		rawMaterialQuery = rawMaterialQuery.Where("rm.received_at <= ?", endDate+" 23:59:59")
+	}
+	if locationID != "" {
+		rawMaterialQuery = rawMaterialQuery.Where("rm.location_id = ?", locationID)
+	}
+
+	sources := []sourceQuery{
+		{name: "stock", base: stockQuery},
+		{name: "purchase_item", base: purchaseQuery},
+		{name: "raw_material", base: rawMaterialQuery},
+	}
+
+	var summary []WeightSourceReport
+	var byUser []WeightSourceUserReport
+
+	for _, source := range sources {
+		var rows []sourceRow
+		if err := source.base.Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		report := WeightSourceReport{Source: source.name}
+		userMap := make(map[uint]*WeightSourceUserReport)
+		var userOrder []uint
+
+		for _, r := range rows {
+			if _, exists := userMap[r.UserID]; !exists {
+				userMap[r.UserID] = &WeightSourceUserReport{UserID: r.UserID, UserName: r.UserName, Source: source.name}
+				userOrder = append(userOrder, r.UserID)
+			}
+			if r.WeightSource == string(models.WeightSourceWeighed) {
+				report.WeighedCount += r.Count
+				report.WeighedWeight += r.Weight
+				userMap[r.UserID].WeighedCount += r.Count
+			} else {
+				report.TypedCount += r.Count
+				report.TypedWeight += r.Weight
+				userMap[r.UserID].TypedCount += r.Count
+				userMap[r.UserID].TypedWeight += r.Weight
+			}
+		}
+
+		if total := report.TypedCount + report.WeighedCount; total > 0 {
+			report.WeighedPercent = float64(report.WeighedCount) / float64(total) * 100
+		}
+		summary = append(summary, report)
+		for _, userID := range userOrder {
+			byUser = append(byUser, *userMap[userID])
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    summary,
+		"by_user": byUser,
+	})
+}
+
 // SoldStockReport represents sold stock report
 type SoldStockReport struct {
 	ID              uint       `json:"id"`
@@ -563,7 +724,7 @@ type SoldStockReport struct {
 	ProductName     string     `json:"product_name"`
 	CategoryName    string     `json:"category_name"`
 	Weight          float64    `json:"weight"`
-	BuyPrice        float64    `json:"buy_price"`  // Dari gold_category saat stok masuk (dihitung)
+	BuyPrice        float64    `json:"buy_price"`  // Harga modal stok, atau estimasi dari gold_category jika tidak tercatat
 	SellPrice       float64    `json:"sell_price"` // Dari transaction_item (snapshot saat dijual)
 	Profit          float64    `json:"profit"`
 	LocationName    string     `json:"location_name"`
@@ -582,7 +743,7 @@ func GetSoldStockReport(c *gin.Context) {
 	var stocks []models.Stock
 	query := database.DB.Model(&models.Stock{}).
 		Preload("Product").
-		Preload("Product.GoldCategory").
+		Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
 		Preload("Location").
 		Where("status = ?", models.StockStatusSold)
 
@@ -608,22 +769,28 @@ func GetSoldStockReport(c *gin.Context) {
 	var reports []SoldStockReport
 	var totalProfit float64 = 0
 	var totalSales float64 = 0
+	locationPrices := loadLocationPrices(database.DB)
 
 	for _, s := range stocks {
+		locationPrices.applyToStock(&s)
 		categoryName := ""
 		if s.Product.GoldCategory.ID != 0 {
 			categoryName = s.Product.GoldCategory.Name
 		}
 
-		// Hitung harga dari gold_category (harga beli saat stok masuk tidak disimpan, jadi gunakan harga saat ini sebagai estimasi)
-		buyPrice := s.Product.GoldCategory.BuyPrice * s.Product.Weight
+		// Gunakan harga modal dari penerimaan barang jika ada, selain itu estimasi dari harga beli gold_category saat ini
+		weight := s.EffectiveWeight()
+		buyPrice := s.CostPrice
+		if buyPrice <= 0 {
+			buyPrice = s.BuyPrice()
+		}
 
 		// Get transaction info and actual sell price from transaction_item
 		var tx models.Transaction
 		var txItem models.TransactionItem
 		customerName := ""
 		txCode := ""
-		sellPrice := s.Product.GoldCategory.SellPrice * s.Product.Weight // Default jika tidak ada transaction_item
+		sellPrice := s.SellPrice().Total // Default jika tidak ada transaction_item
 
 		if s.TransactionID != nil {
 			database.DB.First(&tx, *s.TransactionID)
@@ -648,7 +815,7 @@ func GetSoldStockReport(c *gin.Context) {
 			SerialNumber:    s.SerialNumber,
 			ProductName:     s.Product.Name,
 			CategoryName:    categoryName,
-			Weight:          s.Product.Weight,
+			Weight:          weight,
 			BuyPrice:        buyPrice,
 			SellPrice:       sellPrice,
 			Profit:          profit,
@@ -1098,6 +1265,8 @@ type PriceHistoryReport struct {
 	UpdateDate    time.Time            `json:"update_date"`
 	UpdatedByName string               `json:"updated_by_name"`
 	Notes         string               `json:"notes"`
+	Source        string               `json:"source"`
+	LocationName  string               `json:"location_name,omitempty"` // Kosong = harga global
 	Details       []PriceHistoryDetail `json:"details"`
 }
 
@@ -1122,9 +1291,17 @@ func GetPriceHistoryReport(c *gin.Context) {
 	var logs []models.PriceUpdateLog
 	query := database.DB.Model(&models.PriceUpdateLog{}).
 		Preload("UpdatedBy").
+		Preload("Location").
 		Preload("PriceDetails").
 		Preload("PriceDetails.GoldCategory")
 
+	// Filter by scope: location_id=global untuk harga global, atau ID lokasi
+	if locationID := c.Query("location_id"); locationID == "global" {
+		query = query.Where("location_id IS NULL")
+	} else if locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
 	if startDate != "" {
 		query = query.Where("update_date >= ?", startDate)
 	}
@@ -1175,11 +1352,18 @@ func GetPriceHistoryReport(c *gin.Context) {
 			continue
 		}
 
+		locationName := ""
+		if log.Location != nil {
+			locationName = log.Location.Name
+		}
+
 		reports = append(reports, PriceHistoryReport{
 			ID:            log.ID,
 			UpdateDate:    log.UpdateDate,
 			UpdatedByName: updatedByName,
 			Notes:         log.Notes,
+			Source:        string(log.Source),
+			LocationName:  locationName,
 			Details:       details,
 		})
 	}
@@ -1218,6 +1402,7 @@ func GetDailyPriceReport(c *gin.Context) {
 		JOIN gold_categories gc ON gc.id = pd.gold_category_id
 		WHERE pul.deleted_at IS NULL
 			AND pd.deleted_at IS NULL
+			AND pul.location_id IS NULL
 			AND pul.update_date >= DATE_SUB(NOW(), INTERVAL ` + days + ` DAY)
 		ORDER BY pul.update_date DESC, gc.code
 	`
@@ -1241,17 +1426,43 @@ type CurrentPriceReport struct {
 	LastUpdated  time.Time `json:"last_updated"`
 }
 
-// GetCurrentPriceReport returns current gold prices for all categories
+// GetCurrentPriceReport returns current gold prices for all categories.
+// With ?at=<RFC3339 atau YYYY-MM-DD HH:MM> it returns the prices in effect at that time from the price history.
+// With ?location_id it applies the price override of that location (override yang berlaku saat ini).
 func GetCurrentPriceReport(c *gin.Context) {
+	var at *time.Time
+	if value := c.Query("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			parsed, err = time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid at, use RFC3339 or YYYY-MM-DD HH:MM"})
+			return
+		}
+		at = &parsed
+	}
+
 	var categories []models.GoldCategory
 	if err := database.DB.Where("is_active = ?", true).Order("code").Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	var locationID uint
+	if value := c.Query("location_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location_id"})
+			return
+		}
+		locationID = uint(id)
+	}
+	locationPrices := loadLocationPrices(database.DB, locationID)
+
 	var reports []CurrentPriceReport
 	for _, cat := range categories {
-		reports = append(reports, CurrentPriceReport{
+		report := CurrentPriceReport{
 			CategoryID:   cat.ID,
 			CategoryCode: cat.Code,
 			CategoryName: cat.Name,
@@ -1259,10 +1470,25 @@ func GetCurrentPriceReport(c *gin.Context) {
 			BuyPrice:     cat.BuyPrice,
 			SellPrice:    cat.SellPrice,
 			LastUpdated:  cat.UpdatedAt,
-		})
+		}
+		if at != nil {
+			var updatedAt *time.Time
+			report.BuyPrice, report.SellPrice, updatedAt = priceAt(database.DB, cat, *at)
+			report.LastUpdated = time.Time{}
+			if updatedAt != nil {
+				report.LastUpdated = *updatedAt
+			}
+		}
+		if locationID > 0 {
+			historic := cat
+			historic.BuyPrice, historic.SellPrice = report.BuyPrice, report.SellPrice
+			effective := locationPrices.category(locationID, historic)
+			report.BuyPrice, report.SellPrice = effective.BuyPrice, effective.SellPrice
+		}
+		reports = append(reports, report)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": reports})
+	c.JSON(http.StatusOK, gin.H{"data": reports, "at": at})
 }
 
 // ==================== DASHBOARD SUMMARY ====================
@@ -1365,11 +1591,14 @@ func GetDashboardSummary(c *gin.Context) {
 	}
 	availableStockQuery.Count(&summary.AvailableStock)
 
-	stockValueQuery := database.DB.Model(&models.Stock{}).Where("status = ?", "available")
+	stockValueQuery := database.DB.Model(&models.Stock{}).
+		Joins("JOIN products ON products.id = stocks.product_id").
+		Joins("JOIN gold_categories ON gold_categories.id = products.gold_category_id").
+		Where("stocks.status = ?", "available")
 	if hasLocationFilter {
-		stockValueQuery = stockValueQuery.Where("location_id IN ?", userLocationIDs)
+		stockValueQuery = stockValueQuery.Where("stocks.location_id IN ?", userLocationIDs)
 	}
-	stockValueQuery.Select("COALESCE(SUM(sell_price), 0)").Scan(&summary.StockValue)
+	stockValueQuery.Select("COALESCE(SUM(" + stockSellValueExpr("stocks", "products", "gold_categories") + "), 0)").Scan(&summary.StockValue)
 
 	// Member stats - these are global, not location-specific
 	database.DB.Model(&models.Member{}).Count(&summary.TotalMembers)
@@ -1519,8 +1748,10 @@ func GetUserDashboard(c *gin.Context) {
 		Count(&data.AvailableStock)
 
 	database.DB.Model(&models.Stock{}).
-		Where("status = ? AND location_id IN ?", "available", userLocationIDs).
-		Select("COALESCE(SUM(sell_price), 0)").Scan(&data.StockValue)
+		Joins("JOIN products ON products.id = stocks.product_id").
+		Joins("JOIN gold_categories ON gold_categories.id = products.gold_category_id").
+		Where("stocks.status = ? AND stocks.location_id IN ?", "available", userLocationIDs).
+		Select("COALESCE(SUM(" + stockSellValueExpr("stocks", "products", "gold_categories") + "), 0)").Scan(&data.StockValue)
 
 	// Member stats - global (member tidak terikat lokasi)
 	database.DB.Model(&models.Member{}).Count(&data.TotalMembers)
@@ -1571,7 +1802,7 @@ func GetUserDashboard(c *gin.Context) {
 	}
 	var stocksByCategory []CategoryStock
 	database.DB.Model(&models.Stock{}).
-		Select("gold_categories.name as category_name, COUNT(*) as count, COALESCE(SUM(stocks.weight), 0) as total_weight").
+		Select("gold_categories.name as category_name, COUNT(*) as count, COALESCE(SUM("+stockWeightExpr("stocks", "products")+"), 0) as total_weight").
 		Joins("JOIN products ON products.id = stocks.product_id").
 		Joins("JOIN gold_categories ON gold_categories.id = products.gold_category_id").
 		Where("stocks.status = ? AND stocks.deleted_at IS NULL AND stocks.location_id IN ?", "available", userLocationIDs).
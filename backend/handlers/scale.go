@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+	"starter/backend/scale"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// scaleReadingMaxAge is how long a pushed reading stays usable
+	scaleReadingMaxAge = 15 * time.Minute
+	// scaleWeightTolerance is the allowed difference (gram) between the submitted weight and the reading
+	scaleWeightTolerance = 0.01
+)
+
+// scaleDriver is the scale attached to the server itself, if any
+var scaleDriver scale.Driver
+
+// SetScaleDriver sets the server-attached scale driver
+func SetScaleDriver(driver scale.Driver) {
+	scaleDriver = driver
+}
+
+// GetScaleReadings returns recent scale readings
+func GetScaleReadings(c *gin.Context) {
+	var readings []models.ScaleReading
+	query := database.DB.Preload("RecordedBy").Order("read_at DESC")
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if deviceID := c.Query("device_id"); deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if c.Query("unused") == "true" {
+		query = query.Where("used_at IS NULL AND read_at >= ?", time.Now().Add(-scaleReadingMaxAge))
+	}
+
+	limit := 50
+	if l := parseInt(c.Query("limit")); l > 0 && l <= 500 {
+		limit = l
+	}
+
+	if err := query.Limit(limit).Find(&readings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": readings})
+}
+
+type CreateScaleReadingRequest struct {
+	LocationID uint    `json:"location_id" binding:"required"`
+	DeviceID   string  `json:"device_id"`
+	RawLine    string  `json:"raw_line"` // Output mentah timbangan, diparse di server
+	Weight     float64 `json:"weight"`   // Dipakai jika raw_line kosong, hanya dengan driver simulasi
+	Unit       string  `json:"unit"`
+	Stable     bool    `json:"stable"`
+}
+
+// CreateScaleReading records a stable reading pushed by a workstation
+func CreateScaleReading(c *gin.Context) {
+	var req CreateScaleReadingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, req.LocationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	var reading scale.Reading
+	if req.RawLine != "" {
+		parsed, err := scale.ParseLine(req.RawLine)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reading = parsed
+	} else {
+		// Berat tanpa output mentah tidak bisa dibuktikan berasal dari timbangan; hanya untuk simulasi
+		if _, simulated := scaleDriver.(*scale.SimulatedDriver); !simulated {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "raw_line is required, send the scale output as read"})
+			return
+		}
+		line := fmt.Sprintf("%.4f %s", req.Weight, req.Unit)
+		parsed, err := scale.ParseLine(line)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		parsed.Stable = req.Stable
+		parsed.Raw = ""
+		reading = parsed
+	}
+
+	if !reading.Stable {
+		c.JSON(http.StatusBadRequest, gin.H{"error": scale.ErrUnstable.Error()})
+		return
+	}
+	if reading.Weight <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scale reading must be greater than zero"})
+		return
+	}
+
+	record, err := saveScaleReading(req.LocationID, req.DeviceID, currentUserID, reading)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+// ReadServerScale takes a stable reading from the scale attached to the server
+func ReadServerScale(c *gin.Context) {
+	if scaleDriver == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No scale attached to the server"})
+		return
+	}
+
+	locationID := uint(parseInt(c.Query("location_id")))
+	if locationID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location_id is required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, locationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	reading, err := scaleDriver.Read(ctx)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to read scale: " + err.Error()})
+		return
+	}
+
+	record, err := saveScaleReading(locationID, "server", currentUserID, reading)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": record})
+}
+
+type SimulateScaleRequest struct {
+	Weight  float64 `json:"weight"`
+	RawLine string  `json:"raw_line"`
+}
+
+// SimulateScale places a weight on the simulated server scale (development only)
+func SimulateScale(c *gin.Context) {
+	simulated, ok := scaleDriver.(*scale.SimulatedDriver)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Server scale is not the simulated driver"})
+		return
+	}
+
+	var req SimulateScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RawLine != "" {
+		simulated.PutLine(req.RawLine)
+	} else {
+		simulated.Put(req.Weight)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Weight placed on simulated scale"})
+}
+
+func saveScaleReading(locationID uint, deviceID string, userID uint, reading scale.Reading) (models.ScaleReading, error) {
+	record := models.ScaleReading{
+		LocationID:   locationID,
+		DeviceID:     strings.TrimSpace(deviceID),
+		Weight:       math.Round(reading.Weight*10000) / 10000,
+		Unit:         reading.Unit,
+		RawLine:      reading.Raw,
+		ReadAt:       reading.At,
+		RecordedByID: userID,
+	}
+	if record.ReadAt.IsZero() {
+		record.ReadAt = time.Now()
+	}
+	err := database.DB.Create(&record).Error
+	return record, err
+}
+
+// claimScaleReading checks that a reading can back the given weight at a location.
+// A nil readingID means the weight was typed by hand.
+func claimScaleReading(tx *gorm.DB, readingID *uint, weight float64, locationID uint) (*models.ScaleReading, models.WeightSource, error) {
+	if readingID == nil || *readingID == 0 {
+		return nil, models.WeightSourceTyped, nil
+	}
+
+	var reading models.ScaleReading
+	if err := tx.First(&reading, *readingID).Error; err != nil {
+		return nil, "", fmt.Errorf("Scale reading %d not found", *readingID)
+	}
+	if reading.UsedAt != nil {
+		return nil, "", fmt.Errorf("Scale reading %d has already been used", reading.ID)
+	}
+	if reading.LocationID != locationID {
+		return nil, "", fmt.Errorf("Scale reading %d was taken at another location", reading.ID)
+	}
+	if time.Since(reading.ReadAt) > scaleReadingMaxAge {
+		return nil, "", fmt.Errorf("Scale reading %d has expired, please weigh again", reading.ID)
+	}
+	if math.Abs(reading.Weight-weight) > scaleWeightTolerance {
+		return nil, "", fmt.Errorf("Weight %.3f g does not match scale reading %.3f g", weight, reading.Weight)
+	}
+
+	return &reading, models.WeightSourceWeighed, nil
+}
+
+// useScaleReading marks a claimed reading as used by a record so it cannot be reused
+func useScaleReading(tx *gorm.DB, reading *models.ScaleReading, target models.ScaleReadingTarget, targetID uint) error {
+	if reading == nil {
+		return nil
+	}
+	now := time.Now()
+	result := tx.Model(&models.ScaleReading{}).
+		Where("id = ? AND used_at IS NULL", reading.ID).
+		Updates(map[string]interface{}{
+			"used_at":     now,
+			"target_type": target,
+			"target_id":   targetID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("Scale reading has already been used")
+	}
+	return nil
+}
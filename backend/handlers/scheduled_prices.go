@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== SCHEDULED PRICE UPDATES ====================
+
+// CreateScheduledPriceRequest sets prices that take effect later. Either prices per category
+// or the 24K base prices (diturunkan saat dijadwalkan, dapat dipreview lebih dulu) are given.
+type CreateScheduledPriceRequest struct {
+	EffectiveAt   time.Time         `json:"effective_at" binding:"required"`
+	Prices        []PriceUpdateItem `json:"prices"`
+	BaseBuyPrice  float64           `json:"base_buy_price"`
+	BaseSellPrice float64           `json:"base_sell_price"`
+	Notes         string            `json:"notes"`
+}
+
+// GetScheduledPriceUpdates returns scheduled price updates, upcoming first
+func GetScheduledPriceUpdates(c *gin.Context) {
+	var updates []models.ScheduledPriceUpdate
+	query := database.DB.Preload("CreatedBy").Order("effective_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Limit(100).Find(&updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": updates})
+}
+
+// GetScheduledPriceUpdate returns a single scheduled price update
+func GetScheduledPriceUpdate(c *gin.Context) {
+	var update models.ScheduledPriceUpdate
+	if err := database.DB.Preload("CreatedBy").Preload("PriceUpdateLog").Preload("PriceUpdateLog.PriceDetails.GoldCategory").
+		First(&update, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled price update not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": update})
+}
+
+// CreateScheduledPriceUpdate schedules a price update for a future time
+func CreateScheduledPriceUpdate(c *gin.Context) {
+	var req CreateScheduledPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Effective time must be in the future, use the bulk update for immediate prices"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	update := models.ScheduledPriceUpdate{
+		EffectiveAt: req.EffectiveAt,
+		Status:      models.ScheduledPriceStatusScheduled,
+		Source:      models.PriceUpdateSourceManual,
+		Notes:       req.Notes,
+		CreatedByID: userID.(uint),
+	}
+
+	prices := req.Prices
+	switch {
+	case req.BaseBuyPrice > 0 || req.BaseSellPrice > 0:
+		if len(req.Prices) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Give either prices or base prices, not both"})
+			return
+		}
+		_, derived, err := derivePriceUpdate(database.DB, req.BaseBuyPrice, req.BaseSellPrice, req.Notes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		prices = derived.Prices
+		update.Source = models.PriceUpdateSourceDerived
+		update.BaseBuyPrice = &req.BaseBuyPrice
+		update.BaseSellPrice = &req.BaseSellPrice
+	case len(req.Prices) == 0:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prices or base prices are required"})
+		return
+	}
+
+	seen := make(map[uint]bool)
+	for _, item := range prices {
+		if seen[item.GoldCategoryID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Gold category %d is listed twice", item.GoldCategoryID)})
+			return
+		}
+		seen[item.GoldCategoryID] = true
+		var category models.GoldCategory
+		if err := database.DB.First(&category, item.GoldCategoryID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Gold category not found", "gold_category_id": item.GoldCategoryID})
+			return
+		}
+		if item.BuyPrice <= 0 || item.SellPrice <= 0 || item.BuyPrice > item.SellPrice {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: prices must be positive and buy price cannot exceed sell price", category.Name)})
+			return
+		}
+		update.Items = append(update.Items, models.ScheduledPriceItem{
+			GoldCategoryID: item.GoldCategoryID,
+			BuyPrice:       item.BuyPrice,
+			SellPrice:      item.SellPrice,
+		})
+	}
+
+	if err := database.DB.Create(&update).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": update})
+}
+
+// CancelScheduledPriceUpdate cancels a price update that has not taken effect yet
+func CancelScheduledPriceUpdate(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	cancelledBy := userID.(uint)
+	now := time.Now()
+
+	// Status dicek di query yang sama agar tidak bentrok dengan worker
+	result := database.DB.Model(&models.ScheduledPriceUpdate{}).
+		Where("id = ? AND status = ?", c.Param("id"), models.ScheduledPriceStatusScheduled).
+		Updates(map[string]interface{}{
+			"status":          models.ScheduledPriceStatusCancelled,
+			"cancelled_by_id": cancelledBy,
+			"cancelled_at":    now,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scheduled price update not found or no longer scheduled"})
+		return
+	}
+
+	var update models.ScheduledPriceUpdate
+	database.DB.First(&update, c.Param("id"))
+	c.JSON(http.StatusOK, gin.H{"data": update})
+}
+
+// applyDueScheduledPrices applies every scheduled update whose effective time has passed, oldest first.
+// Each update is locked and applied in its own transaction, so it is applied exactly once
+// even with several server instances running the worker.
+func applyDueScheduledPrices(db *gorm.DB) (int, error) {
+	applied := 0
+	for {
+		tx := db.Begin()
+		var update models.ScheduledPriceUpdate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND effective_at <= ?", models.ScheduledPriceStatusScheduled, time.Now()).
+			Order("effective_at, id").First(&update).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return applied, nil
+		}
+		if err != nil {
+			tx.Rollback()
+			return applied, err
+		}
+
+		// Harga global yang diubah setelah waktu berlaku lebih baru; jadwal yang terlambat jangan menimpanya
+		var newer models.PriceUpdateLog
+		err = tx.Where("location_id IS NULL AND update_date > ?", update.EffectiveAt).Order("update_date DESC").First(&newer).Error
+		if err == nil {
+			if err := tx.Model(&update).Updates(map[string]interface{}{
+				"status": models.ScheduledPriceStatusSuperseded,
+				"error":  fmt.Sprintf("Superseded by price update #%d at %s", newer.ID, newer.UpdateDate.Format("2006-01-02 15:04")),
+			}).Error; err != nil {
+				tx.Rollback()
+				return applied, err
+			}
+			if err := tx.Commit().Error; err != nil {
+				return applied, err
+			}
+			log.Printf("Scheduled price update %d superseded by price update %d", update.ID, newer.ID)
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			tx.Rollback()
+			return applied, err
+		}
+
+		req := BulkUpdatePriceRequest{Notes: update.Notes}
+		if req.Notes == "" {
+			req.Notes = fmt.Sprintf("Harga terjadwal #%d", update.ID)
+		}
+		for _, item := range update.Items {
+			req.Prices = append(req.Prices, PriceUpdateItem{GoldCategoryID: item.GoldCategoryID, BuyPrice: item.BuyPrice, SellPrice: item.SellPrice})
+		}
+
+		priceUpdateLog, err := applyPriceUpdateTx(tx, models.PriceUpdateLog{
+			UpdateDate:    update.EffectiveAt,
+			UpdatedByID:   update.CreatedByID,
+			Source:        update.Source,
+			BaseBuyPrice:  update.BaseBuyPrice,
+			BaseSellPrice: update.BaseSellPrice,
+		}, req)
+		if err != nil {
+			tx.Rollback()
+			// Tandai gagal agar tidak dicoba terus setiap tick
+			db.Model(&models.ScheduledPriceUpdate{}).Where("id = ? AND status = ?", update.ID, models.ScheduledPriceStatusScheduled).
+				Updates(map[string]interface{}{"status": models.ScheduledPriceStatusFailed, "error": err.Error()})
+			log.Printf("Scheduled price update %d failed: %v", update.ID, err)
+			continue
+		}
+
+		now := time.Now()
+		if err := tx.Model(&update).Updates(map[string]interface{}{
+			"status":              models.ScheduledPriceStatusApplied,
+			"applied_at":          now,
+			"price_update_log_id": priceUpdateLog.ID,
+		}).Error; err != nil {
+			tx.Rollback()
+			return applied, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return applied, err
+		}
+		applied++
+	}
+}
+
+// StartScheduledPriceWorker applies due scheduled price updates at start and then periodically
+func StartScheduledPriceWorker(interval time.Duration) {
+	go func() {
+		run := func() {
+			applied, err := applyDueScheduledPrices(database.DB)
+			if err != nil {
+				log.Println("Scheduled price worker failed:", err)
+			}
+			if applied > 0 {
+				log.Printf("Scheduled price worker applied %d price updates", applied)
+			}
+		}
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
+
+// priceAt returns the global buy and sell price of a category at time t from the price history:
+// harga baru dari update terakhir sebelum t, atau harga lama dari update pertama setelah t
+func priceAt(db *gorm.DB, category models.GoldCategory, t time.Time) (buyPrice, sellPrice float64, updatedAt *time.Time) {
+	type historyRow struct {
+		OldBuyPrice  float64
+		NewBuyPrice  float64
+		OldSellPrice float64
+		NewSellPrice float64
+		UpdateDate   time.Time
+	}
+	base := func() *gorm.DB {
+		return db.Table("price_details").
+			Select("price_details.old_buy_price, price_details.new_buy_price, price_details.old_sell_price, price_details.new_sell_price, price_update_logs.update_date").
+			Joins("JOIN price_update_logs ON price_update_logs.id = price_details.price_update_log_id AND price_update_logs.deleted_at IS NULL").
+			Where("price_details.gold_category_id = ? AND price_details.deleted_at IS NULL AND price_update_logs.location_id IS NULL", category.ID)
+	}
+
+	var before historyRow
+	if err := base().Where("price_update_logs.update_date <= ?", t).
+		Order("price_update_logs.update_date DESC, price_details.id DESC").Limit(1).Scan(&before).Error; err == nil && !before.UpdateDate.IsZero() {
+		return before.NewBuyPrice, before.NewSellPrice, &before.UpdateDate
+	}
+
+	var after historyRow
+	if err := base().Where("price_update_logs.update_date > ?", t).
+		Order("price_update_logs.update_date, price_details.id").Limit(1).Scan(&after).Error; err == nil && !after.UpdateDate.IsZero() {
+		return after.OldBuyPrice, after.OldSellPrice, nil
+	}
+
+	// Tidak ada riwayat: harga tidak pernah berubah
+	return category.BuyPrice, category.SellPrice, nil
+}
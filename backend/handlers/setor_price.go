@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"starter/backend/database"
+	"starter/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== SETOR PRICE VALIDATION ====================
+
+// SetorPricePolicy controls how far the setor price per gram may deviate from the reference buy price
+type SetorPricePolicy struct {
+	TolerancePercent     float64 // Selisih sampai batas ini diterima langsung
+	ApprovalLimitPercent float64 // Selisih sampai batas ini perlu persetujuan supervisor, di atasnya ditolak
+}
+
+// SetorApprovePermission lets a supervisor approve setor prices outside the tolerance
+const SetorApprovePermission = "transactions.approve-setor-price"
+
+var setorPricePolicy = SetorPricePolicy{TolerancePercent: 1, ApprovalLimitPercent: 5}
+
+// SetSetorPricePolicy sets the tolerance of setor prices
+func SetSetorPricePolicy(policy SetorPricePolicy) {
+	setorPricePolicy = policy
+}
+
+// SetorPriceCheck is the server-side reference price of one setor line and how the entered price compares to it
+type SetorPriceCheck struct {
+	ReferencePricePerGram float64 `json:"reference_price_per_gram"` // Setelah potongan kondisi, 0 = tidak ada acuan
+	DeductionPercent      float64 `json:"deduction_percent"`
+	PricePerGram          float64 `json:"price_per_gram"`
+	DeviationPercent      float64 `json:"deviation_percent"`
+	RequiresApproval      bool    `json:"requires_approval"`
+	Reason                string  `json:"reason,omitempty"`
+}
+
+// errSetorPriceRejected marks a setor price that deviates beyond what a supervisor may approve
+var errSetorPriceRejected = errors.New("setor price rejected")
+
+// setorReferencePrice returns the buy price per gram of the category at the location, or for gold without
+// category the price of the purest active category scaled to the entered purity. 0 = tidak ada acuan.
+func setorReferencePrice(db *gorm.DB, locationID uint, categoryID *uint, purity string) (float64, error) {
+	if categoryID != nil && *categoryID > 0 {
+		category, err := effectiveCategory(db, locationID, *categoryID)
+		if err != nil {
+			return 0, fmt.Errorf("Gold category ID %d not found", *categoryID)
+		}
+		return category.BuyPrice, nil
+	}
+
+	var value float64
+	fmt.Sscanf(purity, "%f", &value)
+	fraction := models.PurityFraction(value)
+	if fraction <= 0 {
+		return 0, nil
+	}
+	if fraction > 1 {
+		return 0, fmt.Errorf("Invalid purity %s", purity)
+	}
+
+	var reference models.GoldCategory
+	if err := db.Where("is_active = ? AND purity > 0", true).Order("purity DESC").First(&reference).Error; err != nil {
+		return 0, nil
+	}
+	reference = loadLocationPrices(db, locationID).category(locationID, reference)
+	return reference.BuyPrice * fraction / *reference.Purity, nil
+}
+
+// checkSetorPrice computes the reference price of a setor line after the condition deduction and compares
+// the entered price with it. An empty price takes the reference price.
+func checkSetorPrice(db *gorm.DB, locationID uint, categoryID *uint, purity, condition string, pricePerGram float64) (SetorPriceCheck, error) {
+	check := SetorPriceCheck{PricePerGram: pricePerGram}
+
+	reference, err := setorReferencePrice(db, locationID, categoryID, purity)
+	if err != nil {
+		return check, err
+	}
+	if condition != "" {
+		if !models.RawMaterialCondition(condition).IsValid() {
+			return check, fmt.Errorf("Invalid condition %s", condition)
+		}
+		var rule models.SetorConditionRule
+		if err := db.Where("condition = ?", condition).First(&rule).Error; err == nil {
+			check.DeductionPercent = rule.DeductionPercent
+		}
+	}
+	check.ReferencePricePerGram = math.Round(reference * (1 - check.DeductionPercent/100))
+
+	if check.ReferencePricePerGram <= 0 {
+		if pricePerGram <= 0 {
+			return check, errors.New("Price per gram is required for gold without category or purity")
+		}
+		check.RequiresApproval = true
+		check.Reason = "No reference price for gold without category or purity"
+		return check, nil
+	}
+	if pricePerGram == 0 {
+		check.PricePerGram = check.ReferencePricePerGram
+		return check, nil
+	}
+
+	check.DeviationPercent = priceChangePercent(check.ReferencePricePerGram, pricePerGram)
+	deviation := math.Abs(check.DeviationPercent)
+	switch {
+	case deviation <= setorPricePolicy.TolerancePercent:
+	case deviation <= setorPricePolicy.ApprovalLimitPercent:
+		check.RequiresApproval = true
+		check.Reason = fmt.Sprintf("Price deviates %.2f%% from the reference, above the %.2f%% tolerance", check.DeviationPercent, setorPricePolicy.TolerancePercent)
+	default:
+		check.Reason = fmt.Sprintf("Price deviates %.2f%% from the reference, above the %.2f%% limit", check.DeviationPercent, math.Max(setorPricePolicy.ApprovalLimitPercent, setorPricePolicy.TolerancePercent))
+		return check, errSetorPriceRejected
+	}
+	return check, nil
+}
+
+// verifySetorSupervisor checks the supervisor credentials given with a setor that needs price approval
+func verifySetorSupervisor(db *gorm.DB, username, password string, cashierID uint) (models.User, error) {
+	if username == "" || password == "" {
+		return models.User{}, errors.New("Supervisor approval is required for prices outside the tolerance")
+	}
+	return verifySupervisor(db, username, password, cashierID, SetorApprovePermission, "setor prices")
+}
+
+// verifySupervisor checks inline supervisor credentials: the account must be active, hold the permission
+// and be someone other than the user asking for approval
+func verifySupervisor(db *gorm.DB, username, password string, requesterID uint, permission, subject string) (models.User, error) {
+	var supervisor models.User
+	if username == "" || password == "" {
+		return supervisor, fmt.Errorf("Supervisor approval is required for %s", subject)
+	}
+	if err := db.Preload("Role.Permissions").Where("username = ?", username).First(&supervisor).Error; err != nil || !supervisor.CheckPassword(password) {
+		return supervisor, errors.New("Invalid supervisor credentials")
+	}
+	if !supervisor.IsActive {
+		return supervisor, errors.New("Supervisor account is inactive")
+	}
+	if supervisor.ID == requesterID {
+		return supervisor, fmt.Errorf("Approval of %s must come from someone other than the requester", subject)
+	}
+	for _, perm := range supervisor.Role.Permissions {
+		if perm.Name == permission {
+			return supervisor, nil
+		}
+	}
+	return supervisor, fmt.Errorf("Supervisor is not allowed to approve %s", subject)
+}
+
+// GetSetorReferencePrice returns the reference price of a setor line before it is entered
+func GetSetorReferencePrice(c *gin.Context) {
+	locationID, err := strconv.ParseUint(c.Query("location_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "location_id is required"})
+		return
+	}
+	var categoryID *uint
+	if value := c.Query("gold_category_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid gold_category_id"})
+			return
+		}
+		parsed := uint(id)
+		categoryID = &parsed
+	}
+	pricePerGram, _ := strconv.ParseFloat(c.Query("price_per_gram"), 64)
+
+	check, err := checkSetorPrice(database.DB, uint(locationID), categoryID, c.Query("purity"), c.Query("condition"), pricePerGram)
+	if errors.Is(err, errSetorPriceRejected) {
+		c.JSON(http.StatusOK, gin.H{"data": check, "rejected": true})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": check, "rejected": false})
+}
+
+// GetSetorConditionRules returns the deduction per condition and the price tolerance
+func GetSetorConditionRules(c *gin.Context) {
+	var rules []models.SetorConditionRule
+	if err := database.DB.Order("deduction_percent, condition").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":                   rules,
+		"tolerance_percent":      setorPricePolicy.TolerancePercent,
+		"approval_limit_percent": setorPricePolicy.ApprovalLimitPercent,
+	})
+}
+
+type SetorConditionRuleItem struct {
+	Condition        models.RawMaterialCondition `json:"condition" binding:"required"`
+	DeductionPercent float64                     `json:"deduction_percent"`
+	Notes            string                      `json:"notes"`
+}
+
+type UpdateSetorConditionRulesRequest struct {
+	Rules []SetorConditionRuleItem `json:"rules" binding:"required,min=1,dive"`
+}
+
+// UpdateSetorConditionRules creates or replaces the deduction of the given conditions
+func UpdateSetorConditionRules(c *gin.Context) {
+	var req UpdateSetorConditionRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+	for _, item := range req.Rules {
+		if !item.Condition.IsValid() {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid condition %s", item.Condition)})
+			return
+		}
+		if item.DeductionPercent < 0 || item.DeductionPercent >= 100 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deduction must be between 0 and 100 percent"})
+			return
+		}
+
+		var rule models.SetorConditionRule
+		tx.Where("condition = ?", item.Condition).First(&rule)
+		rule.Condition = item.Condition
+		rule.DeductionPercent = item.DeductionPercent
+		rule.Notes = item.Notes
+		rule.UpdatedByID = &currentUserID
+		if err := tx.Save(&rule).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tx.Commit()
+
+	var rules []models.SetorConditionRule
+	database.DB.Order("deduction_percent, condition").Find(&rules)
+	c.JSON(http.StatusOK, gin.H{"data": rules, "message": "Setor condition rules updated successfully"})
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"starter/backend/database"
+	"starter/backend/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ==================== STOCK AGING ====================
+
+// agingBuckets are the age ranges (days) used by the aging report
+var agingBuckets = []struct {
+	Key     string
+	MaxDays int // inclusive, -1 = no upper bound
+}{
+	{"0-30", 30},
+	{"31-90", 90},
+	{"91-180", 180},
+	{"180+", -1},
+}
+
+// agingBucket returns the bucket key for an age in days
+func agingBucket(days int) string {
+	for _, b := range agingBuckets {
+		if b.MaxDays < 0 || days <= b.MaxDays {
+			return b.Key
+		}
+	}
+	return agingBuckets[len(agingBuckets)-1].Key
+}
+
+// AgingBucketTotal is the count, weight and cost of pieces in one age bucket
+type AgingBucketTotal struct {
+	Count     int64   `json:"count"`
+	Weight    float64 `json:"weight"`
+	CostValue float64 `json:"cost_value"`
+	SellValue float64 `json:"sell_value"`
+}
+
+// StockAgingRow is the aging of one group (location, box, product type or gold category)
+type StockAgingRow struct {
+	GroupKey       string                       `json:"group_key"`
+	GroupName      string                       `json:"group_name"`
+	Buckets        map[string]*AgingBucketTotal `json:"buckets"`
+	Total          AgingBucketTotal             `json:"total"`
+	AverageAgeDays float64                      `json:"average_age_days"`
+	OldestAgeDays  int                          `json:"oldest_age_days"`
+	totalAgeDays   int
+}
+
+// agedStock is an on-hand stock piece with its ages
+type agedStock struct {
+	Stock           models.Stock
+	AgeDays         int        // Sejak diterima
+	LocationAgeDays int        // Sejak tiba di lokasi sekarang (transfer terakhir)
+	ArrivedAt       time.Time  // Tanggal tiba di lokasi sekarang
+	LastTransferAt  *time.Time // Transfer masuk terakhir
+}
+
+// loadAgedStocks loads on-hand stocks with their age since receipt and since arriving at their current location
+func loadAgedStocks(c *gin.Context) ([]agedStock, error) {
+	var stocks []models.Stock
+	query := database.DB.Model(&models.Stock{}).
+		Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
+		Preload("Location").Preload("StorageBox").
+		Where("stocks.status IN ?", []models.StockStatus{models.StockStatusAvailable, models.StockStatusReserved})
+
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("stocks.location_id = ?", locationID)
+	}
+	if boxID := c.Query("storage_box_id"); boxID != "" {
+		scope, err := storageSubtreeScope(boxID)
+		if err != nil {
+			return nil, fmt.Errorf("Storage box not found")
+		}
+		query = query.Scopes(scope)
+	}
+	if goldCategoryID := c.Query("gold_category_id"); goldCategoryID != "" {
+		query = query.Where("stocks.product_id IN (?)", database.DB.Model(&models.Product{}).Select("id").Where("gold_category_id = ?", goldCategoryID))
+	}
+	if productType := c.Query("product_type"); productType != "" {
+		query = query.Where("stocks.product_id IN (?)", database.DB.Model(&models.Product{}).Select("id").Where("type = ?", productType))
+	}
+
+	if err := query.Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	// Transfer masuk terakhir ke lokasi stok saat ini
+	lastTransfers := make(map[uint]time.Time)
+	if len(stocks) > 0 {
+		var rows []struct {
+			StockID       uint
+			TransferredAt time.Time
+		}
+		database.DB.Table("stock_transfers st").
+			Select("st.stock_id, MAX(st.transferred_at) as transferred_at").
+			Joins("JOIN stocks s ON s.id = st.stock_id AND s.location_id = st.to_location_id").
+			Where("st.deleted_at IS NULL AND st.status = ?", "completed").
+			Group("st.stock_id").
+			Scan(&rows)
+		for _, row := range rows {
+			lastTransfers[row.StockID] = row.TransferredAt
+		}
+	}
+
+	now := time.Now()
+	aged := make([]agedStock, 0, len(stocks))
+	for _, stock := range stocks {
+		received := stock.CreatedAt
+		if stock.ReceivedAt != nil {
+			received = *stock.ReceivedAt
+		}
+		item := agedStock{
+			Stock:     stock,
+			AgeDays:   int(now.Sub(received).Hours() / 24),
+			ArrivedAt: received,
+		}
+		if transferredAt, ok := lastTransfers[stock.ID]; ok && transferredAt.After(received) {
+			t := transferredAt
+			item.LastTransferAt = &t
+			item.ArrivedAt = transferredAt
+		}
+		item.LocationAgeDays = int(now.Sub(item.ArrivedAt).Hours() / 24)
+		aged = append(aged, item)
+	}
+	return aged, nil
+}
+
+// GetStockAgingReport returns on-hand stock bucketed by age (0-30/31-90/91-180/180+ days).
+// group_by: location (default), box, product_type, gold_category; basis: received (default) or location.
+func GetStockAgingReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "location")
+	basis := c.DefaultQuery("basis", "received")
+
+	aged, err := loadAgedStocks(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows := make(map[string]*StockAgingRow)
+	var total StockAgingRow
+	total.Buckets = newAgingBuckets()
+	locationPrices := loadLocationPrices(database.DB)
+
+	for _, item := range aged {
+		key, name := agingGroup(item.Stock, groupBy)
+		row, ok := rows[key]
+		if !ok {
+			row = &StockAgingRow{GroupKey: key, GroupName: name, Buckets: newAgingBuckets()}
+			rows[key] = row
+		}
+
+		days := item.AgeDays
+		if basis == "location" {
+			days = item.LocationAgeDays
+		}
+		priced := item.Stock
+		locationPrices.applyToStock(&priced)
+		weight := item.Stock.EffectiveWeight()
+		value := AgingBucketTotal{
+			Count:     1,
+			Weight:    weight,
+			CostValue: item.Stock.CostPrice,
+			SellValue: priced.SellPrice().Total,
+		}
+
+		for _, r := range []*StockAgingRow{row, &total} {
+			r.Buckets[agingBucket(days)].add(value)
+			r.Total.add(value)
+			r.totalAgeDays += days
+			if days > r.OldestAgeDays {
+				r.OldestAgeDays = days
+			}
+		}
+	}
+
+	var result []StockAgingRow
+	for _, row := range rows {
+		if row.Total.Count > 0 {
+			row.AverageAgeDays = float64(row.totalAgeDays) / float64(row.Total.Count)
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GroupName < result[j].GroupName })
+	if total.Total.Count > 0 {
+		total.AverageAgeDays = float64(total.totalAgeDays) / float64(total.Total.Count)
+	}
+	total.GroupKey = "total"
+	total.GroupName = "Total"
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": groupBy,
+		"basis":    basis,
+		"data":     result,
+		"total":    total,
+	})
+}
+
+func newAgingBuckets() map[string]*AgingBucketTotal {
+	buckets := make(map[string]*AgingBucketTotal)
+	for _, b := range agingBuckets {
+		buckets[b.Key] = &AgingBucketTotal{}
+	}
+	return buckets
+}
+
+func (t *AgingBucketTotal) add(v AgingBucketTotal) {
+	t.Count += v.Count
+	t.Weight += v.Weight
+	t.CostValue += v.CostValue
+	t.SellValue += v.SellValue
+}
+
+// agingGroup returns the grouping key and display name of a stock
+func agingGroup(stock models.Stock, groupBy string) (string, string) {
+	switch groupBy {
+	case "box":
+		name := stock.StorageBox.PathCode
+		if name == "" {
+			name = stock.StorageBox.Code
+		}
+		return strconv.FormatUint(uint64(stock.StorageBoxID), 10), stock.Location.Name + " - " + name
+	case "product_type":
+		return string(stock.Product.Type), string(stock.Product.Type)
+	case "gold_category":
+		return strconv.FormatUint(uint64(stock.Product.GoldCategoryID), 10), stock.Product.GoldCategory.Name
+	default:
+		return strconv.FormatUint(uint64(stock.LocationID), 10), stock.Location.Name
+	}
+}
+
+// ==================== SELL-THROUGH ====================
+
+// ProductSellThrough is the sell-through of one product over a period
+type ProductSellThrough struct {
+	ProductID        uint    `json:"product_id"`
+	ProductName      string  `json:"product_name"`
+	Barcode          string  `json:"barcode"`
+	ProductType      string  `json:"product_type"`
+	GoldCategoryName string  `json:"gold_category_name"`
+	OpeningStock     int64   `json:"opening_stock"` // Stok di awal periode
+	Received         int64   `json:"received"`      // Diterima dalam periode
+	Sold             int64   `json:"sold"`          // Terjual dalam periode
+	OnHand           int64   `json:"on_hand"`       // Stok saat ini
+	SellThroughRate  float64 `json:"sell_through"`  // Terjual / (awal + diterima) dalam persen
+	AverageAgeDays   float64 `json:"average_age_days"`
+}
+
+// GetSellThroughReport returns sell-through per product: sold / (opening stock + received) over a period
+func GetSellThroughReport(c *gin.Context) {
+	now := time.Now()
+	startDate := c.DefaultQuery("start_date", now.AddDate(0, 0, -90).Format("2006-01-02"))
+	endDate := c.DefaultQuery("end_date", now.Format("2006-01-02"))
+	end := endDate + " 23:59:59"
+
+	locationFilter := ""
+	args := []interface{}{startDate, startDate, startDate, end, startDate, end, end}
+	if locationID := c.Query("location_id"); locationID != "" {
+		locationFilter = "AND s.location_id = ?"
+		args = append(args, locationID)
+	}
+
+	received := "COALESCE(s.received_at, s.created_at)"
+	query := `
+		SELECT
+			p.id as product_id,
+			p.name as product_name,
+			p.barcode,
+			p.type as product_type,
+			gc.name as gold_category_name,
+			SUM(CASE WHEN ` + received + ` < ? AND (s.status <> 'sold' OR s.sold_at >= ?) THEN 1 ELSE 0 END) as opening_stock,
+			SUM(CASE WHEN ` + received + ` >= ? AND ` + received + ` <= ? THEN 1 ELSE 0 END) as received,
+			SUM(CASE WHEN s.status = 'sold' AND s.sold_at >= ? AND s.sold_at <= ? THEN 1 ELSE 0 END) as sold,
+			SUM(CASE WHEN s.status IN ('available', 'reserved') AND ` + received + ` <= ? THEN 1 ELSE 0 END) as on_hand
+		FROM stocks s
+		JOIN products p ON p.id = s.product_id
+		JOIN gold_categories gc ON gc.id = p.gold_category_id
+		WHERE s.deleted_at IS NULL AND s.status <> 'written_off' ` + locationFilter + `
+		GROUP BY p.id, p.name, p.barcode, p.type, gc.name
+		ORDER BY p.name
+	`
+
+	var results []ProductSellThrough
+	if err := database.DB.Raw(query, args...).Scan(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Umur rata-rata stok yang masih ada per produk
+	aged, err := loadAgedStocks(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ageSum := make(map[uint]int)
+	ageCount := make(map[uint]int)
+	for _, item := range aged {
+		ageSum[item.Stock.ProductID] += item.AgeDays
+		ageCount[item.Stock.ProductID]++
+	}
+
+	var filtered []ProductSellThrough
+	for _, r := range results {
+		available := r.OpeningStock + r.Received
+		if available == 0 && r.Sold == 0 {
+			continue
+		}
+		if available > 0 {
+			r.SellThroughRate = float64(r.Sold) / float64(available) * 100
+		}
+		if ageCount[r.ProductID] > 0 {
+			r.AverageAgeDays = float64(ageSum[r.ProductID]) / float64(ageCount[r.ProductID])
+		}
+		filtered = append(filtered, r)
+	}
+
+	// Paling lambat laku di atas
+	if c.DefaultQuery("sort", "slowest") == "slowest" {
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].SellThroughRate < filtered[j].SellThroughRate })
+	} else {
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].SellThroughRate > filtered[j].SellThroughRate })
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": startDate,
+		"end_date":   endDate,
+		"data":       filtered,
+	})
+}
+
+// ==================== SLOW-MOVER CANDIDATES ====================
+
+// SlowMoverCandidate is an on-hand piece suggested for melting or transfer to another store
+type SlowMoverCandidate struct {
+	StockID             uint    `json:"stock_id"`
+	SerialNumber        string  `json:"serial_number"`
+	ProductID           uint    `json:"product_id"`
+	ProductName         string  `json:"product_name"`
+	GoldCategoryName    string  `json:"gold_category_name"`
+	LocationID          uint    `json:"location_id"`
+	LocationName        string  `json:"location_name"`
+	StorageBox          string  `json:"storage_box"`
+	Weight              float64 `json:"weight"`
+	CostPrice           float64 `json:"cost_price"`
+	AgeDays             int     `json:"age_days"`
+	LocationAgeDays     int     `json:"location_age_days"`
+	SoldHere            int64   `json:"sold_here"`      // Terjual di lokasi ini dalam jendela penjualan
+	SoldElsewhere       int64   `json:"sold_elsewhere"` // Terjual di lokasi lain dalam jendela penjualan
+	SuggestedLocationID *uint   `json:"suggested_location_id,omitempty"`
+	SuggestedLocation   string  `json:"suggested_location,omitempty"`
+	Reason              string  `json:"reason"`
+}
+
+// GetSlowMoverCandidates suggests pieces to melt (old and the design no longer sells anywhere)
+// or to transfer (idle at this location while the design sells at another store).
+func GetSlowMoverCandidates(c *gin.Context) {
+	meltAgeDays := parseIntDefault(c.Query("melt_age_days"), 365)
+	transferAgeDays := parseIntDefault(c.Query("transfer_age_days"), 90)
+	salesWindowDays := parseIntDefault(c.Query("sales_window_days"), 180)
+
+	aged, err := loadAgedStocks(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Penjualan per produk per lokasi dalam jendela penjualan
+	since := time.Now().AddDate(0, 0, -salesWindowDays)
+	var salesRows []struct {
+		ProductID  uint
+		LocationID uint
+		Sold       int64
+	}
+	database.DB.Model(&models.Stock{}).
+		Select("product_id, location_id, COUNT(*) as sold").
+		Where("status = ? AND sold_at >= ?", models.StockStatusSold, since).
+		Group("product_id, location_id").
+		Scan(&salesRows)
+
+	sales := make(map[uint]map[uint]int64)
+	for _, row := range salesRows {
+		if sales[row.ProductID] == nil {
+			sales[row.ProductID] = make(map[uint]int64)
+		}
+		sales[row.ProductID][row.LocationID] = row.Sold
+	}
+
+	var locations []models.Location
+	database.DB.Find(&locations)
+	locationNames := make(map[uint]string)
+	for _, loc := range locations {
+		locationNames[loc.ID] = loc.Name
+	}
+
+	var meltCandidates, transferCandidates []SlowMoverCandidate
+	for _, item := range aged {
+		stock := item.Stock
+		if stock.Status != models.StockStatusAvailable {
+			continue
+		}
+
+		candidate := SlowMoverCandidate{
+			StockID:          stock.ID,
+			SerialNumber:     stock.SerialNumber,
+			ProductID:        stock.ProductID,
+			ProductName:      stock.Product.Name,
+			GoldCategoryName: stock.Product.GoldCategory.Name,
+			LocationID:       stock.LocationID,
+			LocationName:     stock.Location.Name,
+			StorageBox:       stock.StorageBox.PathCode,
+			Weight:           stock.EffectiveWeight(),
+			CostPrice:        stock.CostPrice,
+			AgeDays:          item.AgeDays,
+			LocationAgeDays:  item.LocationAgeDays,
+		}
+
+		// Lokasi lain dengan penjualan terbanyak untuk produk ini
+		var bestLocation uint
+		var bestSold int64
+		for locationID, sold := range sales[stock.ProductID] {
+			if locationID == stock.LocationID {
+				candidate.SoldHere = sold
+				continue
+			}
+			candidate.SoldElsewhere += sold
+			if sold > bestSold {
+				bestLocation, bestSold = locationID, sold
+			}
+		}
+
+		switch {
+		case item.LocationAgeDays >= transferAgeDays && candidate.SoldHere == 0 && bestSold > 0:
+			candidate.SuggestedLocationID = &bestLocation
+			candidate.SuggestedLocation = locationNames[bestLocation]
+			candidate.Reason = fmt.Sprintf("Tidak laku %d hari di %s, terjual %d di %s", item.LocationAgeDays, stock.Location.Name, bestSold, locationNames[bestLocation])
+			transferCandidates = append(transferCandidates, candidate)
+		case item.AgeDays >= meltAgeDays && candidate.SoldHere == 0 && candidate.SoldElsewhere == 0:
+			candidate.Reason = fmt.Sprintf("Umur %d hari, desain tidak terjual di lokasi manapun dalam %d hari", item.AgeDays, salesWindowDays)
+			meltCandidates = append(meltCandidates, candidate)
+		}
+	}
+
+	sort.Slice(meltCandidates, func(i, j int) bool { return meltCandidates[i].AgeDays > meltCandidates[j].AgeDays })
+	sort.Slice(transferCandidates, func(i, j int) bool {
+		return transferCandidates[i].LocationAgeDays > transferCandidates[j].LocationAgeDays
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"melt":     meltCandidates,
+		"transfer": transferCandidates,
+		"criteria": gin.H{
+			"melt_age_days":     meltAgeDays,
+			"transfer_age_days": transferAgeDays,
+			"sales_window_days": salesWindowDays,
+		},
+	})
+}
+
+// parseIntDefault parses a positive integer query value, falling back to def
+func parseIntDefault(s string, def int) int {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
@@ -9,12 +10,13 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // GetStocks returns all stocks with filters
 func GetStocks(c *gin.Context) {
 	var stocks []models.Stock
-	query := database.DB.Preload("Product").Preload("Product.GoldCategory").
+	query := database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
 		Preload("Location").Preload("StorageBox")
 
 	// Filter by location_id
@@ -22,9 +24,18 @@ func GetStocks(c *gin.Context) {
 		query = query.Where("location_id = ?", locationID)
 	}
 
-	// Filter by storage_box_id
+	// Filter by storage_box_id (include_children=true aggregates the whole subtree)
 	if boxID := c.Query("storage_box_id"); boxID != "" {
-		query = query.Where("storage_box_id = ?", boxID)
+		if c.Query("include_children") == "true" {
+			scope, err := storageSubtreeScope(boxID)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Storage box not found"})
+				return
+			}
+			query = query.Scopes(scope)
+		} else {
+			query = query.Where("storage_box_id = ?", boxID)
+		}
 	}
 
 	// Filter by status
@@ -48,8 +59,8 @@ func GetStocks(c *gin.Context) {
 func GetStock(c *gin.Context) {
 	id := c.Param("id")
 	var stock models.Stock
-	if err := database.DB.Preload("Product").Preload("Product.GoldCategory").
-		Preload("Location").Preload("StorageBox").First(&stock, id).Error; err != nil {
+	if err := database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Product.Images").
+		Preload("Stones").Preload("Images").Preload("Location").Preload("StorageBox").First(&stock, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
 		return
 	}
@@ -60,22 +71,27 @@ func GetStock(c *gin.Context) {
 func GetStockBySerial(c *gin.Context) {
 	serial := c.Param("serial")
 	var stock models.Stock
-	if err := database.DB.Preload("Product").Preload("Product.GoldCategory").
-		Preload("Location").Preload("StorageBox").
+	if err := database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Product.Images").
+		Preload("Stones").Preload("Images").Preload("Location").Preload("StorageBox").
 		Where("serial_number = ?", serial).First(&stock).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": stock})
+	c.JSON(http.StatusOK, gin.H{"data": stock, "photo_url": stock.PhotoURL()})
 }
 
 type CreateStockRequest struct {
-	ProductID    uint   `json:"product_id" binding:"required"`
-	LocationID   uint   `json:"location_id" binding:"required"`
-	StorageBoxID uint   `json:"storage_box_id" binding:"required"`
-	Quantity     int    `json:"quantity" binding:"required,min=1"`
-	SupplierName string `json:"supplier_name"`
-	Notes        string `json:"notes"`
+	ProductID    uint      `json:"product_id" binding:"required"`
+	LocationID   uint      `json:"location_id" binding:"required"`
+	StorageBoxID uint      `json:"storage_box_id" binding:"required"`
+	Quantity     int       `json:"quantity" binding:"required,min=1"`
+	SupplierID   *uint     `json:"supplier_id"`
+	SupplierName string    `json:"supplier_name"`
+	CostPerGram  float64   `json:"cost_per_gram"`       // Harga modal per gram (optional)
+	CostPerPiece float64   `json:"cost_per_piece"`      // Harga modal per buah (optional, overrides cost_per_gram)
+	PieceWeights []float64 `json:"piece_weights"`       // Berat bersih aktual per buah, diketik (optional, panjang = quantity)
+	Certificates []string  `json:"certificate_numbers"` // Nomor sertifikat per keping, wajib untuk logam mulia
+	Notes        string    `json:"notes"`
 }
 
 // CreateStock creates a new stock entry (receiving from distributor)
@@ -106,9 +122,45 @@ func CreateStock(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Storage box not found in this location"})
 		return
 	}
+	if storageBoxHasChildren(database.DB, box.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+		return
+	}
+
+	if len(req.PieceWeights) > 0 && len(req.PieceWeights) != req.Quantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%d piece weights given for quantity %d", len(req.PieceWeights), req.Quantity)})
+		return
+	}
+
+	certificates, err := validateBullionCertificates(database.DB, product, req.Certificates, req.Quantity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Resolve supplier master if given
+	supplierName := req.SupplierName
+	if req.SupplierID != nil {
+		var supplier models.Supplier
+		if err := database.DB.First(&supplier, *req.SupplierID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Supplier not found"})
+			return
+		}
+		supplierName = supplier.Name
+	}
+
+	// Cost basis per piece
+	costPrice := req.CostPerGram * product.Weight
+	costPerGram := req.CostPerGram
+	if req.CostPerPiece > 0 {
+		costPrice = req.CostPerPiece
+		if product.Weight > 0 {
+			costPerGram = req.CostPerPiece / product.Weight
+		}
+	}
 
 	// Create multiple stock entries based on quantity
-	// Harga tidak disimpan di stock - akan dihitung dari gold_category saat dibutuhkan
+	// Harga jual tidak disimpan di stock - akan dihitung dari gold_category saat dibutuhkan
 	now := time.Now()
 	timestamp := now.Unix() // Use seconds for shorter serial
 	var stocks []models.Stock
@@ -123,14 +175,32 @@ func CreateStock(c *gin.Context) {
 			StorageBoxID: req.StorageBoxID,
 			SerialNumber: serialNumber,
 			Status:       models.StockStatusAvailable,
-			SupplierName: req.SupplierName,
+			SupplierName: supplierName,
+			SupplierID:   req.SupplierID,
 			ReceivedAt:   &now,
+			CostPrice:    costPrice,
+			CostPerGram:  costPerGram,
 			Notes:        req.Notes,
 		}
+		if len(certificates) > 0 {
+			stock.CertificateNumber = certificates[i]
+			stock.Manufacturer = product.Manufacturer
+		}
+		if len(req.PieceWeights) > 0 && req.PieceWeights[i] > 0 {
+			stock.NetWeight = req.PieceWeights[i]
+			stock.GrossWeight = req.PieceWeights[i]
+			// Berat diketik, belum terverifikasi timbangan; verifikasi lewat timbang ulang
+			if req.CostPerPiece <= 0 {
+				stock.CostPrice = req.CostPerGram * stock.NetWeight
+			}
+		}
 		stocks = append(stocks, stock)
 	}
 
 	// Create stocks one by one to ensure each gets a unique ID
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
 	tx := database.DB.Begin()
 	for i := range stocks {
 		if err := tx.Create(&stocks[i]).Error; err != nil {
@@ -138,6 +208,12 @@ func CreateStock(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		stocks[i].Product = product
+		if err := recordStockFineGold(tx, stocks[i], models.FineGoldMovementReceipt, 1, "stock", stocks[i].ID, stocks[i].SerialNumber, &currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	}
 	tx.Commit()
 
@@ -146,7 +222,7 @@ func CreateStock(c *gin.Context) {
 	for _, s := range stocks {
 		stockIDs = append(stockIDs, s.ID)
 	}
-	database.DB.Preload("Product").Preload("Product.GoldCategory").
+	database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
 		Preload("Location").Preload("StorageBox").Find(&stocks, stockIDs)
 
 	c.JSON(http.StatusCreated, gin.H{"data": stocks, "count": len(stocks)})
@@ -199,40 +275,274 @@ func UpdateStock(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	before := stock
+
+	// Barang yang ditahan penawaran tidak boleh dipindah atau diubah statusnya
+	if (req.LocationID > 0 && req.LocationID != stock.LocationID) || (req.Status != "" && req.Status != stock.Status) {
+		if held, ok := stockQuotation(database.DB, stock.ID, 0); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock is reserved by quotation %s, cancel the quotation first", held.QuotationNumber)})
+			return
+		}
+	}
 
 	if req.LocationID > 0 {
 		stock.LocationID = req.LocationID
 	}
 	if req.StorageBoxID > 0 {
+		if storageBoxHasChildren(database.DB, req.StorageBoxID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+			return
+		}
 		stock.StorageBoxID = req.StorageBoxID
 	}
-	if req.Status != "" {
+	if req.Status != "" && req.Status != stock.Status {
+		// Write-off hanya boleh lewat dokumen write-off yang disetujui
+		if req.Status == models.StockStatusWrittenOff || stock.Status == models.StockStatusWrittenOff {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Written-off status can only be changed through a write-off"})
+			return
+		}
+		// Stok yang ditahan write-off pending hanya dilepas lewat persetujuan atau penolakan write-off
+		var pendingWriteOffs int64
+		database.DB.Model(&models.StockWriteOff{}).
+			Where("stock_id = ? AND status = ?", stock.ID, models.WriteOffStatusPending).
+			Count(&pendingWriteOffs)
+		if pendingWriteOffs > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Stock is held by a pending write-off, approve or reject the write-off first"})
+			return
+		}
 		stock.Status = req.Status
 	}
 	if req.Notes != "" {
 		stock.Notes = req.Notes
 	}
 
-	if err := database.DB.Save(&stock).Error; err != nil {
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	if err := tx.Save(&stock).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	database.DB.Preload("Product").Preload("Product.GoldCategory").
+	// Pindah lokasi atau status tanpa dokumen dicatat sebagai penyesuaian emas murni
+	wasOnHand, isOnHand := stockOnHand(before.Status), stockOnHand(stock.Status)
+	if before.LocationID != stock.LocationID || wasOnHand != isOnHand {
+		if wasOnHand {
+			if err := recordStockFineGold(tx, before, models.FineGoldMovementAdjustment, -1, "stock", stock.ID, stock.SerialNumber, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if isOnHand {
+			if err := recordStockFineGold(tx, stock, models.FineGoldMovementAdjustment, 1, "stock", stock.ID, stock.SerialNumber, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
 		Preload("Location").Preload("StorageBox").First(&stock, stock.ID)
 	c.JSON(http.StatusOK, gin.H{"data": stock})
 }
 
-// DeleteStock deletes a stock
+type UpdateStockWeightRequest struct {
+	GrossWeight    float64 `json:"gross_weight"`
+	NetWeight      float64 `json:"net_weight" binding:"required,gt=0"`
+	ScaleReadingID *uint   `json:"scale_reading_id"` // Reading timbangan untuk berat kotor; kosong = diketik
+	Notes          string  `json:"notes"`
+}
+
+// UpdateStockWeight records a re-weighing of a stock piece on the scale
+func UpdateStockWeight(c *gin.Context) {
+	id := c.Param("id")
+	var stock models.Stock
+	if err := database.DB.Preload("Product").Preload("Product.Stones").Preload("Stones").First(&stock, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+
+	var req UpdateStockWeightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if stock.Status == models.StockStatusSold {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot re-weigh a sold stock"})
+		return
+	}
+
+	// Berat bersih adalah berat emas saja; berat kotor termasuk batu
+	stoneWeight := models.StonesWeightGrams(stock.PieceStones())
+	grossWeight := req.GrossWeight
+	if grossWeight <= 0 {
+		grossWeight = req.NetWeight + stoneWeight
+	}
+	if grossWeight < req.NetWeight {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Gross weight cannot be less than net weight"})
+		return
+	}
+	if req.NetWeight+stoneWeight > grossWeight+stoneWeightTolerance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Net gold weight plus stones (%.3f g) exceeds gross weight, enter the gold weight net of stones", stoneWeight)})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	verifiedBy := userID.(uint)
+	previousWeight := stock.EffectiveWeight()
+	now := time.Now()
+
+	tx := database.DB.Begin()
+
+	// Timbangan membaca berat kotor (seluruh perhiasan di atas timbangan)
+	reading, weightSource, err := claimScaleReading(tx, req.ScaleReadingID, grossWeight, stock.LocationID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{
+		"gross_weight":          grossWeight,
+		"net_weight":            req.NetWeight,
+		"weight_verified_at":    now,
+		"weight_verified_by_id": verifiedBy,
+		"weight_source":         weightSource,
+		"scale_reading_id":      req.ScaleReadingID,
+	}
+	if req.Notes != "" {
+		updates["notes"] = req.Notes
+	}
+
+	if err := tx.Model(&stock).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := useScaleReading(tx, reading, models.ScaleReadingTargetStock, stock.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Selisih timbang ulang menjadi penyesuaian emas murni
+	if stockOnHand(stock.Status) && req.NetWeight != previousWeight {
+		reweighed := stock
+		reweighed.NetWeight = math.Abs(req.NetWeight - previousWeight)
+		sign := 1.0
+		if req.NetWeight < previousWeight {
+			sign = -1
+		}
+		if err := recordStockFineGold(tx, reweighed, models.FineGoldMovementAdjustment, sign, "stock", stock.ID, stock.SerialNumber, &verifiedBy); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
+		Preload("Location").Preload("StorageBox").First(&stock, stock.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":            stock,
+		"previous_weight": previousWeight,
+		"difference":      req.NetWeight - previousWeight,
+	})
+}
+
+// stockOnHand reports whether a stock status still counts as physically held by its location
+func stockOnHand(status models.StockStatus) bool {
+	for _, s := range onHandStockStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// stockDeleteWindow is how long after creation a stock entry may still be deleted as an input error
+const stockDeleteWindow = 24 * time.Hour
+
+// DeleteStock deletes a stock that was created in error.
+// Barang yang hilang, dicuri, rusak atau dilebur harus melalui write-off.
 func DeleteStock(c *gin.Context) {
 	id := c.Param("id")
-	if err := database.DB.Delete(&models.Stock{}, id).Error; err != nil {
+	var stock models.Stock
+	if err := database.DB.First(&stock, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+
+	if reason := stockDeleteBlocker(stock); reason != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": reason + ". Use a write-off to remove this piece from inventory"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	if err := tx.Delete(&stock).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Membatalkan emas murni yang tercatat saat input
+	if err := recordStockFineGold(tx, stock, models.FineGoldMovementReceipt, -1, "stock", stock.ID, stock.SerialNumber, &currentUserID); err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"message": "Stock deleted successfully"})
 }
 
+// stockDeleteBlocker explains why a stock can no longer be treated as an input error, or returns ""
+func stockDeleteBlocker(stock models.Stock) string {
+	if stock.Status != models.StockStatusAvailable {
+		return fmt.Sprintf("Stock with status %s cannot be deleted", stock.Status)
+	}
+	if stock.TransactionID != nil {
+		return "Stock has a transaction"
+	}
+	if stock.GoodsReceiptID != nil {
+		return "Stock comes from a goods receipt, cancel the receipt instead"
+	}
+	if stock.BarcodePrinted {
+		return "Stock label has already been printed"
+	}
+	if time.Since(stock.CreatedAt) > stockDeleteWindow {
+		return "Stock was created more than 24 hours ago"
+	}
+	if held, ok := stockQuotation(database.DB, stock.ID, 0); ok {
+		return fmt.Sprintf("Stock is reserved by quotation %s", held.QuotationNumber)
+	}
+
+	var count int64
+	database.DB.Model(&models.StockTransfer{}).Where("stock_id = ?", stock.ID).Count(&count)
+	if count > 0 {
+		return "Stock has been transferred"
+	}
+	database.DB.Model(&models.StockWriteOff{}).Where("stock_id = ?", stock.ID).Count(&count)
+	if count > 0 {
+		return "Stock has a write-off"
+	}
+	return ""
+}
+
 // ==================== STOCK TRANSFER ====================
 
 type TransferStockRequest struct {
@@ -265,6 +575,14 @@ func TransferStock(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock is not available for transfer"})
 		return
 	}
+	if transferNumber := pendingTransferOf(database.DB, stock.ID); transferNumber != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock already has draft transfer %s", transferNumber)})
+		return
+	}
+	if held, ok := stockQuotation(database.DB, stock.ID, 0); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock is reserved by quotation %s, cancel the quotation first", held.QuotationNumber)})
+		return
+	}
 
 	// Verify destination location and box
 	var toBox models.StorageBox
@@ -272,6 +590,10 @@ func TransferStock(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Destination box not found in specified location"})
 		return
 	}
+	if storageBoxHasChildren(database.DB, toBox.ID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+		return
+	}
 
 	// Create transfer record
 	transferNumber := fmt.Sprintf("TRF%d", time.Now().UnixNano()/1000000)
@@ -298,9 +620,7 @@ func TransferStock(c *gin.Context) {
 	}
 
 	// Update stock location
-	stock.LocationID = req.ToLocationID
-	stock.StorageBoxID = req.ToBoxID
-	if err := tx.Save(&stock).Error; err != nil {
+	if err := moveTransferredStock(tx, transfer, &stock, userID.(uint)); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -313,6 +633,118 @@ func TransferStock(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"data": transfer})
 }
 
+// moveTransferredStock moves a stock piece to the destination of a transfer and books the fine gold between locations
+func moveTransferredStock(tx *gorm.DB, transfer models.StockTransfer, stock *models.Stock, userID uint) error {
+	fromStock := *stock
+	stock.LocationID = transfer.ToLocationID
+	stock.StorageBoxID = transfer.ToBoxID
+	if err := tx.Save(stock).Error; err != nil {
+		return err
+	}
+
+	// Emas murni pindah antar lokasi (pindah box dalam lokasi yang sama tidak dicatat)
+	if fromStock.LocationID != stock.LocationID {
+		if err := recordStockFineGold(tx, fromStock, models.FineGoldMovementTransferOut, -1, "stock_transfer", transfer.ID, transfer.TransferNumber, &userID); err != nil {
+			return err
+		}
+		if err := recordStockFineGold(tx, *stock, models.FineGoldMovementTransferIn, 1, "stock_transfer", transfer.ID, transfer.TransferNumber, &userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingTransferOf returns the number of a draft transfer already holding the stock, or ""
+func pendingTransferOf(db *gorm.DB, stockID uint) string {
+	var transfer models.StockTransfer
+	if err := db.Where("stock_id = ? AND status = ?", stockID, "pending").First(&transfer).Error; err != nil {
+		return ""
+	}
+	return transfer.TransferNumber
+}
+
+// CompleteStockTransfer executes a draft (pending) transfer, e.g. one created from a replenishment suggestion
+func CompleteStockTransfer(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	var transfer models.StockTransfer
+	if err := tx.First(&transfer, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock transfer not found"})
+		return
+	}
+	if transfer.Status != "pending" {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft transfers can be completed"})
+		return
+	}
+
+	var stock models.Stock
+	if err := tx.First(&stock, transfer.StockID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+	if stock.Status != models.StockStatusAvailable || stock.LocationID != transfer.FromLocationID {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is no longer available at the source location", stock.SerialNumber)})
+		return
+	}
+	// Penawaran bisa dibuat setelah draft transfer; barangnya harus tetap di lokasi penawaran
+	if held, ok := stockQuotation(tx, stock.ID, 0); ok {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is reserved by quotation %s, cancel the quotation first", stock.SerialNumber, held.QuotationNumber)})
+		return
+	}
+
+	transfer.Status = "completed"
+	transfer.FromBoxID = stock.StorageBoxID
+	transfer.TransferredByID = currentUserID
+	transfer.TransferredAt = time.Now()
+	if err := tx.Save(&transfer).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := moveTransferredStock(tx, transfer, &stock, currentUserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Stock").Preload("FromLocation").Preload("FromBox").
+		Preload("ToLocation").Preload("ToBox").Preload("TransferredBy").First(&transfer, transfer.ID)
+	c.JSON(http.StatusOK, gin.H{"data": transfer})
+}
+
+// CancelStockTransfer cancels a draft (pending) transfer
+func CancelStockTransfer(c *gin.Context) {
+	id := c.Param("id")
+	var transfer models.StockTransfer
+	if err := database.DB.First(&transfer, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock transfer not found"})
+		return
+	}
+	if transfer.Status != "pending" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only draft transfers can be cancelled"})
+		return
+	}
+
+	transfer.Status = "cancelled"
+	if err := database.DB.Save(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": transfer})
+}
+
 // GetStockTransfers returns all stock transfers
 func GetStockTransfers(c *gin.Context) {
 	var transfers []models.StockTransfer
@@ -335,6 +767,11 @@ func GetStockTransfers(c *gin.Context) {
 		query = query.Where("to_location_id = ?", toID)
 	}
 
+	// Filter by status (pending = draft transfers)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
 	if err := query.Order("created_at DESC").Find(&transfers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -343,13 +780,20 @@ func GetStockTransfers(c *gin.Context) {
 }
 
 // GetStocksByBox returns all stocks in a specific storage box for barcode printing
+// For non-leaf nodes (etalase, brankas) the stocks of all descendant boxes are returned
 func GetStocksByBox(c *gin.Context) {
 	boxID := c.Param("box_id")
 	var stocks []models.Stock
 
-	query := database.DB.Preload("Product").Preload("Product.GoldCategory").
+	scope, err := storageSubtreeScope(boxID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storage box not found"})
+		return
+	}
+
+	query := database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
 		Preload("Location").Preload("StorageBox").
-		Where("storage_box_id = ?", boxID)
+		Scopes(scope)
 
 	// Filter by status (default: available)
 	if status := c.Query("status"); status != "" {
@@ -365,7 +809,8 @@ func GetStocksByBox(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": stocks})
 }
 
-// MarkStocksPrinted marks stocks as barcode printed
+// MarkStocksPrinted records labels printed from the browser as a completed label job,
+// so every printed mark has a job in the audit trail
 type MarkPrintedRequest struct {
 	StockIDs []uint `json:"stock_ids" binding:"required"`
 }
@@ -382,25 +827,61 @@ func MarkStocksPrinted(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	result := database.DB.Model(&models.Stock{}).
-		Where("id IN ?", req.StockIDs).
-		Updates(map[string]interface{}{
-			"barcode_printed":    true,
-			"barcode_printed_at": now,
-		})
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
 
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	var ids []uint
+	if err := database.DB.Model(&models.Stock{}).Where("id IN ?", req.StockIDs).Order("id").Pluck("id", &ids).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No stocks found"})
 		return
 	}
 
+	var template models.LabelTemplate
+	if err := database.DB.Where("is_active = ?", true).Order("is_default DESC, id").First(&template).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Label template not found"})
+		return
+	}
+
+	job := models.LabelJob{
+		JobNumber:   generateTransactionCode("LBL"),
+		TemplateID:  template.ID,
+		Format:      models.LabelFormatPDF, // Dicetak dari dialog cetak browser
+		StockIDs:    ids,
+		LabelCount:  len(ids),
+		Status:      models.LabelJobStatusRendered,
+		CreatedByID: currentUserID,
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Create(&job).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create label job: " + err.Error()})
+		return
+	}
+	if err := completeLabelJob(tx, &job, currentUserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("%d stocks marked as printed", result.RowsAffected),
-		"count":   result.RowsAffected,
+		"message": fmt.Sprintf("%d stocks marked as printed", len(ids)),
+		"count":   len(ids),
+		"data":    job,
 	})
 }
 
+// stockWeightExpr returns the SQL expression for the effective weight of a stock piece:
+// its measured net weight, or the product weight when the piece was never weighed
+func stockWeightExpr(stockAlias, productAlias string) string {
+	return fmt.Sprintf("COALESCE(NULLIF(%s.net_weight, 0), %s.weight)", stockAlias, productAlias)
+}
+
 // GetStocksByLocation returns stocks grouped by location with summary
 func GetStocksByLocation(c *gin.Context) {
 	type CategorySummary struct {
@@ -434,7 +915,7 @@ func GetStocksByLocation(c *gin.Context) {
 			Count(&totalItems)
 
 		database.DB.Table("stocks").
-			Select("COALESCE(SUM(products.weight), 0)").
+			Select("COALESCE(SUM("+stockWeightExpr("stocks", "products")+"), 0)").
 			Joins("JOIN products ON products.id = stocks.product_id").
 			Where("stocks.location_id = ? AND stocks.status = ?", loc.ID, "available").
 			Scan(&totalWeight)
@@ -442,7 +923,7 @@ func GetStocksByLocation(c *gin.Context) {
 		// Get category breakdown
 		var categories []CategorySummary
 		rows, err := database.DB.Table("stocks").
-			Select("gold_categories.name as category_name, COUNT(*) as count, COALESCE(SUM(products.weight), 0) as weight").
+			Select("gold_categories.name as category_name, COUNT(*) as count, COALESCE(SUM("+stockWeightExpr("stocks", "products")+"), 0) as weight").
 			Joins("JOIN products ON products.id = stocks.product_id").
 			Joins("JOIN gold_categories ON gold_categories.id = products.gold_category_id").
 			Where("stocks.location_id = ? AND stocks.status = ?", loc.ID, "available").
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ==================== GEMSTONE COMPONENTS ====================
+
+// stoneWeightTolerance is the allowed difference (gram) between gross weight and gold + stones,
+// karena berat karat pada sertifikat dibulatkan
+const stoneWeightTolerance = 0.02
+
+type StoneRequest struct {
+	StoneType         models.StoneType `json:"stone_type" binding:"required"`
+	Carat             float64          `json:"carat"` // Berat per butir (ct)
+	Count             int              `json:"count"`
+	Clarity           string           `json:"clarity"`
+	Color             string           `json:"color"`
+	CertificateNumber string           `json:"certificate_number"`
+	PricePerStone     float64          `json:"price_per_stone"`
+	Notes             string           `json:"notes"`
+}
+
+// buildStoneComponents validates stone requests and converts them into components without owner
+func buildStoneComponents(reqs []StoneRequest) ([]models.StoneComponent, error) {
+	var stones []models.StoneComponent
+	for i, req := range reqs {
+		if !req.StoneType.IsValid() {
+			return nil, fmt.Errorf("Stone %d has invalid type %s", i+1, req.StoneType)
+		}
+		if req.Carat < 0 || req.PricePerStone < 0 {
+			return nil, fmt.Errorf("Stone %d cannot have negative carat or price", i+1)
+		}
+		count := req.Count
+		if count == 0 {
+			count = 1
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("Stone %d has invalid count", i+1)
+		}
+		stones = append(stones, models.StoneComponent{
+			StoneType:         req.StoneType,
+			Carat:             req.Carat,
+			Count:             count,
+			Clarity:           strings.ToUpper(strings.TrimSpace(req.Clarity)),
+			Color:             strings.TrimSpace(req.Color),
+			CertificateNumber: strings.TrimSpace(req.CertificateNumber),
+			PricePerStone:     req.PricePerStone,
+			Notes:             req.Notes,
+		})
+	}
+	return stones, nil
+}
+
+// replaceStones replaces the stones owned by a product, stock, transaction item or raw material.
+// ownerColumn is the foreign key column of the owner (product_id, stock_id, ...)
+func replaceStones(tx *gorm.DB, ownerColumn string, ownerID uint, stones []models.StoneComponent) error {
+	if err := tx.Where(ownerColumn+" = ?", ownerID).Delete(&models.StoneComponent{}).Error; err != nil {
+		return err
+	}
+	for i := range stones {
+		stones[i].ID = 0
+		switch ownerColumn {
+		case "product_id":
+			stones[i].ProductID = &ownerID
+		case "stock_id":
+			stones[i].StockID = &ownerID
+		case "transaction_item_id":
+			stones[i].TransactionItemID = &ownerID
+		case "raw_material_id":
+			stones[i].RawMaterialID = &ownerID
+		}
+		if err := tx.Create(&stones[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockStoneValueExpr returns the SQL expression for the stone value of a piece:
+// batu per buah jika ada, selain itu batu desain produk
+func stockStoneValueExpr(stockAlias, productAlias string) string {
+	return fmt.Sprintf(`COALESCE(
+		(SELECT SUM(sc.price_per_stone * sc.count) FROM stone_components sc WHERE sc.stock_id = %s.id AND sc.deleted_at IS NULL),
+		(SELECT SUM(sc.price_per_stone * sc.count) FROM stone_components sc WHERE sc.product_id = %s.id AND sc.deleted_at IS NULL),
+		0)`, stockAlias, productAlias)
+}
+
+// stockSellValueExpr returns the SQL expression for the current sell value of a piece:
+// harga jual per gram di lokasi stok * berat emas + nilai batu + ongkos pembuatan, atau harga per keping untuk logam mulia
+func stockSellValueExpr(stockAlias, productAlias, categoryAlias string) string {
+	return fmt.Sprintf("(CASE WHEN %s.kind = '%s' THEN %s.bullion_sell_price ELSE %s * %s + %s + COALESCE(%s.making_charge, 0) END)",
+		productAlias, models.ProductKindBullion, productAlias,
+		locationPriceExpr(stockAlias+".location_id", categoryAlias, "sell_price"), stockWeightExpr(stockAlias, productAlias),
+		stockStoneValueExpr(stockAlias, productAlias), productAlias)
+}
+
+// stockBuyValueExpr returns the SQL expression for the current buyback value of a piece (emas saja, harga di lokasi stok)
+func stockBuyValueExpr(stockAlias, productAlias, categoryAlias string) string {
+	return fmt.Sprintf("(CASE WHEN %s.kind = '%s' THEN %s.bullion_buy_price ELSE %s * %s END)",
+		productAlias, models.ProductKindBullion, productAlias,
+		locationPriceExpr(stockAlias+".location_id", categoryAlias, "buy_price"), stockWeightExpr(stockAlias, productAlias))
+}
+
+type UpdateStockStonesRequest struct {
+	Stones []StoneRequest `json:"stones"`
+}
+
+// UpdateStockStones sets the stones of a single piece (dengan nomor sertifikat).
+// An empty list makes the piece follow the stones of its product design again.
+func UpdateStockStones(c *gin.Context) {
+	id := c.Param("id")
+	var stock models.Stock
+	if err := database.DB.Preload("Product").First(&stock, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+	if stock.Product.IsBullion() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bullion has no stones"})
+		return
+	}
+	if stock.Status == models.StockStatusSold || stock.Status == models.StockStatusWrittenOff {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change stones of a sold or written-off stock"})
+		return
+	}
+
+	var req UpdateStockStonesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stones, err := buildStoneComponents(req.Stones)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Berat emas dicatat bersih dari batu
+	if stock.GrossWeight > 0 && stock.NetWeight+models.StonesWeightGrams(stones) > stock.GrossWeight+stoneWeightTolerance {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Gold weight %.3f g plus stones %.3f g exceeds gross weight %.3f g, re-weigh the piece first",
+			stock.NetWeight, models.StonesWeightGrams(stones), stock.GrossWeight)})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := replaceStones(tx, "stock_id", stock.ID, stones); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	database.DB.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").
+		Preload("Location").Preload("StorageBox").First(&stock, stock.ID)
+	loadLocationPrices(database.DB, stock.LocationID).applyToStock(&stock)
+	c.JSON(http.StatusOK, gin.H{"data": stock, "price": stock.SellPrice()})
+}
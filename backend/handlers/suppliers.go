@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSuppliers returns all suppliers
+func GetSuppliers(c *gin.Context) {
+	var suppliers []models.Supplier
+	query := database.DB
+
+	// Filter by active status
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+
+	// Search by name, code or phone
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name ILIKE ? OR code ILIKE ? OR phone ILIKE ?",
+			"%"+search+"%", "%"+search+"%", "%"+search+"%")
+	}
+
+	if err := query.Order("name").Find(&suppliers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": suppliers})
+}
+
+// GetSupplier returns a single supplier
+func GetSupplier(c *gin.Context) {
+	id := c.Param("id")
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": supplier})
+}
+
+type CreateSupplierRequest struct {
+	Code          string `json:"code"`
+	Name          string `json:"name" binding:"required"`
+	ContactPerson string `json:"contact_person"`
+	Phone         string `json:"phone"`
+	Email         string `json:"email"`
+	Address       string `json:"address"`
+	TaxNumber     string `json:"tax_number"`
+	Notes         string `json:"notes"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+// CreateSupplier creates a new supplier
+func CreateSupplier(c *gin.Context) {
+	var req CreateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code := req.Code
+	if code == "" {
+		code = generateSupplierCode()
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	supplier := models.Supplier{
+		Code:          code,
+		Name:          req.Name,
+		ContactPerson: req.ContactPerson,
+		Phone:         req.Phone,
+		Email:         req.Email,
+		Address:       req.Address,
+		TaxNumber:     req.TaxNumber,
+		Notes:         req.Notes,
+		IsActive:      isActive,
+	}
+
+	if err := database.DB.Create(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": supplier})
+}
+
+// generateSupplierCode generates a unique supplier code
+func generateSupplierCode() string {
+	timestamp := time.Now().UnixNano() / 1000000
+	return fmt.Sprintf("SUP%d", timestamp)
+}
+
+type UpdateSupplierRequest struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	ContactPerson string `json:"contact_person"`
+	Phone         string `json:"phone"`
+	Email         string `json:"email"`
+	Address       string `json:"address"`
+	TaxNumber     string `json:"tax_number"`
+	Notes         string `json:"notes"`
+	IsActive      *bool  `json:"is_active"`
+}
+
+// UpdateSupplier updates an existing supplier
+func UpdateSupplier(c *gin.Context) {
+	id := c.Param("id")
+	var supplier models.Supplier
+	if err := database.DB.First(&supplier, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found"})
+		return
+	}
+
+	var req UpdateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Code != "" {
+		supplier.Code = req.Code
+	}
+	if req.Name != "" {
+		supplier.Name = req.Name
+	}
+	if req.ContactPerson != "" {
+		supplier.ContactPerson = req.ContactPerson
+	}
+	if req.Phone != "" {
+		supplier.Phone = req.Phone
+	}
+	if req.Email != "" {
+		supplier.Email = req.Email
+	}
+	if req.Address != "" {
+		supplier.Address = req.Address
+	}
+	if req.TaxNumber != "" {
+		supplier.TaxNumber = req.TaxNumber
+	}
+	if req.Notes != "" {
+		supplier.Notes = req.Notes
+	}
+	if req.IsActive != nil {
+		supplier.IsActive = *req.IsActive
+	}
+
+	if err := database.DB.Save(&supplier).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": supplier})
+}
+
+// DeleteSupplier deletes a supplier
+func DeleteSupplier(c *gin.Context) {
+	id := c.Param("id")
+	if err := database.DB.Delete(&models.Supplier{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Supplier deleted successfully"})
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"starter/backend/database"
 	"starter/backend/models"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetTransactions returns all transactions
@@ -123,8 +127,8 @@ func GetTransaction(c *gin.Context) {
 	id := c.Param("id")
 	var transaction models.Transaction
 	if err := database.DB.Preload("Member").Preload("Location").Preload("Cashier").
-		Preload("Items").Preload("Items.Stock").Preload("Items.Stock.Product").
-		Preload("Items.GoldCategory").First(&transaction, id).Error; err != nil {
+		Preload("Items").Preload("Items.Stock").Preload("Items.Stock.Product").Preload("Items.Stock.Images").
+		Preload("Items.GoldCategory").Preload("Items.Stones").Preload("Items.PriceApprovedBy").First(&transaction, id).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
@@ -136,8 +140,8 @@ func GetTransactionByCode(c *gin.Context) {
 	code := c.Param("code")
 	var transaction models.Transaction
 	if err := database.DB.Preload("Member").Preload("Location").Preload("Cashier").
-		Preload("Items").Preload("Items.GoldCategory").Preload("Items.Stock").Preload("Items.Stock.Product").Preload("Items.Stock.Product.GoldCategory").
-		Where("transaction_code = ?", code).First(&transaction).Error; err != nil {
+		Preload("Items").Preload("Items.GoldCategory").Preload("Items.Stones").Preload("Items.Stock").Preload("Items.Stock.Product").Preload("Items.Stock.Product.GoldCategory").
+		Preload("Items.Stock.Images").Where("transaction_code = ?", code).First(&transaction).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
@@ -164,6 +168,40 @@ type CreateSaleRequest struct {
 	PaymentMethod   string            `json:"payment_method" binding:"required"`
 	PaidAmount      float64           `json:"paid_amount" binding:"required"`
 	Notes           string            `json:"notes"`
+	QuotationID     *uint             `json:"quotation_id"`  // Penawaran yang masih berlaku: harga buah di penawaran dipakai
+	RedeemPoints    int               `json:"redeem_points"` // Poin member yang ditukar sebagai diskon
+}
+
+// saleLine prices a stock piece at the latest gold category price (dengan override harga lokasi) and its
+// actual weight (fallback ke berat produk), plus stone value and making charge.
+// Salinan dipakai agar harga lokasi tidak ikut tersimpan bersama stok
+func saleLine(stock models.Stock, locationPrices locationPriceBook) models.TransactionItem {
+	priced := stock
+	locationPrices.applyToStock(&priced)
+	weight := stock.EffectiveWeight()
+	price := priced.SellPrice()
+	pricePerGram := priced.Product.GoldCategory.SellPrice
+	if stock.Product.IsBullion() && weight > 0 {
+		// Logam mulia dijual per keping, harga per gram hanya informasi
+		pricePerGram = price.Total / weight
+	}
+
+	return models.TransactionItem{
+		StockID:      &stock.ID,
+		ItemName:     stock.Product.Name,
+		Barcode:      stock.Product.Barcode,
+		Weight:       weight,
+		PricePerGram: pricePerGram,
+		UnitPrice:    price.Total,
+		GoldValue:    price.GoldValue,
+		StoneValue:   price.StoneValue,
+		MakingCharge: price.MakingCharge,
+		Quantity:     1,
+
+		CertificateNumber: stock.CertificateNumber,
+		Manufacturer:      stock.Manufacturer,
+		PhotoURL:          stock.PhotoURL(),
+	}
 }
 
 // CreateSale creates a new sale transaction
@@ -192,11 +230,25 @@ func CreateSale(c *gin.Context) {
 
 	var subTotal float64 = 0
 	var transactionItems []models.TransactionItem
+	var soldStocks []models.Stock
+	locationPrices := loadLocationPrices(tx, req.LocationID)
+
+	var quotation *models.Quotation
+	if req.QuotationID != nil {
+		loaded, err := loadSaleQuotation(tx, *req.QuotationID, req.LocationID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		quotation = &loaded
+	}
 
 	// Process each item
 	for _, item := range req.Items {
 		var stock models.Stock
-		if err := tx.Preload("Product").Preload("Product.GoldCategory").First(&stock, item.StockID).Error; err != nil {
+		if err := tx.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Product.Images").
+			Preload("Stones").Preload("Images").First(&stock, item.StockID).Error; err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock ID %d not found", item.StockID)})
 			return
@@ -216,25 +268,33 @@ func CreateSale(c *gin.Context) {
 			return
 		}
 
-		// Gunakan harga terbaru dari gold category, bukan dari stock.SellPrice yang mungkin sudah lama
-		currentSellPrice := stock.Product.GoldCategory.SellPrice * stock.Product.Weight
-		pricePerGram := stock.Product.GoldCategory.SellPrice
+		line := saleLine(stock, locationPrices)
 
-		itemSubTotal := currentSellPrice - item.Discount
-		subTotal += itemSubTotal
+		// Buah yang ditahan penawaran lain tidak bisa dijual; buah di penawaran ini memakai harga yang dikunci
+		quoted, inQuotation := quotedItem(quotation, stock.ID)
+		if !inQuotation {
+			var exceptID uint
+			if quotation != nil {
+				exceptID = quotation.ID
+			}
+			if held, ok := stockQuotation(tx, stock.ID, exceptID); ok {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock %s is reserved by quotation %s until %s", stock.SerialNumber, held.QuotationNumber, held.ValidUntil.Format("2006-01-02 15:04"))})
+				return
+			}
+		} else {
+			line.PricePerGram = quoted.PricePerGram
+			line.UnitPrice = quoted.UnitPrice
+			line.GoldValue = quoted.GoldValue
+			line.StoneValue = quoted.StoneValue
+			line.MakingCharge = quoted.MakingCharge
+		}
 
-		transactionItems = append(transactionItems, models.TransactionItem{
-			StockID:      &stock.ID,
-			ItemName:     stock.Product.Name,
-			Barcode:      stock.Product.Barcode,
-			Weight:       stock.Product.Weight,
-			PricePerGram: pricePerGram,
-			UnitPrice:    currentSellPrice,
-			Quantity:     1,
-			Discount:     item.Discount,
-			SubTotal:     itemSubTotal,
-			Notes:        item.Notes,
-		})
+		line.Discount = item.Discount
+		line.SubTotal = line.UnitPrice - item.Discount
+		line.Notes = item.Notes
+		subTotal += line.SubTotal
+		transactionItems = append(transactionItems, line)
 
 		// Update stock status to sold
 		now := time.Now()
@@ -245,6 +305,7 @@ func CreateSale(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		soldStocks = append(soldStocks, stock)
 	}
 
 	// Calculate totals
@@ -252,7 +313,46 @@ func CreateSale(c *gin.Context) {
 	if req.DiscountPercent > 0 {
 		discountAmount = subTotal * req.DiscountPercent / 100
 	}
-	grandTotal := subTotal - discountAmount + req.Tax
+
+	// Diskon tier member setelah diskon transaksi
+	var memberDiscountPercent, memberDiscount float64
+	if req.MemberID != nil {
+		percent, err := memberTierDiscount(tx, *req.MemberID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		memberDiscountPercent = percent
+		memberDiscount = (subTotal - discountAmount) * percent / 100
+	}
+
+	// Tukar poin member sebagai potongan
+	var pointsDiscount float64
+	if req.RedeemPoints < 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Redeemed points cannot be negative"})
+		return
+	}
+	if req.RedeemPoints > 0 {
+		if req.MemberID == nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Points can only be redeemed by a member"})
+			return
+		}
+		if memberPointSettings.RedeemValue <= 0 {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Point redemption is disabled"})
+			return
+		}
+		pointsDiscount = float64(req.RedeemPoints) * memberPointSettings.RedeemValue
+		if pointsDiscount > subTotal-discountAmount-memberDiscount {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Redeemed points are worth more than the total"})
+			return
+		}
+	}
+	grandTotal := subTotal - discountAmount - memberDiscount - pointsDiscount + req.Tax
 	changeAmount := req.PaidAmount - grandTotal
 
 	if changeAmount < 0 {
@@ -273,6 +373,12 @@ func CreateSale(c *gin.Context) {
 		DiscountPercent: req.DiscountPercent,
 		Tax:             req.Tax,
 		GrandTotal:      grandTotal,
+
+		MemberDiscount:        memberDiscount,
+		MemberDiscountPercent: memberDiscountPercent,
+		PointsRedeemed:        req.RedeemPoints,
+		PointsDiscount:        pointsDiscount,
+
 		PaymentMethod:   models.PaymentMethod(req.PaymentMethod),
 		PaidAmount:      req.PaidAmount,
 		ChangeAmount:    changeAmount,
@@ -309,14 +415,53 @@ func CreateSale(c *gin.Context) {
 		}
 	}
 
+	// Penawaran selesai, buah yang tidak dibeli ikut dilepas
+	if quotation != nil {
+		now := time.Now()
+		if err := tx.Model(quotation).Updates(map[string]interface{}{
+			"status":         models.QuotationStatusConverted,
+			"transaction_id": transaction.ID,
+			"closed_at":      now,
+		}).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Emas murni keluar dari lokasi
+	for _, stock := range soldStocks {
+		if err := recordStockFineGold(tx, stock, models.FineGoldMovementSale, -1, "transaction", transaction.ID, transaction.TransactionCode, &currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Update member if exists
 	if req.MemberID != nil {
-		var member models.Member
-		if err := tx.First(&member, *req.MemberID).Error; err == nil {
-			member.TotalPurchase += grandTotal
-			member.TransactionCount += 1
-			member.AddPoints(grandTotal)
-			tx.Save(&member)
+		if req.RedeemPoints > 0 {
+			if _, err := postMemberPoints(tx, models.MemberPointEntry{
+				MemberID:      *req.MemberID,
+				Type:          models.MemberPointRedeem,
+				Points:        -req.RedeemPoints,
+				TransactionID: &transaction.ID,
+				Notes:         transaction.TransactionCode,
+				CreatedByID:   &currentUserID,
+			}); err != nil {
+				tx.Rollback()
+				status := http.StatusInternalServerError
+				if errors.Is(err, errInsufficientPoints) {
+					status = http.StatusBadRequest
+				}
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if _, err := recordMemberTransaction(tx, *req.MemberID, transaction); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
@@ -337,9 +482,18 @@ type PurchaseItemRequest struct {
 	WeightGross      float64 `json:"weight_gross"`
 	ShrinkagePercent float64 `json:"shrinkage_percent"`
 	Weight           float64 `json:"weight" binding:"required"` // Berat bersih
-	PricePerGram     float64 `json:"price_per_gram" binding:"required"`
+	PricePerGram     float64 `json:"price_per_gram"`            // Kosong = harga beli acuan (kategori di lokasi ini, dikurangi potongan kondisi)
 	Condition        string  `json:"condition"`
 	Notes            string  `json:"notes"`
+	ScaleReadingID   *uint   `json:"scale_reading_id"` // Reading timbangan untuk berat kotor; kosong = diketik
+	// Batu pada barang setor, dinilai terpisah (price_per_stone = harga beli kembali per butir).
+	// Weight adalah berat emas bersih dari batu
+	Stones []StoneRequest `json:"stones"`
+	// Buyback logam mulia: sertifikat harus cocok dengan keping yang pernah kita jual.
+	// Harga mengikuti harga buyback per keping, bukan price_per_gram
+	CertificateNumber string `json:"certificate_number"`
+	Manufacturer      string `json:"manufacturer"`
+	RestockBoxID      *uint  `json:"restock_box_id"` // Simpan kembali sebagai stok siap jual di box ini
 }
 
 type CreatePurchaseRequest struct {
@@ -351,6 +505,9 @@ type CreatePurchaseRequest struct {
 	PaymentMethod     string                `json:"payment_method" binding:"required"`
 	Notes             string                `json:"notes"`
 	SaveAsRawMaterial bool                  `json:"save_as_raw_material"` // Flag untuk simpan ke raw material
+	// Supervisor yang menyetujui harga di luar toleransi harga acuan
+	SupervisorUsername string `json:"supervisor_username"`
+	SupervisorPassword string `json:"supervisor_password"`
 }
 
 // CreatePurchase creates a new purchase/setor transaction (buying from customer)
@@ -379,9 +536,26 @@ func CreatePurchase(c *gin.Context) {
 
 	var grandTotal float64 = 0
 	var transactionItems []models.TransactionItem
+	var scaleReadings []*models.ScaleReading
+	var itemStones [][]models.StoneComponent
+	buybackStocks := make([]*models.Stock, len(req.Items))
+	var priceApprover *models.User
 
 	// Process each item
-	for _, item := range req.Items {
+	for idx, item := range req.Items {
+		// Timbangan membaca berat kotor (sebelum susut)
+		scaleWeight := item.WeightGross
+		if scaleWeight == 0 {
+			scaleWeight = item.Weight
+		}
+		reading, weightSource, err := claimScaleReading(tx, item.ScaleReadingID, scaleWeight, req.LocationID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		scaleReadings = append(scaleReadings, reading)
+
 		var categoryName string
 		var categoryID *uint
 
@@ -401,7 +575,95 @@ func CreatePurchase(c *gin.Context) {
 			}
 		}
 
-		totalPrice := item.Weight * item.PricePerGram
+		stones, err := buildStoneComponents(item.Stones)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if item.WeightGross > 0 && item.Weight+models.StonesWeightGrams(stones) > item.WeightGross+stoneWeightTolerance {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Gold weight plus stones exceeds gross weight, enter the gold weight net of stones"})
+			return
+		}
+		itemStones = append(itemStones, stones)
+
+		// Harga per gram dicek terhadap harga beli acuan server; logam mulia bersertifikat mengikuti harga buyback
+		pricePerGram := item.PricePerGram
+		var priceCheck SetorPriceCheck
+		if item.CertificateNumber == "" {
+			priceCheck, err = checkSetorPrice(tx, req.LocationID, item.GoldCategoryID, item.Purity, item.Condition, item.PricePerGram)
+			if errors.Is(err, errSetorPriceRejected) {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: %s", idx+1, priceCheck.Reason), "price_check": priceCheck})
+				return
+			}
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Item %d: %s", idx+1, err.Error())})
+				return
+			}
+			if priceCheck.RequiresApproval && priceApprover == nil {
+				supervisor, err := verifySetorSupervisor(tx, req.SupervisorUsername, req.SupervisorPassword, currentUserID)
+				if err != nil {
+					tx.Rollback()
+					c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "requires_approval": true, "item_index": idx, "price_check": priceCheck})
+					return
+				}
+				priceApprover = &supervisor
+			}
+			pricePerGram = priceCheck.PricePerGram
+		}
+
+		// Emas dan batu dinilai terpisah
+		goldValue := item.Weight * pricePerGram
+		stoneValue := models.StonesValue(stones)
+		itemName := fmt.Sprintf("Setor Emas %s", categoryName)
+
+		// Buyback logam mulia: verifikasi sertifikat terhadap penjualan kita
+		if item.CertificateNumber != "" {
+			sold, err := findBuybackStock(tx, item.Manufacturer, item.CertificateNumber)
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if math.Abs(item.Weight-sold.EffectiveWeight()) > bullionWeightTolerance {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Weight %.3f g does not match certificate %s (%.3f g)", item.Weight, sold.CertificateNumber, sold.EffectiveWeight())})
+				return
+			}
+			if sold.Product.BullionBuyPrice <= 0 {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Buyback price of %s is not set", sold.Product.Name)})
+				return
+			}
+			if len(stones) > 0 {
+				tx.Rollback()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Bullion has no stones"})
+				return
+			}
+			for _, other := range buybackStocks {
+				if other != nil && other.ID == sold.ID {
+					tx.Rollback()
+					c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Certificate %s is listed twice", sold.CertificateNumber)})
+					return
+				}
+			}
+			buybackStocks[idx] = &sold
+
+			goldValue = sold.BuyPrice()
+			pricePerGram = goldValue / item.Weight
+			priceCheck.ReferencePricePerGram = pricePerGram
+			categoryID = &sold.Product.GoldCategoryID
+			itemName = fmt.Sprintf("Buyback %s", sold.Product.Name)
+		} else if item.RestockBoxID != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only certified bullion can be restocked directly"})
+			return
+		}
+
+		totalPrice := goldValue + stoneValue
 		grandTotal += totalPrice
 
 		notesText := ""
@@ -411,20 +673,42 @@ func CreatePurchase(c *gin.Context) {
 		if item.Purity != "" {
 			notesText += fmt.Sprintf(" Kadar: %s.", item.Purity)
 		}
+		if len(stones) > 0 {
+			notesText += fmt.Sprintf(" Batu: %d butir.", stoneCount(stones))
+		}
 		if item.Notes != "" {
 			notesText += fmt.Sprintf(" %s", item.Notes)
 		}
 
-		transactionItems = append(transactionItems, models.TransactionItem{
+		transactionItem := models.TransactionItem{
 			GoldCategoryID: categoryID,
-			ItemName:       fmt.Sprintf("Setor Emas %s", categoryName),
+			ItemName:       itemName,
 			Weight:         item.Weight,
-			PricePerGram:   item.PricePerGram,
+			PricePerGram:   pricePerGram,
 			UnitPrice:      totalPrice,
+			GoldValue:      goldValue,
+			StoneValue:     stoneValue,
 			Quantity:       1,
 			SubTotal:       totalPrice,
 			Notes:          notesText,
-		})
+			WeightSource:   weightSource,
+			WeightGross:    scaleWeight,
+			ScaleReadingID: item.ScaleReadingID,
+
+			ReferencePricePerGram:     priceCheck.ReferencePricePerGram,
+			ConditionDeductionPercent: priceCheck.DeductionPercent,
+			PriceDeviationPercent:     priceCheck.DeviationPercent,
+		}
+		if priceCheck.RequiresApproval {
+			transactionItem.PriceApprovedByID = &priceApprover.ID
+		}
+		if sold := buybackStocks[idx]; sold != nil {
+			transactionItem.Barcode = sold.Product.Barcode
+			transactionItem.CertificateNumber = sold.CertificateNumber
+			transactionItem.Manufacturer = sold.Manufacturer
+			transactionItem.BuybackOfStockID = &sold.ID
+		}
+		transactionItems = append(transactionItems, transactionItem)
 	}
 
 	// Create transaction
@@ -459,24 +743,90 @@ func CreatePurchase(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if err := useScaleReading(tx, scaleReadings[i], models.ScaleReadingTargetTransactionItem, transactionItems[i].ID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err := replaceStones(tx, "transaction_item_id", transactionItems[i].ID, itemStones[i]); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	// Update member if exists
 	if req.MemberID != nil {
-		var member models.Member
-		if err := tx.First(&member, *req.MemberID).Error; err == nil {
-			member.TotalSell += grandTotal
-			member.TransactionCount += 1
-			// Also add points for selling gold to us (half the rate)
-			points := int(grandTotal / 200000) // 1 point per 200,000 sold
-			member.Points += points
-			tx.Save(&member)
+		if _, err := recordMemberTransaction(tx, *req.MemberID, transaction); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Logam mulia yang dibeli kembali bisa langsung dijual lagi dengan sertifikat yang sama
+	for i, item := range req.Items {
+		sold := buybackStocks[i]
+		if sold == nil || item.RestockBoxID == nil {
+			continue
+		}
+		var box models.StorageBox
+		if err := tx.Where("id = ? AND location_id = ?", *item.RestockBoxID, req.LocationID).First(&box).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Restock box not found in this location"})
+			return
+		}
+		if storageBoxHasChildren(tx, box.ID) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Stock can only be placed in a leaf storage box"})
+			return
+		}
+
+		now := time.Now()
+		restocked := models.Stock{
+			ProductID:         sold.ProductID,
+			LocationID:        req.LocationID,
+			StorageBoxID:      box.ID,
+			SerialNumber:      generateSerialNumber(sold.Product.Barcode, now.Unix(), i),
+			Status:            models.StockStatusAvailable,
+			SupplierName:      fmt.Sprintf("Buyback %s", transaction.TransactionCode),
+			ReceivedAt:        &now,
+			GrossWeight:       sold.GrossWeight,
+			NetWeight:         sold.NetWeight,
+			WeightSource:      transactionItems[i].WeightSource,
+			CertificateNumber: sold.CertificateNumber,
+			Manufacturer:      sold.Manufacturer,
+			CostPrice:         transactionItems[i].SubTotal,
+			CostPerGram:       transactionItems[i].PricePerGram,
+			Notes:             fmt.Sprintf("Dibeli kembali dari penjualan stok %s", sold.SerialNumber),
+		}
+		if err := tx.Create(&restocked).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		restocked.Product = sold.Product
+		if err := recordStockFineGold(tx, restocked, models.FineGoldMovementSetor, 1, "transaction", transaction.ID, transaction.TransactionCode, &currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Dicatat di baris setor agar pembatalan bisa mengeluarkan keping ini lagi
+		if err := tx.Model(&transactionItems[i]).Update("restocked_stock_id", restocked.ID).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
 	// Create raw materials if flag is true
 	if req.SaveAsRawMaterial {
-		for _, item := range req.Items {
+		for i, item := range req.Items {
+			// Keping logam mulia yang distok ulang tidak menjadi bahan baku
+			if buybackStocks[i] != nil && item.RestockBoxID != nil {
+				continue
+			}
+
 			// Set weight gross default to weight if not provided
 			weightGross := item.WeightGross
 			if weightGross == 0 {
@@ -497,14 +847,14 @@ func CreatePurchase(c *gin.Context) {
 			now := time.Now()
 			rawMaterial := models.RawMaterial{
 				Code:             GenerateRawMaterialCode(),
-				GoldCategoryID:   item.GoldCategoryID,
+				GoldCategoryID:   transactionItems[i].GoldCategoryID,
 				LocationID:       req.LocationID,
 				WeightGross:      weightGross,
 				ShrinkagePercent: item.ShrinkagePercent,
 				WeightGrams:      item.Weight,
 				Purity:           purity,
-				BuyPricePerGram:  item.PricePerGram,
-				TotalBuyPrice:    item.Weight * item.PricePerGram,
+				BuyPricePerGram:  transactionItems[i].PricePerGram,
+				TotalBuyPrice:    transactionItems[i].GoldValue, // Emas saja, batu dinilai terpisah
 				Condition:        condition,
 				Status:           models.RawMaterialStatusAvailable,
 				MemberID:         req.MemberID,
@@ -512,6 +862,8 @@ func CreatePurchase(c *gin.Context) {
 				ReceivedAt:       &now,
 				ReceivedByID:     &currentUserID,
 				Notes:            item.Notes,
+				WeightSource:     transactionItems[i].WeightSource,
+				ScaleReadingID:   item.ScaleReadingID,
 			}
 
 			if err := tx.Create(&rawMaterial).Error; err != nil {
@@ -519,6 +871,22 @@ func CreatePurchase(c *gin.Context) {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create raw material: " + err.Error()})
 				return
 			}
+			// Batu yang dilepas dicatat ulang di bahan baku (tanpa nilai emas); batu di baris setor tetap sebagai riwayat
+			rawStones := make([]models.StoneComponent, len(itemStones[i]))
+			for j, stone := range itemStones[i] {
+				stone.TransactionItemID = nil
+				rawStones[j] = stone
+			}
+			if err := replaceStones(tx, "raw_material_id", rawMaterial.ID, rawStones); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if err := recordRawMaterialFineGold(tx, rawMaterial, models.FineGoldMovementSetor, 1, "transaction", transaction.ID, transaction.TransactionCode, &currentUserID); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
 		}
 	}
 
@@ -526,11 +894,20 @@ func CreatePurchase(c *gin.Context) {
 
 	// Load full transaction data
 	database.DB.Preload("Member").Preload("Location").Preload("Cashier").
-		Preload("Items").First(&transaction, transaction.ID)
+		Preload("Items").Preload("Items.Stones").Preload("Items.PriceApprovedBy").First(&transaction, transaction.ID)
 
 	c.JSON(http.StatusCreated, gin.H{"data": transaction})
 }
 
+// stoneCount returns the number of stones in a set of components
+func stoneCount(stones []models.StoneComponent) int {
+	count := 0
+	for _, s := range stones {
+		count += s.Count
+	}
+	return count
+}
+
 // generateTransactionCode generates a unique transaction code
 func generateTransactionCode(prefix string) string {
 	now := time.Now()
@@ -540,18 +917,33 @@ func generateTransactionCode(prefix string) string {
 // CancelTransaction cancels a transaction
 func CancelTransaction(c *gin.Context) {
 	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
 	var transaction models.Transaction
-	if err := database.DB.Preload("Items").First(&transaction, id).Error; err != nil {
+	if err := tx.Preload("Items").First(&transaction, id).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
 
-	if transaction.Status != "completed" {
+	// Klaim pembatalan secara kondisional agar stok, emas murni, poin dan member tidak dibalik dua kali
+	claim := tx.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", transaction.ID, "completed").
+		Update("status", "cancelled")
+	if claim.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": claim.Error.Error()})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		tx.Rollback()
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Only completed transactions can be cancelled"})
 		return
 	}
-
-	tx := database.DB.Begin()
+	transaction.Status = "cancelled"
 
 	// If sale transaction, restore stock status
 	if transaction.Type == models.TransactionTypeSale {
@@ -567,22 +959,96 @@ func CancelTransaction(c *gin.Context) {
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 					return
 				}
+
+				// Barang kembali ke lokasi
+				var stock models.Stock
+				if err := tx.First(&stock, *item.StockID).Error; err == nil {
+					if err := recordStockFineGold(tx, stock, models.FineGoldMovementSale, 1, "transaction", transaction.ID, transaction.TransactionCode, &currentUserID); err != nil {
+						tx.Rollback()
+						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+						return
+					}
+				}
 			}
 		}
 	}
 
-	// Update transaction status
-	transaction.Status = "cancelled"
-	if err := tx.Save(&transaction).Error; err != nil {
+	// Setor dibatalkan: barang yang diterima keluar lagi, selama belum dipakai
+	if transaction.Type == models.TransactionTypePurchase {
+		if err := reversePurchaseGoods(tx, transaction, currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Poin yang didapat dan ditukar di transaksi ini dikembalikan
+	if err := reverseTransactionPoints(tx, transaction, currentUserID); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Total belanja/setor dan tier member dikurangi transaksi yang dibatalkan
+	if transaction.MemberID != nil {
+		if err := reverseMemberTransaction(tx, *transaction.MemberID, transaction); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{"data": transaction})
 }
 
+// reversePurchaseGoods removes the raw materials and restocked buyback pieces a setor created and books their fine gold out.
+// Barang yang sudah dilebur, dijual atau dipakai di draft lebur/produksi membuat pembatalan ditolak.
+func reversePurchaseGoods(tx *gorm.DB, transaction models.Transaction, userID uint) error {
+	var rawMaterials []models.RawMaterial
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_id = ?", transaction.ID).Find(&rawMaterials).Error; err != nil {
+		return err
+	}
+	for _, rm := range rawMaterials {
+		if rm.Status != models.RawMaterialStatusAvailable {
+			return fmt.Errorf("Raw material %s from this setor is already %s", rm.Code, rm.Status)
+		}
+		if batchNumber := meltBatchOfRawMaterial(tx, rm.ID); batchNumber != "" {
+			return fmt.Errorf("Raw material %s from this setor is in melt batch %s", rm.Code, batchNumber)
+		}
+		if orderNumber := productionOrderOfInput(tx, "raw_material_id", rm.ID); orderNumber != "" {
+			return fmt.Errorf("Raw material %s from this setor is used by production order %s", rm.Code, orderNumber)
+		}
+		if err := tx.Delete(&rm).Error; err != nil {
+			return err
+		}
+		if err := recordRawMaterialFineGold(tx, rm, models.FineGoldMovementSetor, -1, "transaction", transaction.ID, transaction.TransactionCode, &userID); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range transaction.Items {
+		if item.RestockedStockID == nil {
+			continue
+		}
+		var stock models.Stock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stock, *item.RestockedStockID).Error; err != nil {
+			return fmt.Errorf("Restocked stock %d not found", *item.RestockedStockID)
+		}
+		if stock.Status != models.StockStatusAvailable {
+			return fmt.Errorf("Restocked stock %s from this setor is already %s", stock.SerialNumber, stock.Status)
+		}
+		if err := tx.Delete(&stock).Error; err != nil {
+			return err
+		}
+		if err := recordStockFineGold(tx, stock, models.FineGoldMovementSetor, -1, "transaction", transaction.ID, transaction.TransactionCode, &userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetDailySummary returns daily sales summary
 func GetDailySummary(c *gin.Context) {
 	date := c.Query("date")
@@ -0,0 +1,451 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"starter/backend/database"
+	"starter/backend/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetWriteOffs returns write-off documents
+func GetWriteOffs(c *gin.Context) {
+	var writeOffs []models.StockWriteOff
+	query := database.DB.Preload("Stock").Preload("Stock.Product").Preload("Location").
+		Preload("RequestedBy").Preload("ApprovedBy")
+
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if locationID := c.Query("location_id"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("created_at >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("created_at <= ?", endDate+" 23:59:59")
+	}
+
+	if err := query.Order("created_at DESC").Find(&writeOffs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Ringkasan nilai yang sudah disetujui
+	var totalWeight, totalCost, totalCurrent float64
+	for _, w := range writeOffs {
+		if w.Status == models.WriteOffStatusApproved {
+			totalWeight += w.Weight
+			totalCost += w.CostValue
+			totalCurrent += w.CurrentValue
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": writeOffs,
+		"summary": gin.H{
+			"total_items":         len(writeOffs),
+			"approved_weight":     totalWeight,
+			"approved_cost_value": totalCost,
+			"approved_sell_value": totalCurrent,
+		},
+	})
+}
+
+// GetWriteOff returns a single write-off document
+func GetWriteOff(c *gin.Context) {
+	id := c.Param("id")
+	var writeOff models.StockWriteOff
+	if err := database.DB.Preload("Stock").Preload("Stock.Product").Preload("Stock.Product.GoldCategory").
+		Preload("Stock.StorageBox").Preload("Location").Preload("RawMaterial").
+		Preload("RequestedBy").Preload("ApprovedBy").
+		First(&writeOff, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Write-off not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": writeOff})
+}
+
+type CreateWriteOffRequest struct {
+	StockID              uint     `json:"stock_id" binding:"required"`
+	Reason               string   `json:"reason" binding:"required"`
+	Description          string   `json:"description" binding:"required"`
+	Photos               []string `json:"photos"` // Tidak diterima; foto hanya lewat endpoint upload
+	ConvertToRawMaterial bool     `json:"convert_to_raw_material"`
+}
+
+// CreateWriteOff submits a write-off for approval and holds the stock piece
+func CreateWriteOff(c *gin.Context) {
+	var req CreateWriteOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reason := models.WriteOffReason(req.Reason)
+	if !reason.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reason. Allowed: lost, stolen, damaged, melt"})
+		return
+	}
+	// URL dari klien tidak bisa dipercaya sebagai bukti; foto harus diunggah agar isinya diperiksa
+	if len(req.Photos) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photos cannot be given as URLs, upload them to the write-off after it is created"})
+		return
+	}
+	if req.ConvertToRawMaterial && !reason.CanConvertToRawMaterial() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only damaged or melted pieces can be converted to raw material"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	var stock models.Stock
+	if err := tx.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").First(&stock, req.StockID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+
+	if !IsAdmin(currentUserID) && !CheckUserLocationAccess(currentUserID, stock.LocationID) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "Anda tidak memiliki akses ke lokasi ini"})
+		return
+	}
+
+	if stock.Status != models.StockStatusAvailable && stock.Status != models.StockStatusReserved {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Cannot write off a stock with status %s", stock.Status)})
+		return
+	}
+
+	if held, ok := stockQuotation(tx, stock.ID, 0); ok {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Stock is reserved by quotation %s, cancel the quotation first", held.QuotationNumber)})
+		return
+	}
+
+	var pendingCount int64
+	tx.Model(&models.StockWriteOff{}).Where("stock_id = ? AND status = ?", stock.ID, models.WriteOffStatusPending).Count(&pendingCount)
+	if pendingCount > 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This stock already has a pending write-off"})
+		return
+	}
+
+	writeOff := models.StockWriteOff{
+		WriteOffNumber:       generateTransactionCode("WO"),
+		StockID:              stock.ID,
+		LocationID:           stock.LocationID,
+		Reason:               reason,
+		Status:               models.WriteOffStatusPending,
+		Description:          req.Description,
+		Photos:               []string{},
+		PreviousStatus:       stock.Status,
+		ConvertToRawMaterial: req.ConvertToRawMaterial,
+		RequestedByID:        currentUserID,
+	}
+	valueWriteOff(&writeOff, stock)
+
+	if err := tx.Create(&writeOff).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tahan stok agar tidak terjual selama menunggu persetujuan
+	if err := tx.Model(&stock).Update("status", models.StockStatusReserved).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Stock").Preload("Stock.Product").Preload("Location").Preload("RequestedBy").First(&writeOff, writeOff.ID)
+	c.JSON(http.StatusCreated, gin.H{"data": writeOff})
+}
+
+// UploadWriteOffPhoto attaches a photo to a pending write-off
+func UploadWriteOffPhoto(c *gin.Context) {
+	id := c.Param("id")
+	var writeOff models.StockWriteOff
+	if err := database.DB.First(&writeOff, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Write-off not found"})
+		return
+	}
+
+	if writeOff.Status != models.WriteOffStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photos can only be added to pending write-offs"})
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	// Isi file diperiksa (magic bytes dan decode), bukan nama file
+	img, err := processImage(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uploadDir := "./uploads/write-offs"
+	if err := os.MkdirAll(uploadDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%d%s", writeOff.WriteOffNumber, time.Now().UnixNano(), img.format.ext)
+	if err := os.WriteFile(filepath.Join(uploadDir, filename), img.data, 0644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	// Dibaca ulang dengan kunci agar upload bersamaan tidak saling menimpa daftar foto
+	tx := database.DB.Begin()
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&writeOff, writeOff.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Write-off not found"})
+		return
+	}
+	if writeOff.Status != models.WriteOffStatusPending {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photos can only be added to pending write-offs"})
+		return
+	}
+	writeOff.Photos = append(writeOff.Photos, "/uploads/write-offs/"+filename)
+	if err := tx.Model(&writeOff).Update("photos", writeOff.Photos).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"data": writeOff})
+}
+
+// ApproveWriteOff approves a write-off, removes the piece from inventory and optionally creates bahan baku
+func ApproveWriteOff(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	// Kunci baris agar persetujuan/penolakan bersamaan tidak diproses dua kali
+	var writeOff models.StockWriteOff
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&writeOff, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Write-off not found"})
+		return
+	}
+
+	if writeOff.Status != models.WriteOffStatusPending {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending write-offs can be approved"})
+		return
+	}
+
+	// Pengaju tidak boleh menyetujui sendiri, kecuali admin
+	if writeOff.RequestedByID == currentUserID && !IsAdmin(currentUserID) {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "A write-off must be approved by someone other than the requester"})
+		return
+	}
+
+	if writeOff.Reason == models.WriteOffReasonDamaged && len(writeOff.Photos) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A photo of the damaged piece is required before approval"})
+		return
+	}
+
+	var stock models.Stock
+	if err := tx.Preload("Product").Preload("Product.GoldCategory").Preload("Product.Stones").Preload("Stones").First(&stock, writeOff.StockID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stock not found"})
+		return
+	}
+
+	// Nilai diambil ulang dengan harga saat persetujuan
+	valueWriteOff(&writeOff, stock)
+
+	now := time.Now()
+	writeOff.Status = models.WriteOffStatusApproved
+	writeOff.ApprovedByID = &currentUserID
+	writeOff.ApprovedAt = &now
+
+	if err := recordStockFineGold(tx, stock, models.FineGoldMovementWriteOff, -1, "write_off", writeOff.ID, writeOff.WriteOffNumber, &currentUserID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if writeOff.ConvertToRawMaterial {
+		rawMaterial, err := writeOffToRawMaterial(tx, writeOff, stock, currentUserID)
+		if err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create raw material: " + err.Error()})
+			return
+		}
+		writeOff.RawMaterialID = &rawMaterial.ID
+
+		// Emas murninya tetap di lokasi sebagai bahan baku
+		if err := recordRawMaterialFineGold(tx, rawMaterial, models.FineGoldMovementWriteOff, 1, "write_off", writeOff.ID, writeOff.WriteOffNumber, &currentUserID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Save(&writeOff).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Model(&stock).Update("status", models.StockStatusWrittenOff).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Preload("Stock").Preload("Stock.Product").Preload("Location").Preload("RawMaterial").
+		Preload("RequestedBy").Preload("ApprovedBy").First(&writeOff, writeOff.ID)
+	c.JSON(http.StatusOK, gin.H{"data": writeOff})
+}
+
+type RejectWriteOffRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RejectWriteOff rejects a pending write-off and releases the stock piece
+func RejectWriteOff(c *gin.Context) {
+	id := c.Param("id")
+	var req RejectWriteOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	currentUserID := userID.(uint)
+
+	tx := database.DB.Begin()
+
+	// Kunci baris agar persetujuan/penolakan bersamaan tidak diproses dua kali
+	var writeOff models.StockWriteOff
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&writeOff, id).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Write-off not found"})
+		return
+	}
+
+	if writeOff.Status != models.WriteOffStatusPending {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending write-offs can be rejected"})
+		return
+	}
+
+	now := time.Now()
+	writeOff.Status = models.WriteOffStatusRejected
+	writeOff.RejectReason = req.Reason
+	writeOff.ApprovedByID = &currentUserID
+	writeOff.ApprovedAt = &now
+
+	if err := tx.Save(&writeOff).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	previousStatus := writeOff.PreviousStatus
+	if previousStatus == "" {
+		previousStatus = models.StockStatusAvailable
+	}
+	if err := tx.Model(&models.Stock{}).Where("id = ?", writeOff.StockID).Update("status", previousStatus).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"data": writeOff})
+}
+
+// valueWriteOff fills the weight and the cost / current values of the piece
+func valueWriteOff(writeOff *models.StockWriteOff, stock models.Stock) {
+	loadLocationPrices(database.DB, stock.LocationID).applyToStock(&stock)
+	weight := stock.EffectiveWeight()
+	writeOff.Weight = weight
+	writeOff.BuyValue = stock.BuyPrice()
+	writeOff.CurrentValue = stock.SellPrice().Total
+
+	// Harga modal dari penerimaan barang, fallback ke harga beli hari ini
+	writeOff.CostValue = stock.CostPrice
+	if writeOff.CostValue <= 0 {
+		writeOff.CostValue = writeOff.BuyValue
+	}
+}
+
+// writeOffToRawMaterial turns a damaged or melted piece into a raw material entry at its cost value
+func writeOffToRawMaterial(tx *gorm.DB, writeOff models.StockWriteOff, stock models.Stock, userID uint) (models.RawMaterial, error) {
+	weight := writeOff.Weight
+	weightGross := stock.GrossWeight
+	if weightGross < weight {
+		weightGross = weight
+	}
+
+	var purity float64
+	if stock.Product.GoldCategory.Purity != nil {
+		purity = *stock.Product.GoldCategory.Purity * 100
+	}
+
+	var pricePerGram float64
+	if weight > 0 {
+		pricePerGram = writeOff.CostValue / weight
+	}
+
+	now := time.Now()
+	goldCategoryID := stock.Product.GoldCategoryID
+	rawMaterial := models.RawMaterial{
+		Code:            GenerateRawMaterialCode(),
+		GoldCategoryID:  &goldCategoryID,
+		LocationID:      stock.LocationID,
+		WeightGross:     weightGross,
+		WeightGrams:     weight,
+		Purity:          purity,
+		BuyPricePerGram: pricePerGram,
+		TotalBuyPrice:   writeOff.CostValue,
+		Condition:       models.RawMaterialConditionDamaged,
+		Status:          models.RawMaterialStatusAvailable,
+		SourceStockID:   &stock.ID,
+		ReceivedAt:      &now,
+		ReceivedByID:    &userID,
+		WeightSource:    stock.WeightSource,
+		Notes:           fmt.Sprintf("Write-off %s (%s) - %s", writeOff.WriteOffNumber, stock.SerialNumber, stock.Product.Name),
+	}
+	if rawMaterial.WeightSource == "" {
+		rawMaterial.WeightSource = models.WeightSourceTyped
+	}
+
+	err := tx.Create(&rawMaterial).Error
+	return rawMaterial, err
+}
@@ -0,0 +1,152 @@
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// Symbology is the barcode type printed on a label
+type Symbology string
+
+const (
+	SymbologyCode128 Symbology = "code128"
+	SymbologyQR      Symbology = "qr"
+)
+
+// Template describes the size and layout of one label and of the sheet it is printed on.
+// All sizes are in millimetres.
+type Template struct {
+	WidthMM      float64   `json:"width_mm"`
+	HeightMM     float64   `json:"height_mm"`
+	PageWidthMM  float64   `json:"page_width_mm"`  // 0 = satu label per halaman (printer roll)
+	PageHeightMM float64   `json:"page_height_mm"` // 0 = satu label per halaman (printer roll)
+	Columns      int       `json:"columns"`
+	Rows         int       `json:"rows"`
+	MarginLeftMM float64   `json:"margin_left_mm"`
+	MarginTopMM  float64   `json:"margin_top_mm"`
+	GapXMM       float64   `json:"gap_x_mm"`
+	GapYMM       float64   `json:"gap_y_mm"`
+	PaddingMM    float64   `json:"padding_mm"`
+	Symbology    Symbology `json:"symbology"`
+	DPI          int       `json:"dpi"`          // Resolusi printer (PNG dan ZPL), default 203
+	FontSizePt   float64   `json:"font_size_pt"` // Ukuran huruf teks (PDF), default 5
+}
+
+// Label is the content of a single label
+type Label struct {
+	Code  string   // Isi barcode (nomor seri)
+	Lines []string // Teks di samping/bawah barcode
+}
+
+var ErrInvalidTemplate = errors.New("invalid label template")
+
+// Validate checks the template and fills in defaults
+func (t *Template) Validate() error {
+	if t.WidthMM <= 0 || t.HeightMM <= 0 {
+		return fmt.Errorf("%w: width and height are required", ErrInvalidTemplate)
+	}
+	if t.Symbology == "" {
+		t.Symbology = SymbologyCode128
+	}
+	if t.Symbology != SymbologyCode128 && t.Symbology != SymbologyQR {
+		return fmt.Errorf("%w: unknown symbology %s", ErrInvalidTemplate, t.Symbology)
+	}
+	if t.DPI <= 0 {
+		t.DPI = 203
+	}
+	if t.FontSizePt <= 0 {
+		t.FontSizePt = 5
+	}
+	if t.Columns <= 0 {
+		t.Columns = 1
+	}
+	if t.Rows <= 0 {
+		t.Rows = 1
+	}
+	if t.PageWidthMM > 0 && t.PageHeightMM > 0 {
+		usedWidth := t.MarginLeftMM + float64(t.Columns)*t.WidthMM + float64(t.Columns-1)*t.GapXMM
+		usedHeight := t.MarginTopMM + float64(t.Rows)*t.HeightMM + float64(t.Rows-1)*t.GapYMM
+		if usedWidth > t.PageWidthMM+0.01 || usedHeight > t.PageHeightMM+0.01 {
+			return fmt.Errorf("%w: %dx%d labels do not fit on the page", ErrInvalidTemplate, t.Columns, t.Rows)
+		}
+	}
+	return nil
+}
+
+// PerPage returns how many labels fit on one page
+func (t *Template) PerPage() int {
+	if t.PageWidthMM <= 0 || t.PageHeightMM <= 0 {
+		return 1
+	}
+	return t.Columns * t.Rows
+}
+
+// dots converts millimetres to printer dots at the template DPI
+func (t *Template) dots(mm float64) int {
+	return int(mm*float64(t.DPI)/25.4 + 0.5)
+}
+
+// layout splits a label into the barcode box and the text box (all in mm, relative to the label)
+type box struct {
+	X, Y, W, H float64
+}
+
+func (t *Template) layout() (barcodeBox, textBox box) {
+	p := t.PaddingMM
+	innerW := t.WidthMM - 2*p
+	innerH := t.HeightMM - 2*p
+
+	if t.Symbology == SymbologyQR {
+		// QR persegi di kiri, teks di kanan
+		size := innerH
+		if size > innerW/2 {
+			size = innerW / 2
+		}
+		return box{p, p, size, size}, box{p + size + 1, p, innerW - size - 1, innerH}
+	}
+
+	// Code128 di atas, teks di bawah
+	barcodeH := innerH * 0.55
+	return box{p, p, innerW, barcodeH}, box{p, p + barcodeH + 0.5, innerW, innerH - barcodeH - 0.5}
+}
+
+// encode renders the barcode symbol scaled to the given pixel size
+func (t *Template) encode(code string, widthPx, heightPx int) (image.Image, error) {
+	var symbol barcode.Barcode
+	var err error
+	switch t.Symbology {
+	case SymbologyQR:
+		symbol, err = qr.Encode(code, qr.M, qr.Auto)
+	default:
+		symbol, err = code128.Encode(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Barcode tidak boleh diperkecil di bawah ukuran modulnya
+	bounds := symbol.Bounds()
+	if widthPx < bounds.Dx() {
+		widthPx = bounds.Dx()
+	}
+	if heightPx < bounds.Dy() {
+		heightPx = bounds.Dy()
+	}
+	if t.Symbology == SymbologyCode128 {
+		// Lebar harus kelipatan lebar modul agar garis tidak pecah
+		widthPx = widthPx / bounds.Dx() * bounds.Dx()
+	} else {
+		size := widthPx
+		if heightPx < size {
+			size = heightPx
+		}
+		size = size / bounds.Dx() * bounds.Dx()
+		widthPx, heightPx = size, size
+	}
+	return barcode.Scale(symbol, widthPx, heightPx)
+}
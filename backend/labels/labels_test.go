@@ -0,0 +1,140 @@
+package labels
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestTemplateValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template Template
+		wantErr  bool
+	}{
+		{"roll label", Template{WidthMM: 40, HeightMM: 20}, false},
+		{"sheet fits", Template{WidthMM: 40, HeightMM: 20, PageWidthMM: 210, PageHeightMM: 297, Columns: 5, Rows: 14}, false},
+		{"sheet too small", Template{WidthMM: 40, HeightMM: 20, PageWidthMM: 100, PageHeightMM: 100, Columns: 3, Rows: 1}, true},
+		{"gaps overflow", Template{WidthMM: 50, HeightMM: 20, PageWidthMM: 100, PageHeightMM: 40, Columns: 2, Rows: 1, GapXMM: 1}, true},
+		{"missing width", Template{HeightMM: 20}, true},
+		{"negative height", Template{WidthMM: 40, HeightMM: -1}, true},
+		{"unknown symbology", Template{WidthMM: 40, HeightMM: 20, Symbology: "ean13"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.template.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTemplate) {
+				t.Errorf("error %v is not ErrInvalidTemplate", err)
+			}
+		})
+	}
+}
+
+func TestTemplateDefaults(t *testing.T) {
+	template := Template{WidthMM: 40, HeightMM: 20}
+	if err := template.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if template.Symbology != SymbologyCode128 || template.DPI != 203 || template.FontSizePt != 5 ||
+		template.Columns != 1 || template.Rows != 1 {
+		t.Errorf("defaults = %+v", template)
+	}
+	if template.PerPage() != 1 {
+		t.Errorf("PerPage() = %d for a roll, want 1", template.PerPage())
+	}
+
+	sheet := Template{WidthMM: 40, HeightMM: 20, PageWidthMM: 210, PageHeightMM: 297, Columns: 5, Rows: 14}
+	if sheet.PerPage() != 70 {
+		t.Errorf("PerPage() = %d, want 70", sheet.PerPage())
+	}
+}
+
+func TestRenderZPL(t *testing.T) {
+	template := Template{WidthMM: 40, HeightMM: 20, PaddingMM: 1}
+	items := []Label{
+		{Code: "SN0001", Lines: []string{"Cincin 24K", "3,25 g"}},
+		{Code: "SN^0002", Lines: []string{"Kalung ~ 18K"}},
+	}
+
+	output, err := RenderZPL(template, items)
+	if err != nil {
+		t.Fatalf("RenderZPL: %v", err)
+	}
+	if got := strings.Count(output, "^XA"); got != 2 {
+		t.Errorf("%d label blocks, want 2", got)
+	}
+	if strings.Count(output, "^XZ") != 2 {
+		t.Error("label blocks are not closed")
+	}
+	if !strings.Contains(output, "^PW320\n^LL160") {
+		t.Errorf("label size at 203 dpi missing in %q", output)
+	}
+	if !strings.Contains(output, "^BCN") || !strings.Contains(output, "^FDSN0001^FS") {
+		t.Errorf("code128 barcode missing in %q", output)
+	}
+	// Karakter kontrol ZPL di data tidak boleh memutus perintah
+	if strings.Contains(output, "SN^0002") || !strings.Contains(output, "^FDSN 0002^FS") || strings.Contains(output, "Kalung ~") {
+		t.Errorf("control characters not escaped in %q", output)
+	}
+	if !strings.Contains(output, "^FDCincin 24K^FS") {
+		t.Errorf("text line missing in %q", output)
+	}
+
+	template.Symbology = SymbologyQR
+	output, err = RenderZPL(template, items[:1])
+	if err != nil {
+		t.Fatalf("RenderZPL QR: %v", err)
+	}
+	if !strings.Contains(output, "^BQN,2,") || !strings.Contains(output, "^FDMA,SN0001^FS") {
+		t.Errorf("QR barcode missing in %q", output)
+	}
+
+	if _, err := RenderZPL(Template{}, items); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("invalid template error = %v", err)
+	}
+}
+
+func TestRenderPNG(t *testing.T) {
+	for _, symbology := range []Symbology{SymbologyCode128, SymbologyQR} {
+		template := Template{WidthMM: 40, HeightMM: 20, PaddingMM: 1, Symbology: symbology}
+		output, err := RenderPNG(template, Label{Code: "SN0001", Lines: []string{"Cincin 24K"}})
+		if err != nil {
+			t.Fatalf("%s: RenderPNG: %v", symbology, err)
+		}
+		img, err := png.Decode(bytes.NewReader(output))
+		if err != nil {
+			t.Fatalf("%s: output is not a PNG: %v", symbology, err)
+		}
+		if size := img.Bounds().Size(); size.X != 320 || size.Y != 160 {
+			t.Errorf("%s: image is %v, want 320x160 at 203 dpi", symbology, size)
+		}
+	}
+
+	if _, err := RenderPNG(Template{WidthMM: 40}, Label{Code: "SN0001"}); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("invalid template error = %v", err)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	template := Template{WidthMM: 40, HeightMM: 20, PageWidthMM: 210, PageHeightMM: 297, Columns: 5, Rows: 14, MarginLeftMM: 5, MarginTopMM: 5}
+	var items []Label
+	for i := 0; i < 75; i++ {
+		items = append(items, Label{Code: "SN0001", Lines: []string{"Cincin 24K"}})
+	}
+	output, err := RenderPDF(template, items)
+	if err != nil {
+		t.Fatalf("RenderPDF: %v", err)
+	}
+	if !bytes.HasPrefix(output, []byte("%PDF")) {
+		t.Errorf("output does not start with a PDF header")
+	}
+	// 75 label dengan 70 per lembar = 2 halaman
+	if pages := bytes.Count(output, []byte("/Type /Page\n")); pages != 2 {
+		t.Errorf("%d pages, want 2", pages)
+	}
+}
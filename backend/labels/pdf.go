@@ -0,0 +1,95 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// RenderPDF lays the labels out on sheets (multi-up) for tag paper or on single-label pages for roll printers
+func RenderPDF(t Template, items []Label) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	pageW, pageH := t.PageWidthMM, t.PageHeightMM
+	if pageW <= 0 || pageH <= 0 {
+		pageW, pageH = t.WidthMM, t.HeightMM
+	}
+	orientation := "P"
+	if pageW > pageH {
+		orientation = "L"
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: orientation,
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: pageW, Ht: pageH},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFont("Helvetica", "", t.FontSizePt)
+
+	barcodeBox, textBox := t.layout()
+	lineHeight := t.FontSizePt * 0.3528 * 1.15 // pt ke mm, dengan spasi baris
+	perPage := t.PerPage()
+
+	for i, label := range items {
+		slot := i % perPage
+		if slot == 0 {
+			pdf.AddPage()
+		}
+
+		x, y := 0.0, 0.0
+		if perPage > 1 {
+			col := slot % t.Columns
+			row := slot / t.Columns
+			x = t.MarginLeftMM + float64(col)*(t.WidthMM+t.GapXMM)
+			y = t.MarginTopMM + float64(row)*(t.HeightMM+t.GapYMM)
+		}
+
+		symbol, err := t.encode(label.Code, t.dots(barcodeBox.W), t.dots(barcodeBox.H))
+		if err != nil {
+			return nil, fmt.Errorf("label %s: %w", label.Code, err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, symbol); err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("barcode-%d", i)
+		options := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader(name, options, &buf)
+
+		// Pertahankan rasio barcode di dalam kotaknya
+		bounds := symbol.Bounds()
+		w := barcodeBox.W
+		h := w * float64(bounds.Dy()) / float64(bounds.Dx())
+		if h > barcodeBox.H {
+			h = barcodeBox.H
+			w = h * float64(bounds.Dx()) / float64(bounds.Dy())
+		}
+		pdf.ImageOptions(name, x+barcodeBox.X, y+barcodeBox.Y, w, h, false, options, 0, "")
+
+		textY := y + textBox.Y + lineHeight
+		for _, line := range label.Lines {
+			if textY > y+textBox.Y+textBox.H+0.01 {
+				break
+			}
+			pdf.Text(x+textBox.X, textY, pdf.UnicodeTranslatorFromDescriptor("")(line))
+			textY += lineHeight
+		}
+	}
+
+	if len(items) == 0 {
+		pdf.AddPage()
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
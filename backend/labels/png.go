@@ -0,0 +1,51 @@
+package labels
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// RenderPNG renders a single label as a PNG image at the template DPI
+func RenderPNG(t Template, label Label) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, t.dots(t.WidthMM), t.dots(t.HeightMM)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	barcodeBox, textBox := t.layout()
+	symbol, err := t.encode(label.Code, t.dots(barcodeBox.W), t.dots(barcodeBox.H))
+	if err != nil {
+		return nil, err
+	}
+	origin := image.Pt(t.dots(barcodeBox.X), t.dots(barcodeBox.Y))
+	draw.Draw(img, symbol.Bounds().Add(origin), symbol, symbol.Bounds().Min, draw.Src)
+
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(color.Black), Face: face}
+	lineHeight := face.Metrics().Height.Ceil()
+	y := t.dots(textBox.Y) + face.Metrics().Ascent.Ceil()
+	maxY := t.dots(textBox.Y + textBox.H)
+	for _, line := range label.Lines {
+		if y > maxY {
+			break
+		}
+		drawer.Dot = fixed.P(t.dots(textBox.X), y)
+		drawer.DrawString(line)
+		y += lineHeight
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package labels
+
+import (
+	"fmt"
+	"strings"
+)
+
+// zplEscaper removes the ZPL control characters from field data
+var zplEscaper = strings.NewReplacer("^", " ", "~", " ")
+
+// RenderZPL renders the labels as ZPL II for Zebra-compatible thermal printers, one ^XA..^XZ block per label
+func RenderZPL(t Template, items []Label) (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	barcodeBox, textBox := t.layout()
+	fontDots := t.dots(t.FontSizePt * 0.3528)
+	if fontDots < 10 {
+		fontDots = 10
+	}
+
+	var b strings.Builder
+	for _, label := range items {
+		code := zplEscaper.Replace(label.Code)
+
+		b.WriteString("^XA\n^CI28\n")
+		fmt.Fprintf(&b, "^PW%d\n^LL%d\n", t.dots(t.WidthMM), t.dots(t.HeightMM))
+
+		fmt.Fprintf(&b, "^FO%d,%d", t.dots(barcodeBox.X), t.dots(barcodeBox.Y))
+		if t.Symbology == SymbologyQR {
+			// Perbesaran QR: ukur dari tinggi kotak, QR versi kecil ~25 modul
+			magnification := t.dots(barcodeBox.H) / 25
+			if magnification < 1 {
+				magnification = 1
+			}
+			if magnification > 10 {
+				magnification = 10
+			}
+			fmt.Fprintf(&b, "^BQN,2,%d^FDMA,%s^FS\n", magnification, code)
+		} else {
+			// Code128: ~11 modul per karakter + start/stop/checksum
+			modules := (len(code)+3)*11 + 2
+			moduleWidth := t.dots(barcodeBox.W) / modules
+			if moduleWidth < 1 {
+				moduleWidth = 1
+			}
+			if moduleWidth > 10 {
+				moduleWidth = 10
+			}
+			fmt.Fprintf(&b, "^BY%d^BCN,%d,N,N,N^FD%s^FS\n", moduleWidth, t.dots(barcodeBox.H), code)
+		}
+
+		y := t.dots(textBox.Y)
+		maxY := t.dots(textBox.Y + textBox.H)
+		for _, line := range label.Lines {
+			if y+fontDots > maxY {
+				break
+			}
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", t.dots(textBox.X), y, fontDots, fontDots, zplEscaper.Replace(line))
+			y += fontDots + 2
+		}
+
+		b.WriteString("^XZ\n")
+	}
+	return b.String(), nil
+}
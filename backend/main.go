@@ -4,8 +4,14 @@ import (
 	"log"
 	"starter/backend/config"
 	"starter/backend/database"
+	"starter/backend/filestore"
 	"starter/backend/handlers"
 	"starter/backend/middleware"
+	"starter/backend/pricefeed"
+	"starter/backend/scale"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -34,6 +40,119 @@ func main() {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Connect server-attached scale (optional)
+	scaleDriver, err := scale.New(cfg.ScaleDriver, cfg.ScaleDevice, cfg.ScaleRequestCommand)
+	if err != nil {
+		log.Fatal("Failed to configure scale:", err)
+	}
+	if scaleDriver != nil {
+		handlers.SetScaleDriver(scaleDriver)
+		defer scaleDriver.Close()
+	}
+
+	// File store for product and stock photos
+	imageStore, err := filestore.New(cfg.ImageStoreDriver, cfg.ImageStoreDir, cfg.ImageBaseURL)
+	if err != nil {
+		log.Fatal("Failed to configure image store:", err)
+	}
+	handlers.SetImageStore(imageStore)
+
+	// External gold price feed (optional)
+	feed, err := pricefeed.New(cfg.PriceFeedProvider, cfg.PriceFeedSource, pricefeed.Fields{Buy: cfg.PriceFeedBuyField, Sell: cfg.PriceFeedSellField})
+	if err != nil {
+		log.Fatal("Failed to configure price feed:", err)
+	}
+	if feed != nil {
+		maxChange, err := strconv.ParseFloat(cfg.PriceFeedMaxChangePercent, 64)
+		if err != nil || maxChange < 0 {
+			log.Fatal("Invalid PRICE_FEED_MAX_CHANGE_PERCENT:", cfg.PriceFeedMaxChangePercent)
+		}
+		handlers.SetPriceFeed(feed, handlers.PriceFeedSettings{
+			AutoApply:        cfg.PriceFeedAutoApply,
+			MaxChangePercent: maxChange,
+			Username:         cfg.PriceFeedUser,
+		})
+		if cfg.PriceFeedInterval != "" {
+			interval, err := time.ParseDuration(cfg.PriceFeedInterval)
+			if err != nil {
+				log.Fatal("Invalid PRICE_FEED_INTERVAL:", err)
+			}
+			handlers.StartPriceFeedJob(interval)
+		}
+	}
+
+	// Scheduled price updates worker
+	priceScheduleInterval, err := time.ParseDuration(cfg.PriceScheduleInterval)
+	if err != nil || priceScheduleInterval <= 0 {
+		log.Fatal("Invalid PRICE_SCHEDULE_INTERVAL:", cfg.PriceScheduleInterval)
+	}
+	handlers.StartScheduledPriceWorker(priceScheduleInterval)
+
+	// Setor price tolerance against the reference buy price
+	setorTolerance, err := strconv.ParseFloat(cfg.SetorPriceTolerance, 64)
+	if err != nil || setorTolerance < 0 {
+		log.Fatal("Invalid SETOR_PRICE_TOLERANCE_PERCENT:", cfg.SetorPriceTolerance)
+	}
+	setorApprovalLimit, err := strconv.ParseFloat(cfg.SetorPriceApprovalLimit, 64)
+	if err != nil || setorApprovalLimit < 0 {
+		log.Fatal("Invalid SETOR_PRICE_APPROVAL_LIMIT_PERCENT:", cfg.SetorPriceApprovalLimit)
+	}
+	handlers.SetSetorPricePolicy(handlers.SetorPricePolicy{
+		TolerancePercent:     setorTolerance,
+		ApprovalLimitPercent: setorApprovalLimit,
+	})
+
+	// Production yield loss accepted without supervisor approval
+	maxYieldLoss, err := strconv.ParseFloat(cfg.ProductionMaxYieldLoss, 64)
+	if err != nil || maxYieldLoss < 0 {
+		log.Fatal("Invalid PRODUCTION_MAX_YIELD_LOSS_PERCENT:", cfg.ProductionMaxYieldLoss)
+	}
+	handlers.SetMaxYieldLossPercent(maxYieldLoss)
+
+	// Quotations: default validity and expiry job
+	quotationValidity, err := time.ParseDuration(cfg.QuotationValidity)
+	if err != nil || quotationValidity <= 0 {
+		log.Fatal("Invalid QUOTATION_VALIDITY:", cfg.QuotationValidity)
+	}
+	quotationMaxValidity, err := time.ParseDuration(cfg.QuotationMaxValidity)
+	if err != nil || quotationMaxValidity < quotationValidity {
+		log.Fatal("Invalid QUOTATION_MAX_VALIDITY:", cfg.QuotationMaxValidity)
+	}
+	handlers.SetQuotationValidity(quotationValidity, quotationMaxValidity)
+	quotationExpiryInterval, err := time.ParseDuration(cfg.QuotationExpiryInterval)
+	if err != nil || quotationExpiryInterval <= 0 {
+		log.Fatal("Invalid QUOTATION_EXPIRY_INTERVAL:", cfg.QuotationExpiryInterval)
+	}
+	handlers.StartQuotationExpiryJob(quotationExpiryInterval)
+
+	// Member points: redemption value, expiry and expiry job
+	memberPointValue, err := strconv.ParseFloat(cfg.MemberPointValue, 64)
+	if err != nil || memberPointValue < 0 {
+		log.Fatal("Invalid MEMBER_POINT_VALUE:", cfg.MemberPointValue)
+	}
+	memberPointExpiryMonths, err := strconv.Atoi(cfg.MemberPointExpiryMonths)
+	if err != nil || memberPointExpiryMonths < 0 {
+		log.Fatal("Invalid MEMBER_POINT_EXPIRY_MONTHS:", cfg.MemberPointExpiryMonths)
+	}
+	handlers.SetMemberPointSettings(handlers.MemberPointSettings{
+		RedeemValue:  memberPointValue,
+		ExpiryMonths: memberPointExpiryMonths,
+	})
+	memberPointExpiryInterval, err := time.ParseDuration(cfg.MemberPointExpiryInterval)
+	if err != nil || memberPointExpiryInterval <= 0 {
+		log.Fatal("Invalid MEMBER_POINT_EXPIRY_INTERVAL:", cfg.MemberPointExpiryInterval)
+	}
+	handlers.StartMemberPointExpiryJob(memberPointExpiryInterval)
+
+	// Replenishment suggestions job (optional)
+	if cfg.ReplenishmentInterval != "" {
+		interval, err := time.ParseDuration(cfg.ReplenishmentInterval)
+		if err != nil {
+			log.Fatal("Invalid REPLENISHMENT_INTERVAL:", err)
+		}
+		handlers.StartReplenishmentJob(interval)
+	}
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -55,6 +174,9 @@ func main() {
 
 	// Serve static files for uploads
 	r.Static("/uploads", "./uploads")
+	if cfg.ImageStoreDriver == "local" && cfg.ImageBaseURL != "/uploads" && strings.HasPrefix(cfg.ImageBaseURL, "/") {
+		r.Static(cfg.ImageBaseURL, cfg.ImageStoreDir)
+	}
 
 	// Public routes
 	api := r.Group("/api")
@@ -112,6 +234,18 @@ func main() {
 			// Price Update routes (daily gold price update)
 			protected.GET("/price-update/check", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.CheckPriceUpdateNeeded)
 			protected.POST("/price-update/bulk", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.BulkUpdatePrices)
+			protected.POST("/price-update/derive/preview", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.PreviewDerivedPrices)
+			protected.POST("/price-update/derive", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.ApplyDerivedPrices)
+			protected.POST("/price-update/feed/fetch", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.FetchPriceFeed)
+			protected.GET("/price-update/proposals", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetPriceProposals)
+			protected.GET("/price-update/proposals/:id", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetPriceProposal)
+			protected.POST("/price-update/proposals/:id/approve", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.ApprovePriceProposal)
+			protected.POST("/price-update/proposals/:id/reject", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.RejectPriceProposal)
+			protected.GET("/price-update/scheduled", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetScheduledPriceUpdates)
+			protected.GET("/price-update/scheduled/:id", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetScheduledPriceUpdate)
+			protected.POST("/price-update/scheduled", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.CreateScheduledPriceUpdate)
+			protected.PUT("/price-update/scheduled/:id/cancel", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.CancelScheduledPriceUpdate)
+			protected.POST("/price-update/bullion", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.UpdateBullionPrices)
 			protected.GET("/price-update/logs", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetPriceUpdateLogs)
 			protected.GET("/price-update/logs/:id", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetPriceUpdateLog)
 
@@ -122,6 +256,16 @@ func main() {
 			protected.POST("/products", middleware.RequirePermission("products.create"), handlers.CreateProduct)
 			protected.PUT("/products/:id", middleware.RequirePermission("products.update"), handlers.UpdateProduct)
 			protected.DELETE("/products/:id", middleware.RequirePermission("products.delete"), handlers.DeleteProduct)
+			protected.POST("/products/:id/images", middleware.RequirePermission("products.update"), handlers.UploadProductImages)
+			protected.PUT("/products/:id/images/:image_id/primary", middleware.RequirePermission("products.update"), handlers.SetPrimaryProductImage)
+			protected.DELETE("/products/:id/images/:image_id", middleware.RequirePermission("products.update"), handlers.DeleteProductImage)
+
+			// Product types routes (master tipe produk dan skema atribut)
+			protected.GET("/product-types", middleware.RequireAnyPermission("products.view", "pos.view-products"), handlers.GetProductTypes)
+			protected.GET("/product-types/:id", middleware.RequireAnyPermission("products.view", "pos.view-products"), handlers.GetProductType)
+			protected.POST("/product-types", middleware.RequirePermission("product-types.manage"), handlers.CreateProductType)
+			protected.PUT("/product-types/:id", middleware.RequirePermission("product-types.manage"), handlers.UpdateProductType)
+			protected.DELETE("/product-types/:id", middleware.RequirePermission("product-types.manage"), handlers.DeleteProductType)
 
 			// Locations routes (Gudang & Toko)
 			protected.GET("/locations", middleware.RequireAnyPermission("locations.view", "pos.view-locations"), handlers.GetLocations)
@@ -129,35 +273,131 @@ func main() {
 			protected.POST("/locations", middleware.RequirePermission("locations.create"), handlers.CreateLocation)
 			protected.PUT("/locations/:id", middleware.RequirePermission("locations.update"), handlers.UpdateLocation)
 			protected.DELETE("/locations/:id", middleware.RequirePermission("locations.delete"), handlers.DeleteLocation)
+			protected.GET("/locations/:id/prices", middleware.RequireAnyPermission("gold-categories.view", "pos.view-gold-categories"), handlers.GetLocationPrices)
+			protected.PUT("/locations/:id/prices", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.SetLocationPrices)
+			protected.DELETE("/locations/:id/prices/:category_id", middleware.RequireAnyPermission("gold-categories.update", "pos.update-gold-prices"), handlers.DeleteLocationPrice)
 
 			// Storage Boxes routes
 			protected.GET("/storage-boxes", middleware.RequirePermission("locations.view"), handlers.GetStorageBoxes)
+			protected.GET("/storage-boxes/tree", middleware.RequireAnyPermission("locations.view", "pos.view-locations"), handlers.GetStorageBoxTree)
 			protected.GET("/storage-boxes/:id", middleware.RequirePermission("locations.view"), handlers.GetStorageBox)
+			protected.GET("/storage-boxes/:id/summary", middleware.RequireAnyPermission("locations.view", "stocks.view"), handlers.GetStorageBoxSummary)
 			protected.POST("/storage-boxes", middleware.RequirePermission("locations.create"), handlers.CreateStorageBox)
 			protected.PUT("/storage-boxes/:id", middleware.RequirePermission("locations.update"), handlers.UpdateStorageBox)
 			protected.DELETE("/storage-boxes/:id", middleware.RequirePermission("locations.delete"), handlers.DeleteStorageBox)
 
+			// Suppliers routes
+			protected.GET("/suppliers", middleware.RequireAnyPermission("suppliers.view", "goods-receipts.view"), handlers.GetSuppliers)
+			protected.GET("/suppliers/:id", middleware.RequireAnyPermission("suppliers.view", "goods-receipts.view"), handlers.GetSupplier)
+			protected.POST("/suppliers", middleware.RequirePermission("suppliers.create"), handlers.CreateSupplier)
+			protected.PUT("/suppliers/:id", middleware.RequirePermission("suppliers.update"), handlers.UpdateSupplier)
+			protected.DELETE("/suppliers/:id", middleware.RequirePermission("suppliers.delete"), handlers.DeleteSupplier)
+
+			// Goods Receipts routes (Penerimaan Barang)
+			protected.GET("/goods-receipts", middleware.RequirePermission("goods-receipts.view"), handlers.GetGoodsReceipts)
+			protected.GET("/goods-receipts/:id", middleware.RequirePermission("goods-receipts.view"), handlers.GetGoodsReceipt)
+			protected.POST("/goods-receipts", middleware.RequirePermission("goods-receipts.create"), handlers.CreateGoodsReceipt)
+			protected.POST("/goods-receipts/:id/post", middleware.RequirePermission("goods-receipts.post"), handlers.PostGoodsReceipt)
+			protected.PUT("/goods-receipts/:id/cancel", middleware.RequirePermission("goods-receipts.cancel"), handlers.CancelGoodsReceipt)
+
 			// Members routes
 			protected.GET("/members", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMembers)
+			protected.GET("/members/duplicates", middleware.RequirePermission("members.view"), handlers.GetDuplicateMembers)
+			protected.GET("/members/merges", middleware.RequirePermission("members.view"), handlers.GetMemberMerges)
 			protected.GET("/members/:id", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMember)
 			protected.GET("/members/code/:code", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMemberByCode)
 			protected.POST("/members", middleware.RequireAnyPermission("members.create", "pos.create-members"), handlers.CreateMember)
 			protected.PUT("/members/:id", middleware.RequireAnyPermission("members.update", "pos.update-members"), handlers.UpdateMember)
 			protected.DELETE("/members/:id", middleware.RequireAnyPermission("members.delete", "pos.delete-members"), handlers.DeleteMember)
+			protected.GET("/members/:id/points", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMemberPoints)
+			protected.GET("/members/:id/statement", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMemberStatement)
 			protected.POST("/members/:id/points", middleware.RequireAnyPermission("members.update", "pos.update-members"), handlers.AddMemberPoints)
+			protected.POST("/members/:id/merge", middleware.RequirePermission("members.merge"), handlers.MergeMember)
 			protected.POST("/members/recalculate-stats", middleware.RequireAnyPermission("members.update", "pos.update-members"), handlers.RecalculateMemberStats)
+			protected.GET("/membership/rules", middleware.RequireAnyPermission("members.view", "pos.view-members"), handlers.GetMembershipRules)
+			protected.PUT("/membership/rules", middleware.RequirePermission("members.update"), handlers.UpdateMembershipRules)
 			// Stocks routes
 			protected.GET("/stocks", middleware.RequireAnyPermission("stocks.view", "pos.view-stocks"), handlers.GetStocks)
 			protected.GET("/stocks/by-location", middleware.RequireAnyPermission("stocks.view", "pos.view-stocks"), handlers.GetStocksByLocation)
 			protected.POST("/stocks", middleware.RequirePermission("stocks.create"), handlers.CreateStock)
 			protected.POST("/stocks-mark-printed", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.MarkStocksPrinted)
+
+			// Melting routes (lebur bahan baku dan kirim ke refinery)
+			protected.GET("/melt-batches", middleware.RequirePermission("melt-batches.view"), handlers.GetMeltBatches)
+			protected.GET("/melt-batches/:id", middleware.RequirePermission("melt-batches.view"), handlers.GetMeltBatch)
+			protected.POST("/melt-batches", middleware.RequirePermission("melt-batches.create"), handlers.CreateMeltBatch)
+			protected.POST("/melt-batches/:id/melt", middleware.RequirePermission("melt-batches.process"), handlers.RecordMeltResult)
+			protected.PUT("/melt-batches/:id/cancel", middleware.RequirePermission("melt-batches.process"), handlers.CancelMeltBatch)
+			protected.GET("/refinery-shipments", middleware.RequirePermission("melt-batches.view"), handlers.GetRefineryShipments)
+			protected.GET("/refinery-shipments/:id", middleware.RequirePermission("melt-batches.view"), handlers.GetRefineryShipment)
+			protected.POST("/refinery-shipments", middleware.RequirePermission("refinery-shipments.create"), handlers.CreateRefineryShipment)
+			protected.POST("/refinery-shipments/:id/settle", middleware.RequirePermission("refinery-shipments.settle"), handlers.SettleRefineryShipment)
+			protected.PUT("/refinery-shipments/:id/cancel", middleware.RequirePermission("refinery-shipments.settle"), handlers.CancelRefineryShipment)
+
+			// Fine gold reconciliation (emas murni)
+			protected.POST("/fine-gold/reconcile", middleware.RequirePermission("fine-gold.reconcile"), handlers.ReconcileFineGold)
+
+			// Production routes (bahan baku menjadi barang jadi)
+			protected.GET("/production-orders", middleware.RequirePermission("production.view"), handlers.GetProductionOrders)
+			protected.GET("/production-orders/:id", middleware.RequirePermission("production.view"), handlers.GetProductionOrder)
+			protected.POST("/production-orders", middleware.RequirePermission("production.create"), handlers.CreateProductionOrder)
+			protected.POST("/production-orders/:id/complete", middleware.RequirePermission("production.complete"), handlers.CompleteProductionOrder)
+			protected.PUT("/production-orders/:id/cancel", middleware.RequirePermission("production.create"), handlers.CancelProductionOrder)
+
+			// Write-off routes (penghapusan stok hilang/dicuri/rusak/lebur)
+			protected.GET("/write-offs", middleware.RequirePermission("write-offs.view"), handlers.GetWriteOffs)
+			protected.GET("/write-offs/:id", middleware.RequirePermission("write-offs.view"), handlers.GetWriteOff)
+			protected.POST("/write-offs", middleware.RequirePermission("write-offs.create"), handlers.CreateWriteOff)
+			protected.POST("/write-offs/:id/photos", middleware.RequirePermission("write-offs.create"), handlers.UploadWriteOffPhoto)
+			protected.POST("/write-offs/:id/approve", middleware.RequirePermission("write-offs.approve"), handlers.ApproveWriteOff)
+			protected.POST("/write-offs/:id/reject", middleware.RequirePermission("write-offs.approve"), handlers.RejectWriteOff)
+
+			// Label routes (cetak label barcode/QR di server)
+			protected.GET("/label-templates", middleware.RequirePermission("labels.view"), handlers.GetLabelTemplates)
+			protected.GET("/label-templates/:id", middleware.RequirePermission("labels.view"), handlers.GetLabelTemplate)
+			protected.POST("/label-templates", middleware.RequirePermission("labels.manage-templates"), handlers.CreateLabelTemplate)
+			protected.PUT("/label-templates/:id", middleware.RequirePermission("labels.manage-templates"), handlers.UpdateLabelTemplate)
+			protected.DELETE("/label-templates/:id", middleware.RequirePermission("labels.manage-templates"), handlers.DeleteLabelTemplate)
+			protected.GET("/label-printers", middleware.RequirePermission("labels.view"), handlers.GetLabelPrinters)
+			protected.POST("/label-printers", middleware.RequirePermission("labels.manage-printers"), handlers.CreateLabelPrinter)
+			protected.PUT("/label-printers/:id", middleware.RequirePermission("labels.manage-printers"), handlers.UpdateLabelPrinter)
+			protected.DELETE("/label-printers/:id", middleware.RequirePermission("labels.manage-printers"), handlers.DeleteLabelPrinter)
+			protected.GET("/labels/jobs", middleware.RequirePermission("labels.view"), handlers.GetLabelJobs)
+			protected.GET("/labels/jobs/:id", middleware.RequirePermission("labels.view"), handlers.GetLabelJob)
+			protected.GET("/labels/jobs/:id/output", middleware.RequirePermission("labels.view"), handlers.GetLabelJobOutput)
+			protected.POST("/labels/jobs", middleware.RequirePermission("labels.print"), handlers.CreateLabelJob)
+			protected.POST("/labels/jobs/:id/complete", middleware.RequirePermission("labels.print"), handlers.CompleteLabelJob)
+			protected.PUT("/labels/jobs/:id/cancel", middleware.RequirePermission("labels.print"), handlers.CancelLabelJob)
 			protected.POST("/stocks/transfer", middleware.RequirePermission("stocks.transfer"), handlers.TransferStock)
 			protected.GET("/stocks/serial/:serial", middleware.RequireAnyPermission("stocks.view", "pos.view-stocks"), handlers.GetStockBySerial)
 			protected.GET("/stocks/box/:box_id/items", middleware.RequireAnyPermission("stocks.view", "pos.view-stocks"), handlers.GetStocksByBox)
 			protected.GET("/stocks/:id", middleware.RequireAnyPermission("stocks.view", "pos.view-stocks"), handlers.GetStock)
 			protected.PUT("/stocks/:id", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.UpdateStock)
+			protected.PUT("/stocks/:id/weight", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.UpdateStockWeight)
+			protected.PUT("/stocks/:id/stones", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.UpdateStockStones)
+			protected.POST("/stocks/:id/images", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.UploadStockImages)
+			protected.PUT("/stocks/:id/images/:image_id/primary", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.SetPrimaryStockImage)
+			protected.DELETE("/stocks/:id/images/:image_id", middleware.RequireAnyPermission("stocks.update", "pos.update-stocks"), handlers.DeleteStockImage)
+
+			// Scale routes (timbangan)
+			protected.GET("/scale/readings", middleware.RequirePermission("pos.use-scale"), handlers.GetScaleReadings)
+			protected.POST("/scale/readings", middleware.RequirePermission("pos.use-scale"), handlers.CreateScaleReading)
+			protected.POST("/scale/read", middleware.RequirePermission("pos.use-scale"), handlers.ReadServerScale)
+			protected.POST("/scale/simulate", middleware.RequirePermission("pos.use-scale"), handlers.SimulateScale)
 			protected.DELETE("/stocks/:id", middleware.RequirePermission("stocks.delete"), handlers.DeleteStock)
 			protected.GET("/stock-transfers", middleware.RequirePermission("stocks.view"), handlers.GetStockTransfers)
+			protected.PUT("/stock-transfers/:id/complete", middleware.RequirePermission("stocks.transfer"), handlers.CompleteStockTransfer)
+			protected.PUT("/stock-transfers/:id/cancel", middleware.RequirePermission("stocks.transfer"), handlers.CancelStockTransfer)
+
+			// Replenishment routes (pengisian ulang toko dari gudang)
+			protected.GET("/replenishment/rules", middleware.RequirePermission("replenishment.view"), handlers.GetReplenishmentRules)
+			protected.POST("/replenishment/rules", middleware.RequirePermission("replenishment.manage"), handlers.CreateReplenishmentRule)
+			protected.PUT("/replenishment/rules/:id", middleware.RequirePermission("replenishment.manage"), handlers.UpdateReplenishmentRule)
+			protected.DELETE("/replenishment/rules/:id", middleware.RequirePermission("replenishment.manage"), handlers.DeleteReplenishmentRule)
+			protected.GET("/replenishment/suggestions", middleware.RequirePermission("replenishment.view"), handlers.GetReplenishmentSuggestions)
+			protected.POST("/replenishment/suggestions/generate", middleware.RequirePermission("replenishment.manage"), handlers.GenerateReplenishmentSuggestions)
+			protected.POST("/replenishment/suggestions/:id/accept", middleware.RequirePermission("replenishment.accept"), handlers.AcceptReplenishmentSuggestion)
+			protected.PUT("/replenishment/suggestions/:id/dismiss", middleware.RequirePermission("replenishment.accept"), handlers.DismissReplenishmentSuggestion)
 
 			// Transactions routes (POS)
 			protected.GET("/transactions", middleware.RequirePermission("transactions.view"), handlers.GetTransactions)
@@ -166,7 +406,15 @@ func main() {
 			protected.GET("/transactions/code/:code", middleware.RequirePermission("transactions.view"), handlers.GetTransactionByCode)
 			protected.POST("/transactions/sale", middleware.RequirePermission("transactions.sale"), handlers.CreateSale)
 			protected.POST("/transactions/purchase", middleware.RequirePermission("transactions.purchase"), handlers.CreatePurchase)
+			protected.GET("/bullion/verify", middleware.RequirePermission("transactions.purchase"), handlers.VerifyBullionCertificate)
+			protected.GET("/setor-price/reference", middleware.RequirePermission("transactions.purchase"), handlers.GetSetorReferencePrice)
+			protected.GET("/setor-price/rules", middleware.RequirePermission("transactions.purchase"), handlers.GetSetorConditionRules)
+			protected.PUT("/setor-price/rules", middleware.RequirePermission("setor-rules.update"), handlers.UpdateSetorConditionRules)
 			protected.PUT("/transactions/:id/cancel", middleware.RequirePermission("transactions.cancel"), handlers.CancelTransaction)
+			protected.GET("/quotations", middleware.RequirePermission("transactions.view"), handlers.GetQuotations)
+			protected.GET("/quotations/:id", middleware.RequirePermission("transactions.view"), handlers.GetQuotation)
+			protected.POST("/quotations", middleware.RequirePermission("transactions.sale"), handlers.CreateQuotation)
+			protected.PUT("/quotations/:id/cancel", middleware.RequirePermission("transactions.sale"), handlers.CancelQuotation)
 			protected.GET("/transactions/daily-summary", middleware.RequirePermission("transactions.view"), handlers.GetDailySummary)
 
 			// Dashboard - accessible by all logged in users (filtered by their assigned locations)
@@ -196,7 +444,14 @@ func main() {
 				reports.GET("/stocks/category", middleware.RequirePermission("reports.view"), handlers.GetStockCategoryReport)
 				reports.GET("/stocks/transfer", middleware.RequirePermission("reports.view"), handlers.GetStockTransferReport)
 				reports.GET("/stocks/sold", middleware.RequirePermission("reports.view"), handlers.GetSoldStockReport)
+				reports.GET("/stocks/aging", middleware.RequirePermission("reports.view"), handlers.GetStockAgingReport)
+				reports.GET("/stocks/sell-through", middleware.RequirePermission("reports.view"), handlers.GetSellThroughReport)
+				reports.GET("/stocks/slow-movers", middleware.RequirePermission("reports.view"), handlers.GetSlowMoverCandidates)
 				reports.GET("/raw-materials", middleware.RequirePermission("reports.view"), handlers.GetRawMaterialReport)
+				reports.GET("/weight-sources", middleware.RequirePermission("reports.view"), handlers.GetWeightSourceReport)
+				reports.GET("/fine-gold", middleware.RequirePermission("reports.view"), handlers.GetFineGoldReport)
+				reports.GET("/fine-gold/balance", middleware.RequirePermission("reports.view"), handlers.GetFineGoldBalance)
+				reports.GET("/fine-gold/movements", middleware.RequirePermission("reports.view"), handlers.GetFineGoldMovements)
 
 				// Financial Reports
 				reports.GET("/financial/summary", middleware.RequirePermission("reports.view"), handlers.GetFinancialSummary)
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FineGoldMovementType defines what caused a change in the fine-gold balance
+type FineGoldMovementType string
+
+const (
+	FineGoldMovementReceipt     FineGoldMovementType = "receipt"      // Penerimaan barang / input stok manual
+	FineGoldMovementProduction  FineGoldMovementType = "production"   // Susut produksi
+	FineGoldMovementSale        FineGoldMovementType = "sale"         // Penjualan (positif saat dibatalkan)
+	FineGoldMovementSetor       FineGoldMovementType = "setor"        // Pembelian dari customer
+	FineGoldMovementRawMaterial FineGoldMovementType = "raw_material" // Bahan baku input manual
+	FineGoldMovementMelt        FineGoldMovementType = "melt"         // Selisih hasil lebur terhadap perkiraan
+	FineGoldMovementRefinery    FineGoldMovementType = "refinery"     // Kirim ke refinery / emas murni diterima
+	FineGoldMovementTransferIn  FineGoldMovementType = "transfer_in"  // Transfer masuk dari lokasi lain
+	FineGoldMovementTransferOut FineGoldMovementType = "transfer_out" // Transfer keluar ke lokasi lain
+	FineGoldMovementWriteOff    FineGoldMovementType = "write_off"    // Write-off (dan konversi ke bahan baku)
+	FineGoldMovementAdjustment  FineGoldMovementType = "adjustment"   // Timbang ulang, koreksi data, rekonsiliasi
+)
+
+// FineGoldMovement is an append-only ledger line of pure gold (gram) entering or leaving a location.
+// Berat bertanda: positif = masuk, negatif = keluar.
+type FineGoldMovement struct {
+	ID              uint                 `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt       `gorm:"index" json:"-"`
+	LocationID      uint                 `gorm:"not null;index" json:"location_id"`
+	Location        Location             `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Type            FineGoldMovementType `gorm:"not null;size:20;index" json:"type"`
+	GoldCategoryID  *uint                `gorm:"index" json:"gold_category_id,omitempty"`
+	GoldCategory    *GoldCategory        `gorm:"foreignKey:GoldCategoryID" json:"gold_category,omitempty"`
+	GrossWeight     float64              `gorm:"not null;default:0" json:"gross_weight"` // Berat kotor (gram)
+	Purity          float64              `gorm:"default:0" json:"purity"`                // Kadar (pecahan), 0 jika tidak diketahui
+	FineWeight      float64              `gorm:"not null;default:0" json:"fine_weight"`  // Berat emas murni (gram)
+	PurityMissing   bool                 `gorm:"default:false" json:"purity_missing"`    // Kategori tanpa kadar, emas murni tidak terhitung
+	ReferenceType   string               `gorm:"size:30;index" json:"reference_type"`    // stock, raw_material, melt_batch, refinery_shipment, production_order, ...
+	ReferenceID     uint                 `gorm:"index" json:"reference_id"`
+	ReferenceNumber string               `gorm:"size:50" json:"reference_number"`
+	OccurredAt      time.Time            `gorm:"not null;index" json:"occurred_at"`
+	CreatedByID     *uint                `json:"created_by_id,omitempty"`
+	Notes           string               `gorm:"size:255" json:"notes"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StoneType defines the kind of gemstone set in a piece
+type StoneType string
+
+const (
+	StoneTypeDiamond  StoneType = "diamond"  // Berlian
+	StoneTypeRuby     StoneType = "ruby"     // Ruby
+	StoneTypeSapphire StoneType = "sapphire" // Safir
+	StoneTypeEmerald  StoneType = "emerald"  // Zamrud
+	StoneTypeZircon   StoneType = "zircon"   // Zirkon / CZ
+	StoneTypePearl    StoneType = "pearl"    // Mutiara
+	StoneTypeOther    StoneType = "other"    // Batu lainnya
+)
+
+// IsValid checks whether the stone type is supported
+func (t StoneType) IsValid() bool {
+	switch t {
+	case StoneTypeDiamond, StoneTypeRuby, StoneTypeSapphire, StoneTypeEmerald, StoneTypeZircon, StoneTypePearl, StoneTypeOther:
+		return true
+	}
+	return false
+}
+
+// GramsPerCarat converts carat to gram (1 ct = 0.2 g)
+const GramsPerCarat = 0.2
+
+// StoneComponent is a group of identical stones set in a product design, a stock piece,
+// a setor line or a raw material. Exactly one owner is filled.
+// Batu pada stok (dengan nomor sertifikat) menggantikan batu desain produk untuk buah tersebut.
+type StoneComponent struct {
+	ID                uint           `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ProductID         *uint          `gorm:"index" json:"product_id,omitempty"`
+	StockID           *uint          `gorm:"index" json:"stock_id,omitempty"`
+	TransactionItemID *uint          `gorm:"index" json:"transaction_item_id,omitempty"` // Batu dari setor
+	RawMaterialID     *uint          `gorm:"index" json:"raw_material_id,omitempty"`     // Batu yang dilepas dari bahan baku setor
+	StoneType         StoneType      `gorm:"not null;size:20" json:"stone_type"`
+	Carat             float64        `gorm:"default:0" json:"carat"` // Berat per butir (ct)
+	Count             int            `gorm:"not null;default:1" json:"count"`
+	Clarity           string         `gorm:"size:20" json:"clarity,omitempty"` // VVS1, VS2, SI1, ...
+	Color             string         `gorm:"size:20" json:"color,omitempty"`   // D-Z atau warna batu
+	CertificateNumber string         `gorm:"size:50;index" json:"certificate_number,omitempty"`
+	PricePerStone     float64        `gorm:"default:0" json:"price_per_stone"`
+	Notes             string         `gorm:"size:255" json:"notes"`
+}
+
+// TotalCarat returns the carat weight of all stones in this component
+func (s *StoneComponent) TotalCarat() float64 {
+	return s.Carat * float64(s.Count)
+}
+
+// TotalValue returns the price of all stones in this component
+func (s *StoneComponent) TotalValue() float64 {
+	return s.PricePerStone * float64(s.Count)
+}
+
+// StonesValue sums the price of a set of stone components
+func StonesValue(stones []StoneComponent) float64 {
+	var total float64
+	for i := range stones {
+		total += stones[i].TotalValue()
+	}
+	return total
+}
+
+// StonesWeightGrams sums the weight (gram) of a set of stone components
+func StonesWeightGrams(stones []StoneComponent) float64 {
+	var total float64
+	for i := range stones {
+		total += stones[i].TotalCarat() * GramsPerCarat
+	}
+	return total
+}
+
+// PriceBreakdown splits the sell price of a piece into gold, stones and making charge
+type PriceBreakdown struct {
+	GoldValue    float64 `json:"gold_value"`
+	StoneValue   float64 `json:"stone_value"`
+	MakingCharge float64 `json:"making_charge"`
+	Total        float64 `json:"total"`
+}
@@ -19,5 +19,13 @@ type GoldCategory struct {
 	SellPrice   float64        `gorm:"not null;default:0" json:"sell_price"`     // Harga jual per gram
 	Description string         `gorm:"size:255" json:"description"`
 	IsActive    bool           `gorm:"default:true" json:"is_active"`
+
+	// Harga diturunkan dari harga dasar 24K (opsional) - see DerivePrices
+	DerivePrice       bool              `gorm:"default:false" json:"derive_price"`
+	SellMarkupPercent float64           `gorm:"default:0" json:"sell_markup_percent"` // Markup harga jual di atas nilai kadar
+	BuySpreadPercent  float64           `gorm:"default:0" json:"buy_spread_percent"`  // Potongan harga beli di bawah nilai kadar
+	RoundingStep      float64           `gorm:"default:0" json:"rounding_step"`       // contoh: 1000 = dibulatkan ke ribuan, 0 = tanpa pembulatan
+	RoundingMode      PriceRoundingMode `gorm:"size:10;default:'nearest'" json:"rounding_mode"`
+
 	Products    []Product      `gorm:"foreignKey:GoldCategoryID" json:"products,omitempty"`
 }
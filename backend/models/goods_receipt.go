@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GoodsReceiptStatus defines the status of a goods receipt document
+type GoodsReceiptStatus string
+
+const (
+	GoodsReceiptStatusDraft     GoodsReceiptStatus = "draft"     // Belum diposting, stok belum dibuat
+	GoodsReceiptStatusPosted    GoodsReceiptStatus = "posted"    // Sudah diposting, stok sudah dibuat
+	GoodsReceiptStatusCancelled GoodsReceiptStatus = "cancelled" // Dibatalkan
+)
+
+// CostType defines how the cost of a receipt line is expressed
+type CostType string
+
+const (
+	CostTypePerGram  CostType = "per_gram"  // Harga modal per gram
+	CostTypePerPiece CostType = "per_piece" // Harga modal per buah
+)
+
+// GoodsReceipt represents a penerimaan barang document from a supplier
+type GoodsReceipt struct {
+	ID            uint               `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt     `gorm:"index" json:"-"`
+	ReceiptNumber string             `gorm:"not null;size:30" json:"receipt_number"` // unique index created manually in migration
+	SupplierID    uint               `gorm:"not null;index" json:"supplier_id"`
+	Supplier      Supplier           `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	LocationID    uint               `gorm:"not null;index" json:"location_id"`
+	Location      Location           `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	InvoiceNumber string             `gorm:"size:50" json:"invoice_number"` // Nomor nota/faktur supplier
+	ReceivedAt    time.Time          `gorm:"not null;index" json:"received_at"`
+	ReceivedByID  uint               `gorm:"not null" json:"received_by_id"`
+	ReceivedBy    User               `gorm:"foreignKey:ReceivedByID" json:"received_by,omitempty"`
+	Status        GoodsReceiptStatus `gorm:"not null;size:20;default:'draft';index" json:"status"`
+	TotalQuantity int                `gorm:"default:0" json:"total_quantity"`
+	TotalWeight   float64            `gorm:"default:0" json:"total_weight"`
+	TotalCost     float64            `gorm:"default:0" json:"total_cost"`
+	PostedAt      *time.Time         `json:"posted_at,omitempty"`
+	Notes         string             `gorm:"size:500" json:"notes"`
+
+	// Relations
+	Items []GoodsReceiptItem `gorm:"foreignKey:GoodsReceiptID" json:"items,omitempty"`
+}
+
+// GoodsReceiptItem represents one product line on a goods receipt
+type GoodsReceiptItem struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	GoodsReceiptID uint           `gorm:"not null;index" json:"goods_receipt_id"`
+	ProductID      uint           `gorm:"not null;index" json:"product_id"`
+	Product        Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	StorageBoxID   uint           `gorm:"not null" json:"storage_box_id"`
+	StorageBox     StorageBox     `gorm:"foreignKey:StorageBoxID" json:"storage_box,omitempty"`
+	Quantity       int            `gorm:"not null" json:"quantity"`
+	PieceWeights   []float64      `gorm:"type:json;serializer:json" json:"piece_weights"`       // Berat bersih aktual per buah (gram), panjang = quantity
+	PieceGross     []float64      `gorm:"type:json;serializer:json" json:"piece_gross_weights"` // Berat kotor aktual per buah (optional)
+	Certificates   []string       `gorm:"type:json;serializer:json" json:"certificate_numbers"` // Nomor sertifikat per keping (logam mulia)
+	TotalWeight    float64        `gorm:"not null" json:"total_weight"`
+	CostType       CostType       `gorm:"not null;size:20" json:"cost_type"`
+	CostPerGram    float64        `gorm:"default:0" json:"cost_per_gram"`
+	CostPerPiece   float64        `gorm:"default:0" json:"cost_per_piece"`
+	TotalCost      float64        `gorm:"not null" json:"total_cost"`
+	Notes          string         `gorm:"size:255" json:"notes"`
+}
+
+// PieceWeight returns the actual weight of the i-th piece, falling back to the product weight
+func (i *GoodsReceiptItem) PieceWeight(index int) float64 {
+	if index < len(i.PieceWeights) && i.PieceWeights[index] > 0 {
+		return i.PieceWeights[index]
+	}
+	return i.Product.Weight
+}
+
+// PieceGrossWeight returns the gross weight of the i-th piece, falling back to its net weight
+func (i *GoodsReceiptItem) PieceGrossWeight(index int) float64 {
+	if index < len(i.PieceGross) && i.PieceGross[index] > 0 {
+		return i.PieceGross[index]
+	}
+	return i.PieceWeight(index)
+}
+
+// PieceCost returns the cost basis of the i-th piece
+func (i *GoodsReceiptItem) PieceCost(index int) float64 {
+	if i.CostType == CostTypePerPiece {
+		return i.CostPerPiece
+	}
+	return i.CostPerGram * i.PieceWeight(index)
+}
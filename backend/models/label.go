@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LabelFormat is the output format of a label job
+type LabelFormat string
+
+const (
+	LabelFormatPNG LabelFormat = "png" // Gambar per label (zip jika lebih dari satu)
+	LabelFormatPDF LabelFormat = "pdf" // Lembar kertas label (multi-up)
+	LabelFormatZPL LabelFormat = "zpl" // Printer thermal Zebra-compatible
+)
+
+// LabelJobStatus defines the status of a label job
+type LabelJobStatus string
+
+const (
+	LabelJobStatusRendered  LabelJobStatus = "rendered"  // Siap dicetak/diunduh
+	LabelJobStatusCompleted LabelJobStatus = "completed" // Sudah dicetak, stok ditandai printed
+	LabelJobStatusFailed    LabelJobStatus = "failed"    // Gagal dikirim ke printer
+	LabelJobStatusCancelled LabelJobStatus = "cancelled"
+)
+
+// LabelTemplate is a configurable label layout (ukuran label, susunan lembar, jenis barcode, isi teks)
+type LabelTemplate struct {
+	ID           uint           `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	Name         string         `gorm:"not null;size:100" json:"name"`
+	Description  string         `gorm:"size:255" json:"description"`
+	WidthMM      float64        `gorm:"not null" json:"width_mm"`
+	HeightMM     float64        `gorm:"not null" json:"height_mm"`
+	PageWidthMM  float64        `gorm:"default:0" json:"page_width_mm"`  // 0 = printer roll (satu label per halaman)
+	PageHeightMM float64        `gorm:"default:0" json:"page_height_mm"` // 0 = printer roll (satu label per halaman)
+	Columns      int            `gorm:"default:1" json:"columns"`
+	Rows         int            `gorm:"default:1" json:"rows"`
+	MarginLeftMM float64        `gorm:"default:0" json:"margin_left_mm"`
+	MarginTopMM  float64        `gorm:"default:0" json:"margin_top_mm"`
+	GapXMM       float64        `gorm:"default:0" json:"gap_x_mm"`
+	GapYMM       float64        `gorm:"default:0" json:"gap_y_mm"`
+	PaddingMM    float64        `gorm:"default:1" json:"padding_mm"`
+	Symbology    string         `gorm:"size:20;default:'code128'" json:"symbology"` // code128, qr
+	DPI          int            `gorm:"default:203" json:"dpi"`
+	FontSizePt   float64        `gorm:"default:5" json:"font_size_pt"`
+	Fields       []string       `gorm:"type:json;serializer:json" json:"fields"` // Baris teks: product_name, serial_number, weight, gold_category, ...
+	IsDefault    bool           `gorm:"default:false" json:"is_default"`
+	IsActive     bool           `gorm:"default:true" json:"is_active"`
+}
+
+// LabelPrinter is a network label printer registered by an administrator.
+// Server hanya mengirim data ke alamat printer yang terdaftar di sini.
+type LabelPrinter struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	Name       string         `gorm:"not null;size:100" json:"name"`
+	Address    string         `gorm:"not null;size:100" json:"address"` // host:port printer ZPL (raw port 9100)
+	LocationID *uint          `gorm:"index" json:"location_id,omitempty"`
+	Location   *Location      `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Notes      string         `gorm:"size:255" json:"notes"`
+	IsActive   bool           `gorm:"default:true" json:"is_active"`
+}
+
+// LabelJob is a batch of stock labels rendered by the server.
+// Stok baru ditandai barcode_printed setelah job selesai (completed).
+type LabelJob struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	JobNumber     string         `gorm:"not null;size:50" json:"job_number"` // unique index created manually in migration
+	TemplateID    uint           `gorm:"not null;index" json:"template_id"`
+	Template      *LabelTemplate `gorm:"foreignKey:TemplateID" json:"template,omitempty"`
+	Format        LabelFormat    `gorm:"not null;size:10" json:"format"`
+	StockIDs      []uint         `gorm:"type:json;serializer:json" json:"stock_ids"`
+	StorageBoxID  *uint          `gorm:"index" json:"storage_box_id,omitempty"` // Jika dicetak per kotak
+	LabelCount    int            `gorm:"default:0" json:"label_count"`
+	Status        LabelJobStatus `gorm:"not null;size:20;default:'rendered';index" json:"status"`
+	PrinterID     *uint          `gorm:"index" json:"printer_id,omitempty"` // Printer ZPL terdaftar (opsional)
+	Printer       *LabelPrinter  `gorm:"foreignKey:PrinterID" json:"printer,omitempty"`
+	ErrorMessage  string         `gorm:"size:255" json:"error_message,omitempty"`
+	CreatedByID   uint           `gorm:"not null" json:"created_by_id"`
+	CreatedBy     *User          `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	CompletedAt   *time.Time     `json:"completed_at,omitempty"`
+	CompletedByID *uint          `json:"completed_by_id,omitempty"`
+}
@@ -31,7 +31,32 @@ type Location struct {
 	Stocks      []Stock        `gorm:"foreignKey:LocationID" json:"stocks,omitempty"`
 }
 
-// StorageBox represents a storage box within a location
+// StorageBoxType defines the kind of storage node in the location tree
+type StorageBoxType string
+
+const (
+	StorageBoxTypeRoom     StorageBoxType = "room"     // Ruangan
+	StorageBoxTypeSafe     StorageBoxType = "safe"     // Brankas
+	StorageBoxTypeShowcase StorageBoxType = "showcase" // Etalase
+	StorageBoxTypeCabinet  StorageBoxType = "cabinet"  // Lemari
+	StorageBoxTypeRack     StorageBoxType = "rack"     // Rak
+	StorageBoxTypeDrawer   StorageBoxType = "drawer"   // Laci
+	StorageBoxTypeTray     StorageBoxType = "tray"     // Baki
+	StorageBoxTypeBox      StorageBoxType = "box"      // Kotak
+)
+
+// IsValid reports whether t is a known storage node type
+func (t StorageBoxType) IsValid() bool {
+	switch t {
+	case StorageBoxTypeRoom, StorageBoxTypeSafe, StorageBoxTypeShowcase, StorageBoxTypeCabinet,
+		StorageBoxTypeRack, StorageBoxTypeDrawer, StorageBoxTypeTray, StorageBoxTypeBox:
+		return true
+	}
+	return false
+}
+
+// StorageBox represents a storage node within a location.
+// Nodes form a tree (ruangan -> etalase/brankas -> baki/laci); stock is only placed on leaf nodes.
 type StorageBox struct {
 	ID          uint           `gorm:"primarykey" json:"id"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -39,11 +64,18 @@ type StorageBox struct {
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 	LocationID  uint           `gorm:"not null;index" json:"location_id"`
 	Location    Location       `gorm:"foreignKey:LocationID" json:"location,omitempty"`
-	Code        string         `gorm:"not null;size:20;index" json:"code"` // e.g., "A1", "B2", etc.
+	ParentID    *uint          `gorm:"index" json:"parent_id"`
+	Parent      *StorageBox    `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
+	Type        StorageBoxType `gorm:"not null;size:20;default:'box'" json:"type"`
+	Code        string         `gorm:"not null;size:20;index" json:"code"` // e.g., "A1", "SC2", "T3" (segment, without slash)
 	Name        string         `gorm:"not null;size:50" json:"name"`       // e.g., "Kotak A1"
+	Path        string         `gorm:"size:255;index" json:"path"`         // ID path from root, e.g. "/3/7/12/" - used for subtree aggregation
+	PathCode    string         `gorm:"size:255;index" json:"path_code"`    // Full code path, e.g. "TK01/SC2/T3"
+	Depth       int            `gorm:"default:0" json:"depth"`             // 0 = directly under location
 	Description string         `gorm:"size:255" json:"description"`
 	Capacity    int            `gorm:"default:0" json:"capacity"` // Max items this box can hold (0 = unlimited)
 	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	Children    []StorageBox   `gorm:"foreignKey:ParentID" json:"children,omitempty"`
 	Stocks      []Stock        `gorm:"foreignKey:StorageBoxID" json:"stocks,omitempty"`
 }
 
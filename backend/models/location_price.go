@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LocationPriceMode defines how a location override changes the global category price
+type LocationPriceMode string
+
+const (
+	LocationPriceModeAbsolute LocationPriceMode = "absolute" // Harga per gram tetap untuk lokasi ini
+	LocationPriceModeDelta    LocationPriceMode = "delta"    // Selisih per gram dari harga global (boleh negatif)
+)
+
+// IsValid checks whether the override mode is supported
+func (m LocationPriceMode) IsValid() bool {
+	return m == LocationPriceModeAbsolute || m == LocationPriceModeDelta
+}
+
+// LocationPrice overrides the price of a gold category at one location (toko di kota lain).
+// Delta mengikuti setiap update harga global, absolute tidak.
+type LocationPrice struct {
+	ID             uint              `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"-"`
+	LocationID     uint              `gorm:"not null;index" json:"location_id"` // unique per gold category, created manually in migration
+	Location       *Location         `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	GoldCategoryID uint              `gorm:"not null;index" json:"gold_category_id"`
+	GoldCategory   *GoldCategory     `gorm:"foreignKey:GoldCategoryID" json:"gold_category,omitempty"`
+	Mode           LocationPriceMode `gorm:"not null;size:10" json:"mode"`
+	BuyPrice       float64           `gorm:"not null;default:0" json:"buy_price"`  // Harga atau selisih beli per gram
+	SellPrice      float64           `gorm:"not null;default:0" json:"sell_price"` // Harga atau selisih jual per gram
+	UpdatedByID    *uint             `json:"updated_by_id,omitempty"`
+}
+
+// Apply returns the category with the buy and sell price effective at the override's location
+func (o *LocationPrice) Apply(category GoldCategory) GoldCategory {
+	switch o.Mode {
+	case LocationPriceModeAbsolute:
+		category.BuyPrice = o.BuyPrice
+		category.SellPrice = o.SellPrice
+	case LocationPriceModeDelta:
+		category.BuyPrice += o.BuyPrice
+		category.SellPrice += o.SellPrice
+	}
+	return category
+}
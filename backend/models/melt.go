@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MeltBatchStatus defines the status of a melt batch
+type MeltBatchStatus string
+
+const (
+	MeltBatchStatusDraft     MeltBatchStatus = "draft"     // Bahan baku dikumpulkan, belum dilebur
+	MeltBatchStatusMelted    MeltBatchStatus = "melted"    // Sudah dilebur, berat hasil dan kadar tercatat
+	MeltBatchStatusShipped   MeltBatchStatus = "shipped"   // Dikirim ke refinery
+	MeltBatchStatusSettled   MeltBatchStatus = "settled"   // Refinery sudah membayar
+	MeltBatchStatusConsumed  MeltBatchStatus = "consumed"  // Dipakai untuk produksi sendiri
+	MeltBatchStatusCancelled MeltBatchStatus = "cancelled" // Dibatalkan sebelum dilebur
+)
+
+// MeltBatch groups raw materials melted together (lebur) into one bar/lot
+type MeltBatch struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-"`
+	BatchNumber string          `gorm:"not null;size:30" json:"batch_number"` // unique index created manually in migration
+	LocationID  uint            `gorm:"not null;index" json:"location_id"`
+	Location    Location        `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Status      MeltBatchStatus `gorm:"not null;size:20;default:'draft';index" json:"status"`
+	CreatedByID uint            `gorm:"not null" json:"created_by_id"`
+	CreatedBy   User            `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	MeltedAt    *time.Time      `json:"melted_at,omitempty"`
+	MeltedByID  *uint           `json:"melted_by_id,omitempty"`
+	MeltedBy    *User           `gorm:"foreignKey:MeltedByID" json:"melted_by,omitempty"`
+	Notes       string          `gorm:"size:500" json:"notes"`
+
+	// Input (jumlah dari bahan baku)
+	InputGrossWeight   float64 `gorm:"default:0" json:"input_gross_weight"`   // Berat kotor semua bahan baku
+	InputNetWeight     float64 `gorm:"default:0" json:"input_net_weight"`     // Berat bersih (setelah susut taksiran)
+	ExpectedFineWeight float64 `gorm:"default:0" json:"expected_fine_weight"` // Emas murni taksiran dari kadar bahan baku
+	TotalCost          float64 `gorm:"default:0" json:"total_cost"`           // Total harga beli bahan baku
+
+	// Hasil lebur
+	OutputWeight             float64 `gorm:"default:0" json:"output_weight"`              // Berat hasil lebur (gram)
+	AssayedPurity            float64 `gorm:"default:0" json:"assayed_purity"`             // Kadar hasil uji (%)
+	ActualFineWeight         float64 `gorm:"default:0" json:"actual_fine_weight"`         // output_weight x assayed_purity
+	ExpectedShrinkagePercent float64 `gorm:"default:0" json:"expected_shrinkage_percent"` // Dari shrinkage_percent bahan baku
+	ActualShrinkagePercent   float64 `gorm:"default:0" json:"actual_shrinkage_percent"`   // (input kotor - output) / input kotor
+	ShrinkageVarianceWeight  float64 `gorm:"default:0" json:"shrinkage_variance_weight"`  // Hasil aktual - taksiran (gram), negatif = rugi
+	FineWeightVariance       float64 `gorm:"default:0" json:"fine_weight_variance"`       // Emas murni aktual - taksiran (gram)
+
+	// Penyelesaian refinery (dialokasikan dari shipment)
+	RefineryShipmentID *uint   `gorm:"index" json:"refinery_shipment_id,omitempty"`
+	CreditedFineWeight float64 `gorm:"default:0" json:"credited_fine_weight"` // Emas murni yang diakui refinery
+	SettlementValue    float64 `gorm:"default:0" json:"settlement_value"`     // Nilai penyelesaian (rupiah)
+	GainLoss           float64 `gorm:"default:0" json:"gain_loss"`            // settlement_value - total_cost
+
+	// Relations
+	Items []MeltBatchItem `gorm:"foreignKey:MeltBatchID" json:"items,omitempty"`
+}
+
+// MeltBatchItem is one raw material in a melt batch, with the values taken when it was added
+type MeltBatchItem struct {
+	ID                 uint           `gorm:"primarykey" json:"id"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
+	MeltBatchID        uint           `gorm:"not null;index" json:"melt_batch_id"`
+	RawMaterialID      uint           `gorm:"not null;index" json:"raw_material_id"`
+	RawMaterial        *RawMaterial   `gorm:"foreignKey:RawMaterialID" json:"raw_material,omitempty"`
+	WeightGross        float64        `gorm:"not null" json:"weight_gross"`
+	WeightGrams        float64        `gorm:"not null" json:"weight_grams"`
+	ShrinkagePercent   float64        `gorm:"default:0" json:"shrinkage_percent"`
+	Purity             float64        `gorm:"default:0" json:"purity"` // Kadar (fraksi, 0.750)
+	ExpectedFineWeight float64        `gorm:"default:0" json:"expected_fine_weight"`
+	Cost               float64        `gorm:"default:0" json:"cost"`
+}
+
+// RefineryShipmentStatus defines the status of a refinery shipment
+type RefineryShipmentStatus string
+
+const (
+	RefineryShipmentStatusSent      RefineryShipmentStatus = "sent"      // Dikirim, menunggu hasil refinery
+	RefineryShipmentStatusSettled   RefineryShipmentStatus = "settled"   // Sudah diselesaikan
+	RefineryShipmentStatusCancelled RefineryShipmentStatus = "cancelled" // Dibatalkan (barang kembali)
+)
+
+// SettlementType defines how a refinery pays for a shipment
+type SettlementType string
+
+const (
+	SettlementTypeCash     SettlementType = "cash"      // Dibayar uang
+	SettlementTypeFineGold SettlementType = "fine_gold" // Dibayar emas murni (24K)
+	SettlementTypeMixed    SettlementType = "mixed"     // Sebagian uang, sebagian emas murni
+)
+
+// RefineryShipment sends melted batches to a refinery and records the settlement received
+type RefineryShipment struct {
+	ID              uint                   `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt         `gorm:"index" json:"-"`
+	ShipmentNumber  string                 `gorm:"not null;size:30" json:"shipment_number"` // unique index created manually in migration
+	SupplierID      *uint                  `gorm:"index" json:"supplier_id,omitempty"`      // Refinery dari master supplier (opsional)
+	Supplier        *Supplier              `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	RefineryName    string                 `gorm:"not null;size:100" json:"refinery_name"`
+	LocationID      uint                   `gorm:"not null;index" json:"location_id"`
+	Location        Location               `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Status          RefineryShipmentStatus `gorm:"not null;size:20;default:'sent';index" json:"status"`
+	SentAt          time.Time              `gorm:"not null" json:"sent_at"`
+	SentByID        uint                   `gorm:"not null" json:"sent_by_id"`
+	SentBy          User                   `gorm:"foreignKey:SentByID" json:"sent_by,omitempty"`
+	TotalWeight     float64                `gorm:"default:0" json:"total_weight"`      // Berat hasil lebur yang dikirim
+	TotalFineWeight float64                `gorm:"default:0" json:"total_fine_weight"` // Emas murni menurut uji sendiri
+	TotalCost       float64                `gorm:"default:0" json:"total_cost"`
+	Notes           string                 `gorm:"size:500" json:"notes"`
+
+	// Penyelesaian
+	SettlementType       SettlementType `gorm:"size:20" json:"settlement_type,omitempty"`
+	SettledAt            *time.Time     `json:"settled_at,omitempty"`
+	SettledByID          *uint          `json:"settled_by_id,omitempty"`
+	CreditedFineWeight   float64        `gorm:"default:0" json:"credited_fine_weight"`     // Emas murni menurut uji refinery
+	SettlementCash       float64        `gorm:"default:0" json:"settlement_cash"`          // Uang yang diterima
+	SettlementFineWeight float64        `gorm:"default:0" json:"settlement_fine_weight"`   // Emas murni yang diterima (gram)
+	FineGoldPricePerGram float64        `gorm:"default:0" json:"fine_gold_price_per_gram"` // Untuk menilai emas murni yang diterima
+	RefiningFee          float64        `gorm:"default:0" json:"refining_fee"`             // Ongkos refinery (sudah dipotong)
+	SettlementValue      float64        `gorm:"default:0" json:"settlement_value"`         // cash + fine_weight x harga
+	GainLoss             float64        `gorm:"default:0" json:"gain_loss"`                // settlement_value - total_cost (ongkos refinery sudah bersih di settlement_value)
+	RawMaterialID        *uint          `json:"raw_material_id,omitempty"`                 // Emas murni yang diterima dicatat sebagai bahan baku
+
+	// Relations
+	Batches []MeltBatch `gorm:"foreignKey:RefineryShipmentID" json:"batches,omitempty"`
+}
@@ -41,26 +41,3 @@ type Member struct {
 	// Relations
 	Transactions []Transaction `gorm:"foreignKey:MemberID" json:"transactions,omitempty"`
 }
-
-// AddPoints adds loyalty points to member
-func (m *Member) AddPoints(amount float64) {
-	// Example: 1 point per 100,000 spent
-	points := int(amount / 100000)
-	m.Points += points
-
-	// Update member type based on total purchase
-	m.updateMemberType()
-}
-
-// updateMemberType updates member type based on total purchase
-func (m *Member) updateMemberType() {
-	if m.TotalPurchase >= 100000000 { // 100 juta
-		m.Type = MemberTypePlatinum
-	} else if m.TotalPurchase >= 50000000 { // 50 juta
-		m.Type = MemberTypeGold
-	} else if m.TotalPurchase >= 20000000 { // 20 juta
-		m.Type = MemberTypeSilver
-	} else {
-		m.Type = MemberTypeRegular
-	}
-}
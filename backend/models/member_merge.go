@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// MemberMerge is the audit record of a duplicate member merged into a surviving member
+type MemberMerge struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	SurvivorID uint      `gorm:"not null;index" json:"survivor_id"`
+	Survivor   *Member   `gorm:"foreignKey:SurvivorID" json:"survivor,omitempty"`
+	MergedID   uint      `gorm:"not null;index" json:"merged_id"` // Member duplikat, dihapus (soft delete) setelah digabung
+	MergedCode string    `gorm:"not null;size:20" json:"merged_code"`
+	MergedName string    `gorm:"not null;size:100" json:"merged_name"`
+	Snapshot   Member    `gorm:"type:json;serializer:json" json:"snapshot"` // Data member duplikat sebelum digabung
+
+	// Jumlah data yang dipindahkan ke member yang dipertahankan
+	TransactionsMoved int `gorm:"default:0" json:"transactions_moved"`
+	RawMaterialsMoved int `gorm:"default:0" json:"raw_materials_moved"`
+	PointEntriesMoved int `gorm:"default:0" json:"point_entries_moved"` // Lot poin yang dipindahkan lewat entri adjust
+	QuotationsMoved   int `gorm:"default:0" json:"quotations_moved"`
+	PointsMoved       int `gorm:"default:0" json:"points_moved"`
+
+	Score        int      `gorm:"default:0" json:"score"`                         // Skor kemiripan saat digabung
+	Reasons      []string `gorm:"type:json;serializer:json" json:"reasons"`       // Alasan kemiripan (telepon, KTP, nama, tanggal lahir)
+	FilledFields []string `gorm:"type:json;serializer:json" json:"filled_fields"` // Kolom kosong di member utama yang diisi dari duplikat
+	Notes        string   `gorm:"size:500" json:"notes"`
+	MergedByID   uint     `gorm:"not null" json:"merged_by_id"`
+	MergedBy     *User    `gorm:"foreignKey:MergedByID" json:"merged_by,omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// MemberPointEntryType defines the kind of movement in the member point ledger
+type MemberPointEntryType string
+
+const (
+	MemberPointEarn    MemberPointEntryType = "earn"    // Poin dari transaksi
+	MemberPointRedeem  MemberPointEntryType = "redeem"  // Poin dipakai sebagai diskon penjualan
+	MemberPointAdjust  MemberPointEntryType = "adjust"  // Koreksi manual (boleh negatif)
+	MemberPointExpire  MemberPointEntryType = "expire"  // Poin kedaluwarsa
+	MemberPointReverse MemberPointEntryType = "reverse" // Pembalikan earn/redeem karena transaksi dibatalkan
+)
+
+// MemberPointEntry is one movement in the append-only point ledger of a member.
+// Entri tidak pernah diubah atau dihapus; koreksi selalu berupa entri baru. Member.Points = saldo entri terakhir.
+type MemberPointEntry struct {
+	ID            uint                 `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time            `gorm:"index" json:"created_at"`
+	MemberID      uint                 `gorm:"not null;index" json:"member_id"`
+	Member        *Member              `gorm:"foreignKey:MemberID" json:"member,omitempty"`
+	Type          MemberPointEntryType `gorm:"not null;size:10;index" json:"type"`
+	Points        int                  `gorm:"not null" json:"points"`  // Positif = masuk, negatif = keluar
+	Balance       int                  `gorm:"not null" json:"balance"` // Saldo setelah entri ini
+	ExpiresAt     *time.Time           `gorm:"index" json:"expires_at,omitempty"`
+	TransactionID *uint                `gorm:"index" json:"transaction_id,omitempty"`
+	Transaction   *Transaction         `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	ReferenceID   *uint                `gorm:"index" json:"reference_id,omitempty"`    // Entri yang dibalik, dikedaluwarsakan atau dipindahkan
+	MemberMergeID *uint                `gorm:"index" json:"member_merge_id,omitempty"` // Penggabungan member yang memindahkan poin ini
+	Notes         string               `gorm:"size:255" json:"notes"`
+	CreatedByID   *uint                `json:"created_by_id,omitempty"`
+	CreatedBy     *User                `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+}
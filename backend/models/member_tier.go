@@ -0,0 +1,99 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemberTierWindow defines which spending counts towards a member's tier
+type MemberTierWindow string
+
+const (
+	MemberTierWindowLifetime        MemberTierWindow = "lifetime"          // Seluruh pembelian sejak terdaftar
+	MemberTierWindowRolling12Months MemberTierWindow = "rolling_12_months" // Pembelian 12 bulan terakhir
+)
+
+// IsValid checks whether the tier window is supported
+func (w MemberTierWindow) IsValid() bool {
+	return w == MemberTierWindowLifetime || w == MemberTierWindowRolling12Months
+}
+
+// MemberTierWindowSettingKey is the settings key holding the tier evaluation window
+const MemberTierWindowSettingKey = "member_tier_window"
+
+// MemberTier is a membership level reached at a minimum spend, with its own sale discount
+type MemberTier struct {
+	ID              uint           `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	Type            MemberType     `gorm:"not null;size:20" json:"type"` // unique index created manually in migration
+	Name            string         `gorm:"not null;size:50" json:"name"`
+	MinSpend        float64        `gorm:"not null;default:0" json:"min_spend"`        // Total pembelian minimal dalam window
+	DiscountPercent float64        `gorm:"not null;default:0" json:"discount_percent"` // Diskon penjualan untuk tier ini
+}
+
+// MemberPointRule is the earn rate of loyalty points for one transaction type
+type MemberPointRule struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt  `gorm:"index" json:"-"`
+	TransactionType TransactionType `gorm:"not null;size:20" json:"transaction_type"`   // unique index created manually in migration
+	AmountPerPoint  float64         `gorm:"not null;default:0" json:"amount_per_point"` // 1 poin per nominal ini, 0 = tanpa poin
+}
+
+// MembershipRules holds the tier thresholds, evaluation window and point rates used by every code path
+type MembershipRules struct {
+	Window     MemberTierWindow  `json:"window"`
+	Tiers      []MemberTier      `json:"tiers"` // Urut dari min_spend terendah
+	PointRules []MemberPointRule `json:"point_rules"`
+}
+
+// SortTiers orders the tiers by minimum spend
+func (r *MembershipRules) SortTiers() {
+	sort.SliceStable(r.Tiers, func(i, j int) bool { return r.Tiers[i].MinSpend < r.Tiers[j].MinSpend })
+}
+
+// Points returns the points earned for a transaction of the given type and amount
+func (r *MembershipRules) Points(transactionType TransactionType, amount float64) int {
+	for _, rule := range r.PointRules {
+		if rule.TransactionType == transactionType && rule.AmountPerPoint > 0 && amount > 0 {
+			return int(math.Floor(amount / rule.AmountPerPoint))
+		}
+	}
+	return 0
+}
+
+// TierFor returns the highest tier whose minimum spend is reached; regular if no tier is configured
+func (r *MembershipRules) TierFor(spend float64) MemberTier {
+	tier := MemberTier{Type: MemberTypeRegular, Name: "Regular"}
+	for _, candidate := range r.Tiers {
+		if spend >= candidate.MinSpend {
+			tier = candidate
+		}
+	}
+	return tier
+}
+
+// Tier returns the configured tier of a member type
+func (r *MembershipRules) Tier(memberType MemberType) (MemberTier, bool) {
+	for _, tier := range r.Tiers {
+		if tier.Type == memberType {
+			return tier, true
+		}
+	}
+	return MemberTier{}, false
+}
+
+// WindowStart returns the start of the spending window at time t, nil for lifetime
+func (r *MembershipRules) WindowStart(t time.Time) *time.Time {
+	if r.Window != MemberTierWindowRolling12Months {
+		return nil
+	}
+	start := t.AddDate(-1, 0, 0)
+	return &start
+}
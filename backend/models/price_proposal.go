@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PriceProposalStatus defines the state of a price update proposed by the price feed
+type PriceProposalStatus string
+
+const (
+	PriceProposalStatusPending    PriceProposalStatus = "pending"    // Menunggu persetujuan
+	PriceProposalStatusApplied    PriceProposalStatus = "applied"    // Sudah diterapkan (otomatis atau disetujui)
+	PriceProposalStatusRejected   PriceProposalStatus = "rejected"   // Ditolak
+	PriceProposalStatusSuperseded PriceProposalStatus = "superseded" // Digantikan usulan atau update harga yang lebih baru
+)
+
+// PriceProposalItem is the proposed price of one gold category
+type PriceProposalItem struct {
+	GoldCategoryID uint    `json:"gold_category_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	OldBuyPrice    float64 `json:"old_buy_price"`
+	NewBuyPrice    float64 `json:"new_buy_price"`
+	OldSellPrice   float64 `json:"old_sell_price"`
+	NewSellPrice   float64 `json:"new_sell_price"`
+	ChangePercent  float64 `json:"change_percent"` // Perubahan terbesar (beli/jual), dalam persen
+}
+
+// PriceProposal is a price update derived from an external price feed.
+// Perubahan di atas batas selalu menunggu persetujuan manusia.
+type PriceProposal struct {
+	ID               uint                `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `gorm:"index" json:"-"`
+	Source           string              `gorm:"size:255" json:"source"` // URL atau file feed
+	FetchedAt        time.Time           `gorm:"not null;index" json:"fetched_at"`
+	BaseBuyPrice     float64             `gorm:"not null" json:"base_buy_price"`
+	BaseSellPrice    float64             `gorm:"not null" json:"base_sell_price"`
+	Items            []PriceProposalItem `gorm:"type:json;serializer:json" json:"items"`
+	MaxChangePercent float64             `gorm:"default:0" json:"max_change_percent"`
+	RequiresApproval bool                `gorm:"default:true" json:"requires_approval"`
+	ApprovalReason   string              `gorm:"size:255" json:"approval_reason"`
+	Status           PriceProposalStatus `gorm:"size:20;default:'pending';index" json:"status"`
+	PriceUpdateLogID *uint               `json:"price_update_log_id,omitempty"`
+	PriceUpdateLog   *PriceUpdateLog     `gorm:"foreignKey:PriceUpdateLogID" json:"price_update_log,omitempty"`
+	ReviewedByID     *uint               `json:"reviewed_by_id,omitempty"`
+	ReviewedBy       *User               `gorm:"foreignKey:ReviewedByID" json:"reviewed_by,omitempty"`
+	ReviewedAt       *time.Time          `json:"reviewed_at,omitempty"`
+	ReviewNotes      string              `gorm:"size:255" json:"review_notes"`
+}
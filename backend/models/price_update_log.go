@@ -6,6 +6,15 @@ import (
 	"gorm.io/gorm"
 )
 
+// PriceUpdateSource defines how a price update was made
+type PriceUpdateSource string
+
+const (
+	PriceUpdateSourceManual  PriceUpdateSource = "manual"  // Diketik per kategori
+	PriceUpdateSourceDerived PriceUpdateSource = "derived" // Diturunkan dari harga dasar 24K
+	PriceUpdateSourceFeed    PriceUpdateSource = "feed"    // Dari feed harga eksternal
+)
+
 // PriceUpdateLog tracks gold category price updates
 type PriceUpdateLog struct {
 	ID             uint           `gorm:"primarykey" json:"id"`
@@ -16,6 +25,16 @@ type PriceUpdateLog struct {
 	UpdatedByID    uint           `gorm:"not null" json:"updated_by_id"`
 	UpdatedBy      *User          `gorm:"foreignKey:UpdatedByID" json:"updated_by,omitempty"`
 	Notes          string         `gorm:"size:255" json:"notes"`
+
+	// Asal update dan harga dasar 24K jika harga diturunkan dari harga dasar (lihat GoldCategory.DerivePrices)
+	Source        PriceUpdateSource `gorm:"size:20;default:'manual'" json:"source"`
+	BaseBuyPrice  *float64          `json:"base_buy_price,omitempty"`
+	BaseSellPrice *float64          `json:"base_sell_price,omitempty"`
+
+	// Lingkup update: kosong = harga global, terisi = override harga lokasi
+	LocationID *uint     `gorm:"index" json:"location_id,omitempty"`
+	Location   *Location `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+
 	PriceDetails   []PriceDetail  `gorm:"foreignKey:PriceUpdateLogID" json:"price_details,omitempty"`
 }
 
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"math"
+)
+
+// PriceRoundingMode defines how derived prices are rounded to the rounding step
+type PriceRoundingMode string
+
+const (
+	PriceRoundingNearest PriceRoundingMode = "nearest" // Pembulatan terdekat
+	PriceRoundingUp      PriceRoundingMode = "up"      // Selalu ke atas
+	PriceRoundingDown    PriceRoundingMode = "down"    // Selalu ke bawah
+)
+
+// IsValid checks whether the rounding mode is supported
+func (m PriceRoundingMode) IsValid() bool {
+	switch m {
+	case PriceRoundingNearest, PriceRoundingUp, PriceRoundingDown:
+		return true
+	}
+	return false
+}
+
+// ReferencePurity is the purity of the 24K (999) base price
+const ReferencePurity = 0.999
+
+var ErrPriceNotDerived = errors.New("price is not derived from the base price")
+
+// RoundPrice rounds value to a multiple of step; step 0 leaves the value unrounded
+func RoundPrice(value, step float64, mode PriceRoundingMode) float64 {
+	if step <= 0 {
+		return value
+	}
+	units := value / step
+	switch mode {
+	case PriceRoundingUp:
+		units = math.Ceil(units - 1e-9)
+	case PriceRoundingDown:
+		units = math.Floor(units + 1e-9)
+	default:
+		units = math.Round(units)
+	}
+	return units * step
+}
+
+// DerivePrices computes the buy and sell price per gram of this category from the 24K base prices:
+// harga dasar x kadar / 0.999, jual ditambah markup, beli dikurangi spread, lalu dibulatkan
+func (g *GoldCategory) DerivePrices(baseBuyPrice, baseSellPrice float64) (buyPrice, sellPrice float64, err error) {
+	if !g.DerivePrice || g.Purity == nil || *g.Purity <= 0 {
+		return g.BuyPrice, g.SellPrice, ErrPriceNotDerived
+	}
+	ratio := *g.Purity / ReferencePurity
+	sellPrice = RoundPrice(baseSellPrice*ratio*(1+g.SellMarkupPercent/100), g.RoundingStep, g.RoundingMode)
+	buyPrice = RoundPrice(baseBuyPrice*ratio*(1-g.BuySpreadPercent/100), g.RoundingStep, g.RoundingMode)
+	return buyPrice, sellPrice, nil
+}
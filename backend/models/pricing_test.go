@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestRoundPrice(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+		step  float64
+		mode  PriceRoundingMode
+		want  float64
+	}{
+		{name: "no step", value: 1234.56, step: 0, mode: PriceRoundingNearest, want: 1234.56},
+		{name: "negative step", value: 1234.56, step: -1000, mode: PriceRoundingUp, want: 1234.56},
+		{name: "nearest down", value: 1499.99, step: 1000, mode: PriceRoundingNearest, want: 1000},
+		{name: "nearest half rounds up", value: 1500, step: 1000, mode: PriceRoundingNearest, want: 2000},
+		{name: "nearest half step 500", value: 1250, step: 500, mode: PriceRoundingNearest, want: 1500},
+		{name: "empty mode is nearest", value: 1600, step: 1000, mode: "", want: 2000},
+		{name: "up", value: 1001, step: 1000, mode: PriceRoundingUp, want: 2000},
+		{name: "up exact multiple", value: 2000, step: 1000, mode: PriceRoundingUp, want: 2000},
+		{name: "up float noise above multiple", value: 2000.0000001, step: 1000, mode: PriceRoundingUp, want: 2000},
+		{name: "down", value: 1999, step: 1000, mode: PriceRoundingDown, want: 1000},
+		{name: "down exact multiple", value: 2000, step: 1000, mode: PriceRoundingDown, want: 2000},
+		{name: "down float noise below multiple", value: 1999.9999999, step: 1000, mode: PriceRoundingDown, want: 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundPrice(tt.value, tt.step, tt.mode); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("RoundPrice(%v, %v, %q) = %v, want %v", tt.value, tt.step, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivePrices(t *testing.T) {
+	purity := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name     string
+		category GoldCategory
+		buy      float64
+		sell     float64
+		err      error
+	}{
+		{
+			name:     "24K without markup",
+			category: GoldCategory{DerivePrice: true, Purity: purity(0.999)},
+			buy:      1000000,
+			sell:     1100000,
+		},
+		{
+			name: "75% with markup, spread and rounding",
+			category: GoldCategory{
+				DerivePrice: true, Purity: purity(0.75),
+				SellMarkupPercent: 10, BuySpreadPercent: 5,
+				RoundingStep: 1000, RoundingMode: PriceRoundingNearest,
+			},
+			buy:  713000, // 1.000.000 x 0.75/0.999 x 0.95 = 713.213
+			sell: 908000, // 1.100.000 x 0.75/0.999 x 1.10 = 908.408
+		},
+		{
+			name: "rounding up",
+			category: GoldCategory{
+				DerivePrice: true, Purity: purity(0.75),
+				RoundingStep: 1000, RoundingMode: PriceRoundingUp,
+			},
+			buy:  751000, // 750.750
+			sell: 826000, // 825.825
+		},
+		{
+			name:     "manual price",
+			category: GoldCategory{Purity: purity(0.75), BuyPrice: 700000, SellPrice: 800000},
+			buy:      700000,
+			sell:     800000,
+			err:      ErrPriceNotDerived,
+		},
+		{
+			name:     "no purity",
+			category: GoldCategory{DerivePrice: true, BuyPrice: 1, SellPrice: 2},
+			buy:      1,
+			sell:     2,
+			err:      ErrPriceNotDerived,
+		},
+		{
+			name:     "zero purity",
+			category: GoldCategory{DerivePrice: true, Purity: purity(0), BuyPrice: 1, SellPrice: 2},
+			buy:      1,
+			sell:     2,
+			err:      ErrPriceNotDerived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buy, sell, err := tt.category.DerivePrices(1000000, 1100000)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if math.Abs(buy-tt.buy) > 1e-6 || math.Abs(sell-tt.sell) > 1e-6 {
+				t.Errorf("prices = %v/%v, want %v/%v", buy, sell, tt.buy, tt.sell)
+			}
+		})
+	}
+}
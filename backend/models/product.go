@@ -6,7 +6,8 @@ import (
 	"gorm.io/gorm"
 )
 
-// ProductType defines the type of jewelry
+// ProductType is the code of a product type from the product_types master table.
+// The constants below are the types seeded by default.
 type ProductType string
 
 const (
@@ -16,6 +17,8 @@ const (
 	ProductTypeAnting  ProductType = "anting"
 	ProductTypeLiontin ProductType = "liontin"
 	ProductTypeOther   ProductType = "other"
+
+	ProductTypeLogamMulia ProductType = "logam_mulia" // Dipakai oleh produk jenis bullion
 )
 
 // ProductCategory defines target customer category
@@ -27,6 +30,14 @@ const (
 	ProductCategoryUnisex ProductCategory = "unisex"
 )
 
+// ProductKind defines how a product is priced
+type ProductKind string
+
+const (
+	ProductKindJewelry ProductKind = "jewelry" // Perhiasan: harga per gram kategori emas + batu + ongkos
+	ProductKindBullion ProductKind = "bullion" // Logam mulia bersertifikat: harga per keping, tanpa ongkos
+)
+
 // Product represents jewelry product master data
 type Product struct {
 	ID             uint            `gorm:"primarykey" json:"id"`
@@ -39,33 +50,56 @@ type Product struct {
 	Category       ProductCategory `gorm:"not null;size:20;index" json:"category"` // dewasa, anak
 	GoldCategoryID uint            `gorm:"not null;index" json:"gold_category_id"` // FK to GoldCategory
 	GoldCategory   GoldCategory    `gorm:"foreignKey:GoldCategoryID" json:"gold_category,omitempty"`
-	Weight         float64         `gorm:"not null" json:"weight"` // Berat dalam gram
+	Weight         float64         `gorm:"not null" json:"weight"`         // Berat emas dalam gram (bersih dari batu)
+	MakingCharge   float64         `gorm:"default:0" json:"making_charge"` // Ongkos pembuatan per buah, ditambahkan ke harga jual
 	Description    string          `gorm:"size:500" json:"description"`
 
-	// Specifications based on product type
-	RingSize       string  `gorm:"size:10" json:"ring_size,omitempty"`    // Lingkar jari untuk cincin
-	BraceletLength float64 `json:"bracelet_length,omitempty"`             // Panjang untuk gelang (cm)
-	NecklaceLength float64 `json:"necklace_length,omitempty"`             // Panjang untuk kalung (cm)
-	EarringType    string  `gorm:"size:50" json:"earring_type,omitempty"` // Tipe anting (tusuk, jepit, etc)
+	// Specifications based on product type (ukuran cincin, panjang gelang/kalung, ...) - see ProductTypeDefinition
+	Attributes []ProductAttributeValue `gorm:"foreignKey:ProductID" json:"attributes,omitempty"`
 
 	// Additional info
 	ImageURL string `gorm:"size:255" json:"image_url,omitempty"`
 	IsActive bool   `gorm:"default:true" json:"is_active"`
 
+	// Jenis harga dan, untuk logam mulia, produsen dan harga per keping (mengikuti harga publikasi produsen)
+	Kind                  ProductKind `gorm:"not null;size:20;default:'jewelry';index" json:"kind"` // jewelry, bullion
+	Manufacturer          string      `gorm:"size:50" json:"manufacturer,omitempty"`                // Antam, UBS, ...
+	BullionBuyPrice       float64     `gorm:"default:0" json:"bullion_buy_price"`                   // Harga buyback per keping
+	BullionSellPrice      float64     `gorm:"default:0" json:"bullion_sell_price"`                  // Harga jual per keping
+	BullionPriceUpdatedAt *time.Time  `json:"bullion_price_updated_at,omitempty"`
+
+	// Batu pada desain produk (berlian, batu warna, ...)
+	Stones []StoneComponent `gorm:"foreignKey:ProductID" json:"stones,omitempty"`
+
+	// Foto desain produk. ImageURL mengikuti foto utama
+	Images []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"`
+
 	// Relations
 	Stocks []Stock `gorm:"foreignKey:ProductID" json:"stocks,omitempty"`
 }
 
-// CalculateSellPrice calculates the selling price based on gold category and weight
+// IsBullion reports whether the product is a certified bullion bar priced per piece
+func (p *Product) IsBullion() bool {
+	return p.Kind == ProductKindBullion
+}
+
+// CalculateSellPrice calculates the selling price based on gold category and weight,
+// plus stones and making charge. Logam mulia memakai harga per keping.
 func (p *Product) CalculateSellPrice() float64 {
+	if p.IsBullion() {
+		return p.BullionSellPrice
+	}
 	if p.GoldCategory.SellPrice > 0 {
-		return p.GoldCategory.SellPrice * p.Weight
+		return p.GoldCategory.SellPrice*p.Weight + StonesValue(p.Stones) + p.MakingCharge
 	}
 	return 0
 }
 
 // CalculateBuyPrice calculates the buying price based on gold category and weight
 func (p *Product) CalculateBuyPrice() float64 {
+	if p.IsBullion() {
+		return p.BullionBuyPrice
+	}
 	if p.GoldCategory.BuyPrice > 0 {
 		return p.GoldCategory.BuyPrice * p.Weight
 	}
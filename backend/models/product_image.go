@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductImage is an uploaded photo of a product design or of a single stock piece.
+// Exactly one owner is filled. Foto per buah didahulukan dari foto desain produk.
+type ProductImage struct {
+	ID           uint           `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ProductID    *uint          `gorm:"index" json:"product_id,omitempty"`
+	StockID      *uint          `gorm:"index" json:"stock_id,omitempty"`
+	StorageKey   string         `gorm:"not null;size:255" json:"-"` // Key file asli di file store
+	ThumbKey     string         `gorm:"not null;size:255" json:"-"` // Key thumbnail di file store
+	URL          string         `gorm:"not null;size:500" json:"url"`
+	ThumbnailURL string         `gorm:"not null;size:500" json:"thumbnail_url"`
+	ContentType  string         `gorm:"size:50" json:"content_type"` // image/jpeg, image/png, image/webp
+	Width        int            `json:"width"`
+	Height       int            `json:"height"`
+	SizeBytes    int64          `json:"size_bytes"`
+	SortOrder    int            `gorm:"default:0" json:"sort_order"`
+	IsPrimary    bool           `gorm:"default:false" json:"is_primary"` // Foto utama untuk struk dan tampilan scan
+	UploadedByID *uint          `json:"uploaded_by_id,omitempty"`
+	UploadedBy   *User          `gorm:"foreignKey:UploadedByID" json:"uploaded_by,omitempty"`
+}
+
+// PrimaryImage returns the primary image of a set, falling back to the first one
+func PrimaryImage(images []ProductImage) *ProductImage {
+	for i := range images {
+		if images[i].IsPrimary {
+			return &images[i]
+		}
+	}
+	if len(images) > 0 {
+		return &images[0]
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductTypeDefinition is the master record of a product type (gelang, cincin, bros, ...) and its attribute schema
+type ProductTypeDefinition struct {
+	ID            uint                         `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt               `gorm:"index" json:"-"`
+	Code          ProductType                  `gorm:"not null;size:20" json:"code"` // Disimpan di products.type - unique index created manually in migration
+	Name          string                       `gorm:"not null;size:50" json:"name"`
+	BarcodePrefix string                       `gorm:"size:5" json:"barcode_prefix"` // Awalan barcode produk, contoh: CIN
+	Description   string                       `gorm:"size:255" json:"description"`
+	SortOrder     int                          `gorm:"default:0" json:"sort_order"`
+	IsActive      bool                         `gorm:"default:true" json:"is_active"`
+	Attributes    []ProductAttributeDefinition `gorm:"foreignKey:ProductTypeID" json:"attributes,omitempty"`
+}
+
+func (ProductTypeDefinition) TableName() string {
+	return "product_types"
+}
+
+// AttributeDataType defines how an attribute value is validated and stored
+type AttributeDataType string
+
+const (
+	AttributeTypeText    AttributeDataType = "text"    // Teks bebas (atau salah satu allowed_values)
+	AttributeTypeNumber  AttributeDataType = "number"  // Angka desimal
+	AttributeTypeInteger AttributeDataType = "integer" // Angka bulat
+	AttributeTypeBoolean AttributeDataType = "boolean" // Ya/tidak
+	AttributeTypeSelect  AttributeDataType = "select"  // Wajib salah satu allowed_values
+)
+
+// IsValid checks whether the data type is supported
+func (t AttributeDataType) IsValid() bool {
+	switch t {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeInteger, AttributeTypeBoolean, AttributeTypeSelect:
+		return true
+	}
+	return false
+}
+
+// IsNumeric reports whether values of this type are stored in NumberValue
+func (t AttributeDataType) IsNumeric() bool {
+	return t == AttributeTypeNumber || t == AttributeTypeInteger
+}
+
+// ProductAttributeDefinition is one attribute in the schema of a product type
+type ProductAttributeDefinition struct {
+	ID            uint              `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index" json:"-"`
+	ProductTypeID uint              `gorm:"not null;index" json:"product_type_id"`
+	Code          string            `gorm:"not null;size:50" json:"code"` // Kunci atribut, contoh: ring_size - unique per type, created manually in migration
+	Name          string            `gorm:"not null;size:100" json:"name"`
+	DataType      AttributeDataType `gorm:"not null;size:20;default:'text'" json:"data_type"`
+	Unit          string            `gorm:"size:20" json:"unit"` // cm, mm, gram, ...
+	Required      bool              `gorm:"default:false" json:"required"`
+	AllowedValues []string          `gorm:"type:json;serializer:json" json:"allowed_values"`
+	MinValue      *float64          `json:"min_value,omitempty"`
+	MaxValue      *float64          `json:"max_value,omitempty"`
+	SortOrder     int               `gorm:"default:0" json:"sort_order"`
+}
+
+// ProductAttributeValue is a validated attribute value of a product
+type ProductAttributeValue struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ProductID   uint           `gorm:"not null;index" json:"product_id"`
+	AttributeID uint           `gorm:"not null;index" json:"attribute_id"`
+	Code        string         `gorm:"not null;size:50;index" json:"code"`
+	Value       string         `gorm:"not null;size:255;index" json:"value"` // Nilai ternormalisasi untuk tampilan dan pencarian
+	NumberValue *float64       `gorm:"index" json:"number_value,omitempty"`  // Terisi untuk atribut angka, untuk filter rentang
+	Unit        string         `gorm:"size:20" json:"unit,omitempty"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductionOrderStatus defines the status of a production order
+type ProductionOrderStatus string
+
+const (
+	ProductionOrderStatusDraft     ProductionOrderStatus = "draft"     // Bahan sudah ditentukan, sedang dikerjakan
+	ProductionOrderStatusCompleted ProductionOrderStatus = "completed" // Barang jadi sudah masuk stok
+	ProductionOrderStatusCancelled ProductionOrderStatus = "cancelled"
+)
+
+// ProductionOrder turns raw material (bahan baku atau hasil lebur) into new finished stock
+type ProductionOrder struct {
+	ID             uint                  `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt        `gorm:"index" json:"-"`
+	OrderNumber    string                `gorm:"not null;size:30" json:"order_number"` // unique index created manually in migration
+	LocationID     uint                  `gorm:"not null;index" json:"location_id"`
+	Location       Location              `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	GoldCategoryID uint                  `gorm:"not null;index" json:"gold_category_id"` // Kadar barang jadi
+	GoldCategory   GoldCategory          `gorm:"foreignKey:GoldCategoryID" json:"gold_category,omitempty"`
+	Status         ProductionOrderStatus `gorm:"not null;size:20;default:'draft';index" json:"status"`
+	CraftsmanName  string                `gorm:"size:100" json:"craftsman_name"` // Pengrajin
+	CreatedByID    uint                  `gorm:"not null" json:"created_by_id"`
+	CreatedBy      User                  `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	CompletedAt    *time.Time            `json:"completed_at,omitempty"`
+	CompletedByID  *uint                 `json:"completed_by_id,omitempty"`
+	Notes          string                `gorm:"size:500" json:"notes"`
+
+	// Bahan masuk
+	InputWeight     float64 `gorm:"default:0" json:"input_weight"`
+	InputFineWeight float64 `gorm:"default:0" json:"input_fine_weight"` // Emas murni bahan
+	InputCost       float64 `gorm:"default:0" json:"input_cost"`
+
+	// Hasil
+	OutputWeight       float64 `gorm:"default:0" json:"output_weight"`      // Berat barang jadi
+	OutputFineWeight   float64 `gorm:"default:0" json:"output_fine_weight"` // Emas murni barang jadi
+	OutputQuantity     int     `gorm:"default:0" json:"output_quantity"`
+	ScrapWeight        float64 `gorm:"default:0" json:"scrap_weight"`      // Sisa/serbuk yang dikembalikan
+	ScrapFineWeight    float64 `gorm:"default:0" json:"scrap_fine_weight"` // Emas murni sisa
+	ScrapRawMaterialID *uint   `json:"scrap_raw_material_id,omitempty"`    // Sisa dicatat sebagai bahan baku
+
+	// Susut produksi (dalam emas murni): input - output - sisa
+	YieldLossFineWeight float64 `gorm:"default:0" json:"yield_loss_fine_weight"`
+	YieldLossPercent    float64 `gorm:"default:0" json:"yield_loss_percent"`
+
+	// Supervisor yang menyetujui susut di atas batas
+	YieldLossApprovedByID *uint `json:"yield_loss_approved_by_id,omitempty"`
+	YieldLossApprovedBy   *User `gorm:"foreignKey:YieldLossApprovedByID" json:"yield_loss_approved_by,omitempty"`
+
+	// Biaya
+	LaborCost   float64 `gorm:"default:0" json:"labor_cost"` // Ongkos pengrajin
+	OtherCost   float64 `gorm:"default:0" json:"other_cost"`
+	ScrapCost   float64 `gorm:"default:0" json:"scrap_cost"`    // Porsi biaya bahan yang ikut ke sisa
+	TotalCost   float64 `gorm:"default:0" json:"total_cost"`    // Biaya yang dialokasikan ke barang jadi
+	CostPerGram float64 `gorm:"default:0" json:"cost_per_gram"` // total_cost / output_weight
+
+	// Relations
+	Inputs  []ProductionInput  `gorm:"foreignKey:ProductionOrderID" json:"inputs,omitempty"`
+	Outputs []ProductionOutput `gorm:"foreignKey:ProductionOrderID" json:"outputs,omitempty"`
+}
+
+// ProductionInput is raw material weight consumed by a production order,
+// either (part of) a raw material or a whole melted batch
+type ProductionInput struct {
+	ID                uint           `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ProductionOrderID uint           `gorm:"not null;index" json:"production_order_id"`
+	RawMaterialID     *uint          `gorm:"index" json:"raw_material_id,omitempty"`
+	RawMaterial       *RawMaterial   `gorm:"foreignKey:RawMaterialID" json:"raw_material,omitempty"`
+	MeltBatchID       *uint          `gorm:"index" json:"melt_batch_id,omitempty"`
+	MeltBatch         *MeltBatch     `gorm:"foreignKey:MeltBatchID" json:"melt_batch,omitempty"`
+	Weight            float64        `gorm:"not null" json:"weight"`  // Berat yang dipakai (gram)
+	Purity            float64        `gorm:"default:0" json:"purity"` // Kadar (fraksi, 0.750)
+	FineWeight        float64        `gorm:"default:0" json:"fine_weight"`
+	Cost              float64        `gorm:"default:0" json:"cost"`
+}
+
+// ProductionOutput is one produced product line; each piece becomes a Stock with its actual weight
+type ProductionOutput struct {
+	ID                uint           `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ProductionOrderID uint           `gorm:"not null;index" json:"production_order_id"`
+	ProductID         uint           `gorm:"not null;index" json:"product_id"`
+	Product           Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	StorageBoxID      uint           `gorm:"not null" json:"storage_box_id"`
+	Quantity          int            `gorm:"not null" json:"quantity"`
+	PieceWeights      []float64      `gorm:"type:json;serializer:json" json:"piece_weights"` // Berat aktual per buah
+	TotalWeight       float64        `gorm:"default:0" json:"total_weight"`
+	FineWeight        float64        `gorm:"default:0" json:"fine_weight"`
+	TotalCost         float64        `gorm:"default:0" json:"total_cost"`
+}
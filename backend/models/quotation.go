@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuotationStatus defines the state of a quotation
+type QuotationStatus string
+
+const (
+	QuotationStatusActive    QuotationStatus = "active"    // Berlaku, buah ditahan sampai valid_until
+	QuotationStatusConverted QuotationStatus = "converted" // Sudah menjadi penjualan
+	QuotationStatusExpired   QuotationStatus = "expired"   // Lewat masa berlaku, buah dilepas
+	QuotationStatusCancelled QuotationStatus = "cancelled" // Dibatalkan, buah dilepas
+)
+
+// Quotation locks the price of stock pieces for a customer during a validity window.
+// Buah hanya ditahan (soft reserve): status stok tetap available, tetapi tidak bisa dijual atau ditawarkan ke pembeli lain.
+type Quotation struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt  `gorm:"index" json:"-"`
+	QuotationNumber string          `gorm:"not null;size:50" json:"quotation_number"` // unique index created manually in migration
+	LocationID      uint            `gorm:"not null;index" json:"location_id"`
+	Location        *Location       `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	MemberID        *uint           `gorm:"index" json:"member_id,omitempty"`
+	Member          *Member         `gorm:"foreignKey:MemberID" json:"member,omitempty"`
+	CustomerName    string          `gorm:"size:100" json:"customer_name"`
+	CustomerPhone   string          `gorm:"size:20" json:"customer_phone"`
+	ValidUntil      time.Time       `gorm:"not null;index" json:"valid_until"`
+	Status          QuotationStatus `gorm:"size:20;default:'active';index" json:"status"`
+	SubTotal        float64         `gorm:"not null;default:0" json:"sub_total"`
+	Notes           string          `gorm:"size:255" json:"notes"`
+	CreatedByID     uint            `gorm:"not null" json:"created_by_id"`
+	CreatedBy       *User           `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	TransactionID   *uint           `json:"transaction_id,omitempty"` // Penjualan dari penawaran ini
+	Transaction     *Transaction    `gorm:"foreignKey:TransactionID" json:"transaction,omitempty"`
+	ClosedAt        *time.Time      `json:"closed_at,omitempty"` // Saat dikonversi, kedaluwarsa atau dibatalkan
+	Items           []QuotationItem `gorm:"foreignKey:QuotationID" json:"items,omitempty"`
+}
+
+// QuotationItem is one stock piece with its price locked at quotation time
+type QuotationItem struct {
+	ID           uint           `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	QuotationID  uint           `gorm:"not null;index" json:"quotation_id"`
+	StockID      uint           `gorm:"not null;index" json:"stock_id"`
+	Stock        *Stock         `gorm:"foreignKey:StockID" json:"stock,omitempty"`
+	ItemName     string         `gorm:"not null;size:100" json:"item_name"`
+	Barcode      string         `gorm:"size:50" json:"barcode"`
+	SerialNumber string         `gorm:"size:50" json:"serial_number"`
+	Weight       float64        `gorm:"not null" json:"weight"`
+	PricePerGram float64        `gorm:"not null" json:"price_per_gram"`
+	UnitPrice    float64        `gorm:"not null" json:"unit_price"` // Harga yang dikunci
+	GoldValue    float64        `gorm:"default:0" json:"gold_value"`
+	StoneValue   float64        `gorm:"default:0" json:"stone_value"`
+	MakingCharge float64        `gorm:"default:0" json:"making_charge"`
+}
+
+// IsValidAt reports whether the quotation still holds its pieces and prices at time t
+func (q *Quotation) IsValidAt(t time.Time) bool {
+	return q.Status == QuotationStatusActive && t.Before(q.ValidUntil)
+}
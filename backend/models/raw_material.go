@@ -22,6 +22,16 @@ const (
 	RawMaterialConditionDamaged   RawMaterialCondition = "damaged"
 )
 
+// IsValid checks whether the condition is one of the known conditions
+func (c RawMaterialCondition) IsValid() bool {
+	switch c {
+	case RawMaterialConditionNew, RawMaterialConditionLikeNew, RawMaterialConditionScratched,
+		RawMaterialConditionDented, RawMaterialConditionDamaged:
+		return true
+	}
+	return false
+}
+
 type RawMaterial struct {
 	ID               uint                 `gorm:"primarykey" json:"id"`
 	CreatedAt        time.Time            `json:"created_at"`
@@ -44,13 +54,48 @@ type RawMaterial struct {
 	MemberID         *uint                `json:"member_id"`
 	Member           *Member              `json:"member,omitempty" gorm:"foreignKey:MemberID"`
 	TransactionID    *uint                `json:"transaction_id"`
+	SourceStockID    *uint                `json:"source_stock_id"` // Stok asal (write-off barang rusak/lebur)
 	ReceivedAt       *time.Time           `json:"received_at"`
 	ReceivedByID     *uint                `json:"received_by_id"`
 	ReceivedBy       *User                `json:"received_by,omitempty" gorm:"foreignKey:ReceivedByID"`
 	ProcessedAt      *time.Time           `json:"processed_at"`
+	WeightSource     WeightSource         `json:"weight_source" gorm:"size:10;default:'typed'"`
+	ScaleReadingID   *uint                `json:"scale_reading_id"`
 	Notes            string               `json:"notes"`
+	Stones           []StoneComponent     `json:"stones,omitempty" gorm:"foreignKey:RawMaterialID"` // Batu yang dilepas dari barang setor
 }
 
 func (RawMaterial) TableName() string {
 	return "raw_materials"
 }
+
+// PurityFraction converts a manually entered purity to a fraction (0.750 for 75%).
+// Kadar yang diisi manual bisa berupa persen (75), per mil (750) atau pecahan (0.75); 0 jika kosong.
+func PurityFraction(purity float64) float64 {
+	switch {
+	case purity > 100:
+		return purity / 1000
+	case purity > 1:
+		return purity / 100
+	case purity > 0:
+		return purity
+	}
+	return 0
+}
+
+// PurityFraction returns the gold content as a fraction (0.750 for 75%).
+// Tanpa kadar yang diisi manual, pakai kadar kategori emas.
+func (r *RawMaterial) PurityFraction() float64 {
+	if purity := PurityFraction(r.Purity); purity > 0 {
+		return purity
+	}
+	if r.GoldCategory != nil && r.GoldCategory.Purity != nil {
+		return *r.GoldCategory.Purity
+	}
+	return 0
+}
+
+// FineWeight returns the pure gold content (gram) of the net weight
+func (r *RawMaterial) FineWeight() float64 {
+	return r.WeightGrams * r.PurityFraction()
+}
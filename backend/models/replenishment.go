@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReplenishmentRule defines the min/max stock level of one product segment at a location
+type ReplenishmentRule struct {
+	ID                 uint            `gorm:"primarykey" json:"id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt  `gorm:"index" json:"-"`
+	LocationID         uint            `gorm:"not null;index" json:"location_id"` // Toko yang diisi ulang
+	Location           Location        `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	SourceLocationID   *uint           `gorm:"index" json:"source_location_id,omitempty"` // Gudang sumber, kosong = semua gudang
+	SourceLocation     *Location       `gorm:"foreignKey:SourceLocationID" json:"source_location,omitempty"`
+	ProductType        ProductType     `gorm:"not null;size:20;index" json:"product_type"`
+	ProductCategory    ProductCategory `gorm:"size:20" json:"product_category"`         // Kosong = semua kategori
+	GoldCategoryID     *uint           `gorm:"index" json:"gold_category_id,omitempty"` // Kosong = semua kadar
+	GoldCategory       *GoldCategory   `gorm:"foreignKey:GoldCategoryID" json:"gold_category,omitempty"`
+	MinQty             int             `gorm:"not null;default:0" json:"min_qty"`
+	MaxQty             int             `gorm:"not null;default:0" json:"max_qty"`
+	LeadTimeDays       int             `gorm:"not null;default:3" json:"lead_time_days"`        // Lama kirim gudang ke toko
+	VelocityWindowDays int             `gorm:"not null;default:30" json:"velocity_window_days"` // Jendela penjualan untuk kecepatan jual
+	TargetBoxID        *uint           `json:"target_box_id,omitempty"`                         // Box tujuan default di toko
+	TargetBox          *StorageBox     `gorm:"foreignKey:TargetBoxID" json:"target_box,omitempty"`
+	IsActive           bool            `gorm:"default:true" json:"is_active"`
+	Notes              string          `gorm:"size:255" json:"notes"`
+}
+
+// ReplenishmentSuggestionStatus defines the status of a replenishment suggestion
+type ReplenishmentSuggestionStatus string
+
+const (
+	ReplenishmentSuggestionPending    ReplenishmentSuggestionStatus = "pending"    // Menunggu keputusan staf
+	ReplenishmentSuggestionAccepted   ReplenishmentSuggestionStatus = "accepted"   // Dibuatkan draft transfer
+	ReplenishmentSuggestionDismissed  ReplenishmentSuggestionStatus = "dismissed"  // Diabaikan staf
+	ReplenishmentSuggestionSuperseded ReplenishmentSuggestionStatus = "superseded" // Digantikan saran yang lebih baru
+)
+
+// ReplenishmentSuggestion is a proposed gudang→toko transfer generated from sales velocity
+type ReplenishmentSuggestion struct {
+	ID                uint                          `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time                     `json:"created_at"`
+	UpdatedAt         time.Time                     `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt                `gorm:"index" json:"-"`
+	RuleID            uint                          `gorm:"not null;index" json:"rule_id"`
+	Rule              ReplenishmentRule             `gorm:"foreignKey:RuleID" json:"rule,omitempty"`
+	LocationID        uint                          `gorm:"not null;index" json:"location_id"`
+	Location          Location                      `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	SourceLocationID  uint                          `gorm:"not null;index" json:"source_location_id"`
+	SourceLocation    Location                      `gorm:"foreignKey:SourceLocationID" json:"source_location,omitempty"`
+	OnHand            int                           `json:"on_hand"`        // Stok toko saat dihitung
+	SoldInWindow      int                           `json:"sold_in_window"` // Terjual dalam jendela penjualan
+	Velocity          float64                       `json:"velocity"`       // Rata-rata terjual per hari
+	DaysOfCover       float64                       `json:"days_of_cover"`  // Stok cukup untuk berapa hari, -1 = tidak ada penjualan
+	SuggestedQty      int                           `json:"suggested_qty"`
+	AvailableAtSource int                           `json:"available_at_source"`
+	StockIDs          []uint                        `gorm:"type:json;serializer:json" json:"stock_ids"` // Barang gudang yang diusulkan (FIFO)
+	Status            ReplenishmentSuggestionStatus `gorm:"not null;size:20;default:'pending';index" json:"status"`
+	GeneratedAt       time.Time                     `gorm:"not null" json:"generated_at"`
+	DecidedByID       *uint                         `json:"decided_by_id,omitempty"`
+	DecidedBy         *User                         `gorm:"foreignKey:DecidedByID" json:"decided_by,omitempty"`
+	DecidedAt         *time.Time                    `json:"decided_at,omitempty"`
+	TransferNumber    string                        `gorm:"size:50" json:"transfer_number,omitempty"` // Nomor draft transfer yang dibuat
+	Notes             string                        `gorm:"size:255" json:"notes"`
+}
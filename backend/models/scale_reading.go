@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WeightSource records how a weight was entered
+type WeightSource string
+
+const (
+	WeightSourceTyped   WeightSource = "typed"   // Diketik manual
+	WeightSourceWeighed WeightSource = "weighed" // Dari timbangan (scale reading)
+)
+
+// ScaleReadingTarget is the record a scale reading was used for
+type ScaleReadingTarget string
+
+const (
+	ScaleReadingTargetStock           ScaleReadingTarget = "stock"
+	ScaleReadingTargetTransactionItem ScaleReadingTarget = "transaction_item"
+	ScaleReadingTargetRawMaterial     ScaleReadingTarget = "raw_material"
+)
+
+// ScaleReading is a stable weight pushed by a workstation scale.
+// Setiap reading hanya boleh dipakai sekali (stok, baris setor, atau bahan baku).
+type ScaleReading struct {
+	ID           uint               `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt     `gorm:"index" json:"-"`
+	LocationID   uint               `gorm:"not null;index" json:"location_id"`
+	Location     *Location          `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	DeviceID     string             `gorm:"size:100;index" json:"device_id"` // Identitas workstation/timbangan
+	Weight       float64            `gorm:"not null" json:"weight"`          // Berat dalam gram
+	Unit         string             `gorm:"size:10" json:"unit"`             // Satuan asli dari timbangan
+	RawLine      string             `gorm:"size:100" json:"raw_line"`        // Output mentah timbangan
+	ReadAt       time.Time          `gorm:"not null" json:"read_at"`
+	RecordedByID uint               `gorm:"not null" json:"recorded_by_id"`
+	RecordedBy   *User              `gorm:"foreignKey:RecordedByID" json:"recorded_by,omitempty"`
+	UsedAt       *time.Time         `gorm:"index" json:"used_at,omitempty"`
+	TargetType   ScaleReadingTarget `gorm:"size:30" json:"target_type,omitempty"`
+	TargetID     *uint              `json:"target_id,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ScheduledPriceStatus defines the state of an effective-dated price update
+type ScheduledPriceStatus string
+
+const (
+	ScheduledPriceStatusScheduled  ScheduledPriceStatus = "scheduled"  // Menunggu waktu berlaku
+	ScheduledPriceStatusApplied    ScheduledPriceStatus = "applied"    // Sudah diterapkan oleh worker
+	ScheduledPriceStatusCancelled  ScheduledPriceStatus = "cancelled"  // Dibatalkan sebelum berlaku
+	ScheduledPriceStatusFailed     ScheduledPriceStatus = "failed"     // Gagal diterapkan, lihat Error
+	ScheduledPriceStatusSuperseded ScheduledPriceStatus = "superseded" // Tidak diterapkan karena ada update harga global yang lebih baru
+)
+
+// ScheduledPriceItem is the new price of one gold category in a scheduled update
+type ScheduledPriceItem struct {
+	GoldCategoryID uint    `json:"gold_category_id"`
+	BuyPrice       float64 `json:"buy_price"`
+	SellPrice      float64 `json:"sell_price"`
+}
+
+// ScheduledPriceUpdate is a price update set in advance (contoh: harga buka besok pagi, disiapkan malam ini).
+// A background worker applies it once at EffectiveAt and links the resulting PriceUpdateLog.
+type ScheduledPriceUpdate struct {
+	ID               uint                 `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt       `gorm:"index" json:"-"`
+	EffectiveAt      time.Time            `gorm:"not null;index" json:"effective_at"`
+	Status           ScheduledPriceStatus `gorm:"size:20;default:'scheduled';index" json:"status"`
+	Items            []ScheduledPriceItem `gorm:"type:json;serializer:json" json:"items"`
+	Source           PriceUpdateSource    `gorm:"size:20;default:'manual'" json:"source"`
+	BaseBuyPrice     *float64             `json:"base_buy_price,omitempty"`
+	BaseSellPrice    *float64             `json:"base_sell_price,omitempty"`
+	Notes            string               `gorm:"size:255" json:"notes"`
+	CreatedByID      uint                 `gorm:"not null" json:"created_by_id"`
+	CreatedBy        *User                `gorm:"foreignKey:CreatedByID" json:"created_by,omitempty"`
+	AppliedAt        *time.Time           `json:"applied_at,omitempty"`
+	PriceUpdateLogID *uint                `json:"price_update_log_id,omitempty"`
+	PriceUpdateLog   *PriceUpdateLog      `gorm:"foreignKey:PriceUpdateLogID" json:"price_update_log,omitempty"`
+	CancelledByID    *uint                `json:"cancelled_by_id,omitempty"`
+	CancelledAt      *time.Time           `json:"cancelled_at,omitempty"`
+	Error            string               `gorm:"size:255" json:"error,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SetorConditionRule is the deduction from the reference buy price for setor gold in a given condition
+type SetorConditionRule struct {
+	ID               uint                 `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt       `gorm:"index" json:"-"`
+	Condition        RawMaterialCondition `gorm:"not null;size:20" json:"condition"`           // unique index created manually in migration
+	DeductionPercent float64              `gorm:"not null;default:0" json:"deduction_percent"` // Potongan dari harga beli acuan (%)
+	Notes            string               `gorm:"size:255" json:"notes"`
+	UpdatedByID      *uint                `json:"updated_by_id,omitempty"`
+}
@@ -10,14 +10,16 @@ import (
 type StockStatus string
 
 const (
-	StockStatusAvailable StockStatus = "available" // Ready for sale
-	StockStatusReserved  StockStatus = "reserved"  // Reserved for order
-	StockStatusSold      StockStatus = "sold"      // Already sold
-	StockStatusTransfer  StockStatus = "transfer"  // In transfer between locations
+	StockStatusAvailable  StockStatus = "available"   // Ready for sale
+	StockStatusReserved   StockStatus = "reserved"    // Reserved for order
+	StockStatusSold       StockStatus = "sold"        // Already sold
+	StockStatusTransfer   StockStatus = "transfer"    // In transfer between locations
+	StockStatusWrittenOff StockStatus = "written_off" // Removed by an approved write-off
 )
 
 // Stock represents individual stock item with location tracking
-// Harga tidak disimpan di sini - selalu dihitung dari gold_category.sell_price * product.weight
+// Harga jual tidak disimpan di sini - selalu dihitung dari gold_category.sell_price * berat (net_weight, atau product.weight)
+// Harga modal (cost_price) disimpan saat penerimaan barang untuk laporan margin
 type Stock struct {
 	ID           uint           `gorm:"primarykey" json:"id"`
 	CreatedAt    time.Time      `json:"created_at"`
@@ -34,8 +36,36 @@ type Stock struct {
 	Notes        string         `gorm:"size:255" json:"notes"`
 
 	// Source tracking
-	SupplierName string     `gorm:"size:100" json:"supplier_name,omitempty"` // Name of supplier/distributor
-	ReceivedAt   *time.Time `json:"received_at,omitempty"`                   // When received at location
+	SupplierName       string        `gorm:"size:100" json:"supplier_name,omitempty"` // Name of supplier/distributor
+	SupplierID         *uint         `gorm:"index" json:"supplier_id,omitempty"`
+	Supplier           *Supplier     `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	GoodsReceiptID     *uint         `gorm:"index" json:"goods_receipt_id,omitempty"` // Penerimaan barang asal stok ini
+	GoodsReceipt       *GoodsReceipt `gorm:"foreignKey:GoodsReceiptID" json:"goods_receipt,omitempty"`
+	GoodsReceiptItemID *uint         `gorm:"index" json:"goods_receipt_item_id,omitempty"`
+	ProductionOrderID  *uint         `gorm:"index" json:"production_order_id,omitempty"` // Produksi sendiri asal stok ini
+	ReceivedAt         *time.Time    `json:"received_at,omitempty"`                      // When received at location
+
+	// Actual measured weight of this piece (gram). 0 = not measured, use product weight
+	GrossWeight        float64      `gorm:"default:0" json:"gross_weight"` // Berat kotor (termasuk batu/aksesoris)
+	NetWeight          float64      `gorm:"default:0" json:"net_weight"`   // Berat bersih emas
+	WeightVerifiedAt   *time.Time   `json:"weight_verified_at,omitempty"`
+	WeightVerifiedByID *uint        `json:"weight_verified_by_id,omitempty"`
+	WeightSource       WeightSource `gorm:"size:10;default:'typed'" json:"weight_source"`
+	ScaleReadingID     *uint        `json:"scale_reading_id,omitempty"`
+
+	// Cost basis (harga modal) - dicatat saat barang diterima, tidak mengikuti harga harian
+	CostPrice   float64 `gorm:"default:0" json:"cost_price"`    // Harga modal per buah
+	CostPerGram float64 `gorm:"default:0" json:"cost_per_gram"` // Harga modal per gram (jika dibeli per gram)
+
+	// Logam mulia: nomor sertifikat produsen per keping
+	CertificateNumber string `gorm:"size:50;index" json:"certificate_number,omitempty"` // unique per manufacturer among unsold stock, created manually in migration
+	Manufacturer      string `gorm:"size:50" json:"manufacturer,omitempty"`
+
+	// Batu per buah (dengan nomor sertifikat). Kosong = mengikuti batu desain produk
+	Stones []StoneComponent `gorm:"foreignKey:StockID" json:"stones,omitempty"`
+
+	// Foto per buah. Kosong = mengikuti foto desain produk
+	Images []ProductImage `gorm:"foreignKey:StockID" json:"images,omitempty"`
 
 	// Sales tracking
 	SoldAt        *time.Time `json:"sold_at,omitempty"`
@@ -46,6 +76,58 @@ type Stock struct {
 	BarcodePrintedAt *time.Time `json:"barcode_printed_at,omitempty"`
 }
 
+// EffectiveWeight returns the measured net weight of this piece, falling back to the product weight
+func (s *Stock) EffectiveWeight() float64 {
+	if s.NetWeight > 0 {
+		return s.NetWeight
+	}
+	return s.Product.Weight
+}
+
+// PieceStones returns the stones of this piece, falling back to the stones of the product design
+func (s *Stock) PieceStones() []StoneComponent {
+	if len(s.Stones) > 0 {
+		return s.Stones
+	}
+	return s.Product.Stones
+}
+
+// PhotoURL returns the thumbnail of this piece for receipts and the scan view,
+// falling back to the product photos and the legacy product image URL
+func (s *Stock) PhotoURL() string {
+	if image := PrimaryImage(s.Images); image != nil {
+		return image.ThumbnailURL
+	}
+	if image := PrimaryImage(s.Product.Images); image != nil {
+		return image.ThumbnailURL
+	}
+	return s.Product.ImageURL
+}
+
+// SellPrice returns the current sell price of this piece:
+// gold_category.sell_price * berat emas + nilai batu + ongkos pembuatan.
+// Logam mulia dijual per keping tanpa ongkos.
+func (s *Stock) SellPrice() PriceBreakdown {
+	if s.Product.IsBullion() {
+		return PriceBreakdown{GoldValue: s.Product.BullionSellPrice, Total: s.Product.BullionSellPrice}
+	}
+	price := PriceBreakdown{
+		GoldValue:    s.Product.GoldCategory.SellPrice * s.EffectiveWeight(),
+		StoneValue:   StonesValue(s.PieceStones()),
+		MakingCharge: s.Product.MakingCharge,
+	}
+	price.Total = price.GoldValue + price.StoneValue + price.MakingCharge
+	return price
+}
+
+// BuyPrice returns the current buyback price of this piece (gold only, per bar for bullion)
+func (s *Stock) BuyPrice() float64 {
+	if s.Product.IsBullion() {
+		return s.Product.BullionBuyPrice
+	}
+	return s.Product.GoldCategory.BuyPrice * s.EffectiveWeight()
+}
+
 // StockTransfer represents stock movement between locations
 type StockTransfer struct {
 	ID              uint           `gorm:"primarykey" json:"id"`
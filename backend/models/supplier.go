@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supplier represents a supplier/distributor of finished jewelry
+type Supplier struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	Code          string         `gorm:"not null;size:20" json:"code"` // unique index created manually in migration
+	Name          string         `gorm:"not null;size:100" json:"name"`
+	ContactPerson string         `gorm:"size:100" json:"contact_person"`
+	Phone         string         `gorm:"size:20" json:"phone"`
+	Email         string         `gorm:"size:100" json:"email"`
+	Address       string         `gorm:"size:255" json:"address"`
+	TaxNumber     string         `gorm:"size:30" json:"tax_number"` // NPWP
+	Notes         string         `gorm:"size:500" json:"notes"`
+	IsActive      bool           `gorm:"default:true" json:"is_active"`
+}
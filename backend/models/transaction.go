@@ -46,6 +46,12 @@ type Transaction struct {
 	Tax             float64 `gorm:"default:0" json:"tax"`
 	GrandTotal      float64 `gorm:"not null" json:"grand_total"`
 
+	// Diskon tier member (penjualan), dihitung dari aturan tier saat transaksi
+	MemberDiscount        float64 `gorm:"default:0" json:"member_discount"`
+	MemberDiscountPercent float64 `gorm:"default:0" json:"member_discount_percent"`
+	PointsRedeemed        int     `gorm:"default:0" json:"points_redeemed"` // Poin member yang ditukar
+	PointsDiscount        float64 `gorm:"default:0" json:"points_discount"` // Nilai rupiah poin yang ditukar
+
 	// Payment
 	PaymentMethod PaymentMethod `gorm:"not null;size:20" json:"payment_method"`
 	PaidAmount    float64       `gorm:"not null" json:"paid_amount"`
@@ -86,11 +92,38 @@ type TransactionItem struct {
 	Barcode      string  `gorm:"size:50" json:"barcode"`
 	Weight       float64 `gorm:"not null" json:"weight"` // Berat dalam gram
 	PricePerGram float64 `gorm:"not null" json:"price_per_gram"`
-	UnitPrice    float64 `gorm:"not null" json:"unit_price"` // Total price for this item
+	UnitPrice    float64 `gorm:"not null" json:"unit_price"`     // Total price for this item
+	GoldValue    float64 `gorm:"default:0" json:"gold_value"`    // Bagian harga dari emas
+	StoneValue   float64 `gorm:"default:0" json:"stone_value"`   // Bagian harga dari batu
+	MakingCharge float64 `gorm:"default:0" json:"making_charge"` // Ongkos pembuatan
 	Quantity     int     `gorm:"not null;default:1" json:"quantity"`
 	Discount     float64 `gorm:"default:0" json:"discount"`
 	SubTotal     float64 `gorm:"not null" json:"sub_total"`
 	Notes        string  `gorm:"size:255" json:"notes"`
+
+	// Asal berat (setor): diketik atau dari timbangan
+	WeightSource   WeightSource `gorm:"size:10;default:'typed'" json:"weight_source"`
+	ScaleReadingID *uint        `json:"scale_reading_id,omitempty"`
+	WeightGross    float64      `gorm:"default:0" json:"weight_gross,omitempty"` // Berat kotor yang ditimbang (sebelum susut)
+
+	// Logam mulia: sertifikat keping yang dijual / dibeli kembali
+	CertificateNumber string `gorm:"size:50;index" json:"certificate_number,omitempty"`
+	Manufacturer      string `gorm:"size:50" json:"manufacturer,omitempty"`
+	BuybackOfStockID  *uint  `gorm:"index" json:"buyback_of_stock_id,omitempty"` // Stok terjual yang dibeli kembali (setor)
+	RestockedStockID  *uint  `gorm:"index" json:"restocked_stock_id,omitempty"`  // Stok baru saat keping langsung dijual lagi (setor)
+
+	// Foto buah saat dijual, untuk struk
+	PhotoURL string `gorm:"size:500" json:"photo_url,omitempty"`
+
+	// Setor: harga beli acuan yang dihitung server saat transaksi, untuk audit
+	ReferencePricePerGram     float64 `gorm:"default:0" json:"reference_price_per_gram,omitempty"`
+	ConditionDeductionPercent float64 `gorm:"default:0" json:"condition_deduction_percent,omitempty"`
+	PriceDeviationPercent     float64 `gorm:"default:0" json:"price_deviation_percent,omitempty"` // Selisih harga per gram terhadap acuan
+	PriceApprovedByID         *uint   `json:"price_approved_by_id,omitempty"`                     // Supervisor yang menyetujui harga di luar toleransi
+	PriceApprovedBy           *User   `gorm:"foreignKey:PriceApprovedByID" json:"price_approved_by,omitempty"`
+
+	// Batu dari barang setor, dinilai terpisah dari emas
+	Stones []StoneComponent `gorm:"foreignKey:TransactionItemID" json:"stones,omitempty"`
 }
 
 // PurchaseItem represents items bought from customers (setor)
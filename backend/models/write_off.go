@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WriteOffReason defines why a stock piece is removed from inventory
+type WriteOffReason string
+
+const (
+	WriteOffReasonLost    WriteOffReason = "lost"    // Hilang
+	WriteOffReasonStolen  WriteOffReason = "stolen"  // Dicuri
+	WriteOffReasonDamaged WriteOffReason = "damaged" // Rusak
+	WriteOffReasonMelt    WriteOffReason = "melt"    // Dikirim untuk dilebur
+)
+
+// IsValid checks if the write-off reason is known
+func (r WriteOffReason) IsValid() bool {
+	switch r {
+	case WriteOffReasonLost, WriteOffReasonStolen, WriteOffReasonDamaged, WriteOffReasonMelt:
+		return true
+	}
+	return false
+}
+
+// CanConvertToRawMaterial reports whether the physical piece still exists and can become bahan baku
+func (r WriteOffReason) CanConvertToRawMaterial() bool {
+	return r == WriteOffReasonDamaged || r == WriteOffReasonMelt
+}
+
+// WriteOffStatus defines the approval status of a write-off
+type WriteOffStatus string
+
+const (
+	WriteOffStatusPending  WriteOffStatus = "pending"  // Menunggu persetujuan, stok ditahan
+	WriteOffStatusApproved WriteOffStatus = "approved" // Disetujui, stok dikeluarkan dari persediaan
+	WriteOffStatusRejected WriteOffStatus = "rejected" // Ditolak, stok dikembalikan
+)
+
+// StockWriteOff is the document that removes a stock piece from inventory (hilang, dicuri, rusak, lebur)
+type StockWriteOff struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+	WriteOffNumber string         `gorm:"not null;size:30" json:"write_off_number"` // unique index created manually in migration
+	StockID        uint           `gorm:"not null;index" json:"stock_id"`
+	Stock          Stock          `gorm:"foreignKey:StockID" json:"stock,omitempty"`
+	LocationID     uint           `gorm:"not null;index" json:"location_id"`
+	Location       Location       `gorm:"foreignKey:LocationID" json:"location,omitempty"`
+	Reason         WriteOffReason `gorm:"not null;size:20;index" json:"reason"`
+	Status         WriteOffStatus `gorm:"not null;size:20;default:'pending';index" json:"status"`
+	Description    string         `gorm:"size:500" json:"description"`
+	Photos         []string       `gorm:"type:json;serializer:json" json:"photos"` // URL foto bukti
+	PreviousStatus StockStatus    `gorm:"size:20" json:"previous_status"`          // Status stok sebelum ditahan
+
+	// Nilai barang saat write-off
+	Weight       float64 `gorm:"default:0" json:"weight"`        // Berat efektif (gram)
+	CostValue    float64 `gorm:"default:0" json:"cost_value"`    // Nilai modal
+	BuyValue     float64 `gorm:"default:0" json:"buy_value"`     // Nilai harga beli hari ini
+	CurrentValue float64 `gorm:"default:0" json:"current_value"` // Nilai harga jual hari ini
+
+	// Konversi ke bahan baku (untuk barang rusak/lebur)
+	ConvertToRawMaterial bool         `gorm:"default:false" json:"convert_to_raw_material"`
+	RawMaterialID        *uint        `json:"raw_material_id,omitempty"`
+	RawMaterial          *RawMaterial `gorm:"foreignKey:RawMaterialID" json:"raw_material,omitempty"`
+
+	RequestedByID uint       `gorm:"not null" json:"requested_by_id"`
+	RequestedBy   User       `gorm:"foreignKey:RequestedByID" json:"requested_by,omitempty"`
+	ApprovedByID  *uint      `json:"approved_by_id,omitempty"`
+	ApprovedBy    *User      `gorm:"foreignKey:ApprovedByID" json:"approved_by,omitempty"`
+	ApprovedAt    *time.Time `json:"approved_at,omitempty"`
+	RejectReason  string     `gorm:"size:255" json:"reject_reason,omitempty"`
+}
@@ -0,0 +1,31 @@
+package pricefeed
+
+import (
+	"context"
+	"os"
+)
+
+// FileProvider reads the reference price from a local JSON file, for offline use and tests
+type FileProvider struct {
+	path   string
+	fields Fields
+}
+
+// NewFileProvider creates a provider reading path on every fetch
+func NewFileProvider(path string, fields Fields) *FileProvider {
+	return &FileProvider{path: path, fields: fields}
+}
+
+// Fetch reads and parses the file
+func (p *FileProvider) Fetch(ctx context.Context) (Quote, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return Quote{}, err
+	}
+	quote, err := ParseQuote(data, p.fields)
+	if err != nil {
+		return Quote{}, err
+	}
+	quote.Source = p.path
+	return quote, nil
+}
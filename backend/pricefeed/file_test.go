@@ -0,0 +1,106 @@
+package pricefeed
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFeed(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "feed.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write feed: %v", err)
+	}
+	return path
+}
+
+func TestFileProviderFetch(t *testing.T) {
+	path := writeFeed(t, `{"data": {"buy": 1150000, "sell": "1200000.50"}}`)
+	provider := NewFileProvider(path, Fields{Buy: "data.buy", Sell: "data.sell"})
+
+	quote, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if quote.BuyPrice != 1150000 || quote.SellPrice != 1200000.50 {
+		t.Errorf("quote = %+v, want buy 1150000 and sell 1200000.50", quote)
+	}
+	if quote.Source != path {
+		t.Errorf("source = %q, want %q", quote.Source, path)
+	}
+	if quote.At.IsZero() {
+		t.Error("quote time is not set")
+	}
+}
+
+func TestFileProviderFetchErrors(t *testing.T) {
+	fields := Fields{Buy: "buy", Sell: "sell"}
+	tests := []struct {
+		name    string
+		content string
+		want    error
+	}{
+		{"missing field", `{"buy": 1150000}`, ErrFieldMissing},
+		{"object as price", `{"buy": 1150000, "sell": {"price": 1}}`, ErrInvalidPrice},
+		{"zero price", `{"buy": 0, "sell": 1200000}`, ErrInvalidPrice},
+		{"negative price", `{"buy": -1, "sell": 1200000}`, ErrInvalidPrice},
+		{"text price", `{"buy": "n/a", "sell": 1200000}`, ErrInvalidPrice},
+		{"separator not supported", `{"buy": "1.150.000", "sell": 1200000}`, ErrInvalidPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFileProvider(writeFeed(t, tt.content), fields)
+			if _, err := provider.Fetch(context.Background()); !errors.Is(err, tt.want) {
+				t.Errorf("Fetch error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProviderRejectsBadDocuments(t *testing.T) {
+	fields := Fields{Buy: "buy", Sell: "sell"}
+	for name, content := range map[string]string{
+		"invalid json":     `{"buy": `,
+		"buy above sell":   `{"buy": 1250000, "sell": 1200000}`,
+		"not an object":    `[1150000, 1200000]`,
+		"nested not found": `{"data": 1}`,
+	} {
+		provider := NewFileProvider(writeFeed(t, content), fields)
+		if _, err := provider.Fetch(context.Background()); err == nil {
+			t.Errorf("%s: Fetch succeeded, want an error", name)
+		}
+	}
+
+	missing := NewFileProvider(filepath.Join(t.TempDir(), "missing.json"), fields)
+	if _, err := missing.Fetch(context.Background()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file error = %v, want not exist", err)
+	}
+}
+
+func TestNewDefaultsFields(t *testing.T) {
+	path := writeFeed(t, `{"buy": 1150000, "sell": 1200000}`)
+	provider, err := New("file", path, Fields{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	quote, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if quote.BuyPrice != 1150000 || quote.SellPrice != 1200000 {
+		t.Errorf("quote = %+v, want buy 1150000 and sell 1200000", quote)
+	}
+
+	if provider, err := New("none", "", Fields{}); err != nil || provider != nil {
+		t.Errorf("New(none) = %v, %v, want no provider", provider, err)
+	}
+	if _, err := New("file", "", Fields{}); err == nil {
+		t.Error("New(file) without a path succeeded")
+	}
+	if _, err := New("ftp", path, Fields{}); err == nil {
+		t.Error("New(ftp) succeeded")
+	}
+}
@@ -0,0 +1,50 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPProvider reads the reference price from a JSON endpoint
+type HTTPProvider struct {
+	url    string
+	fields Fields
+	client *http.Client
+}
+
+// NewHTTPProvider creates a provider polling url
+func NewHTTPProvider(url string, fields Fields) *HTTPProvider {
+	return &HTTPProvider{url: url, fields: fields, client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Fetch requests the endpoint and parses the quote
+func (p *HTTPProvider) Fetch(ctx context.Context) (Quote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return Quote{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Quote{}, fmt.Errorf("price feed returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Quote{}, err
+	}
+	quote, err := ParseQuote(data, p.fields)
+	if err != nil {
+		return Quote{}, err
+	}
+	quote.Source = p.url
+	return quote, nil
+}
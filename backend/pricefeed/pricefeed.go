@@ -0,0 +1,130 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quote is a 24K (999) reference price per gram reported by a feed
+type Quote struct {
+	BuyPrice  float64   `json:"buy_price"`
+	SellPrice float64   `json:"sell_price"`
+	Source    string    `json:"source"` // URL atau path file
+	At        time.Time `json:"at"`
+}
+
+// Provider fetches the current reference price
+type Provider interface {
+	Fetch(ctx context.Context) (Quote, error)
+}
+
+var (
+	ErrFieldMissing = errors.New("price field not found in feed")
+	ErrInvalidPrice = errors.New("feed price is not a positive number")
+)
+
+// Fields tells where the prices are in the JSON document, as dotted paths (contoh: data.buy)
+type Fields struct {
+	Buy  string
+	Sell string
+}
+
+// ParseQuote reads the buy and sell price from a JSON document. Prices may be numbers
+// or numeric strings ("1.234.000" style thousand separators are not supported).
+func ParseQuote(data []byte, fields Fields) (Quote, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Quote{}, fmt.Errorf("invalid feed JSON: %w", err)
+	}
+	buy, err := lookupPrice(doc, fields.Buy)
+	if err != nil {
+		return Quote{}, err
+	}
+	sell, err := lookupPrice(doc, fields.Sell)
+	if err != nil {
+		return Quote{}, err
+	}
+	if buy > sell {
+		return Quote{}, fmt.Errorf("feed buy price %.2f exceeds sell price %.2f", buy, sell)
+	}
+	return Quote{BuyPrice: buy, SellPrice: sell, At: time.Now()}, nil
+}
+
+func lookupPrice(doc interface{}, path string) (float64, error) {
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrFieldMissing, path)
+		}
+		if value, ok = object[key]; !ok {
+			return 0, fmt.Errorf("%w: %s", ErrFieldMissing, path)
+		}
+	}
+
+	var price float64
+	switch v := value.(type) {
+	case float64:
+		price = v
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidPrice, path)
+		}
+		price = parsed
+	default:
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPrice, path)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPrice, path)
+	}
+	return price, nil
+}
+
+// Policy decides whether prices derived from a feed may be applied without review
+type Policy struct {
+	AutoApply        bool    // Terapkan otomatis jika perubahan di bawah batas
+	MaxChangePercent float64 // Perubahan di atas batas ini selalu menunggu persetujuan
+}
+
+// RequiresApproval reports whether a proposal whose largest price move is changePercent
+// must wait for approval, and why
+func (p Policy) RequiresApproval(changePercent float64) (bool, string) {
+	if !p.AutoApply {
+		return true, "Auto-apply is off"
+	}
+	if changePercent > p.MaxChangePercent {
+		return true, fmt.Sprintf("Price moves %.2f%%, above the %.2f%% limit", changePercent, p.MaxChangePercent)
+	}
+	return false, ""
+}
+
+// New returns the provider configured by name ("http" or "file"). source is the URL or file path.
+func New(driver, source string, fields Fields) (Provider, error) {
+	if fields.Buy == "" {
+		fields.Buy = "buy"
+	}
+	if fields.Sell == "" {
+		fields.Sell = "sell"
+	}
+	switch driver {
+	case "http":
+		if source == "" {
+			return nil, errors.New("price feed URL is required for the http provider")
+		}
+		return NewHTTPProvider(source, fields), nil
+	case "file":
+		if source == "" {
+			return nil, errors.New("price feed file is required for the file provider")
+		}
+		return NewFileProvider(source, fields), nil
+	case "", "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown price feed provider: %s", driver)
+}
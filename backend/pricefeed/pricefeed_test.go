@@ -0,0 +1,32 @@
+package pricefeed
+
+import "testing"
+
+func TestPolicyRequiresApproval(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		change float64
+		want   bool
+	}{
+		{"small move is applied", Policy{AutoApply: true, MaxChangePercent: 2}, 1.5, false},
+		{"move at the limit is applied", Policy{AutoApply: true, MaxChangePercent: 2}, 2, false},
+		{"large move waits", Policy{AutoApply: true, MaxChangePercent: 2}, 7.5, true},
+		{"first price waits", Policy{AutoApply: true, MaxChangePercent: 2}, 100, true},
+		{"auto-apply off waits", Policy{AutoApply: false, MaxChangePercent: 2}, 0.1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.policy.RequiresApproval(tt.change)
+			if got != tt.want {
+				t.Errorf("RequiresApproval(%v) = %v, want %v", tt.change, got, tt.want)
+			}
+			if got && reason == "" {
+				t.Error("approval required without a reason")
+			}
+			if !got && reason != "" {
+				t.Errorf("reason = %q for an applied move", reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reading is a single weight reported by a scale, always normalised to grams
+type Reading struct {
+	Weight float64   `json:"weight"`
+	Unit   string    `json:"unit"`   // Satuan asli dari timbangan (g, ct, oz, ...)
+	Stable bool      `json:"stable"` // Timbangan melaporkan berat sudah stabil
+	Raw    string    `json:"raw"`    // Baris mentah dari timbangan
+	At     time.Time `json:"at"`
+}
+
+// Driver reads weights from a scale
+type Driver interface {
+	// Read blocks until the scale reports a stable reading or ctx is done
+	Read(ctx context.Context) (Reading, error)
+	Close() error
+}
+
+var (
+	ErrUnstable   = errors.New("scale reading is not stable")
+	ErrOverload   = errors.New("scale is overloaded")
+	ErrNoWeight   = errors.New("no weight found in scale output")
+	ErrNegative   = errors.New("scale reading is negative")
+	ErrUnknownUOM = errors.New("unknown scale unit")
+)
+
+// gramsPerUnit converts the units jewelry scales commonly report into grams
+var gramsPerUnit = map[string]float64{
+	"g":    1,
+	"gr":   1,
+	"gram": 1,
+	"ct":   0.2,
+	"kg":   1000,
+	"mg":   0.001,
+	"oz":   28.349523125,
+	"ozt":  31.1034768,
+	"dwt":  1.55517384,
+	"tl":   37.429, // Tael (Hong Kong)
+	"mom":  3.75,   // Momme
+}
+
+var weightPattern = regexp.MustCompile(`([+-]?)\s*(\d+(?:[.,]\d+)?)\s*([a-zA-Z]*)`)
+
+// ParseLine parses one line of the text protocols spoken by common jewelry scales:
+//
+//	ST,GS,+  12.345 g     (A&D, Ohaus, Vibra - ST stable, US unstable, OL overload)
+//	S S     12.345 g      (Mettler Toledo MT-SICS - S S stable, S D dynamic)
+//	+  12.345 g           (simple continuous output, "?" marks unstable)
+func ParseLine(line string) (Reading, error) {
+	raw := strings.TrimRight(line, "\r\n")
+	text := strings.TrimSpace(raw)
+	reading := Reading{Raw: raw, Stable: true, At: time.Now()}
+
+	if text == "" {
+		return reading, ErrNoWeight
+	}
+
+	upper := strings.ToUpper(text)
+	switch {
+	case strings.HasPrefix(upper, "OL") || strings.HasPrefix(upper, "S +") || strings.HasPrefix(upper, "S -"):
+		return reading, ErrOverload
+	case strings.HasPrefix(upper, "US") || strings.HasPrefix(upper, "S D"):
+		reading.Stable = false
+	case strings.HasPrefix(upper, "S S") || strings.HasPrefix(upper, "ST"):
+		reading.Stable = true
+	}
+	if strings.Contains(text, "?") {
+		reading.Stable = false
+	}
+
+	// Header A&D/Ohaus: "ST,GS," atau "US,NT," - buang field huruf di depan
+	for {
+		idx := strings.IndexByte(text, ',')
+		if idx <= 0 || !isAlpha(text[:idx]) {
+			break
+		}
+		text = text[idx+1:]
+	}
+	// Header MT-SICS: "S S" / "S D"
+	if strings.HasPrefix(upper, "S S") || strings.HasPrefix(upper, "S D") {
+		text = text[3:]
+	}
+
+	match := weightPattern.FindStringSubmatch(text)
+	if match == nil {
+		return reading, ErrNoWeight
+	}
+
+	value, err := strconv.ParseFloat(strings.Replace(match[2], ",", ".", 1), 64)
+	if err != nil {
+		return reading, ErrNoWeight
+	}
+	if match[1] == "-" && value != 0 {
+		return reading, ErrNegative
+	}
+
+	unit := strings.ToLower(match[3])
+	if unit == "" {
+		unit = "g"
+	}
+	factor, ok := gramsPerUnit[unit]
+	if !ok {
+		return reading, fmt.Errorf("%w: %s", ErrUnknownUOM, match[3])
+	}
+
+	reading.Unit = unit
+	reading.Weight = value * factor
+	return reading, nil
+}
+
+func isAlpha(s string) bool {
+	for _, r := range strings.TrimSpace(s) {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// New returns the driver configured by name ("serial" or "simulated").
+// requestCommand may use the escapes \r and \n, as it usually comes from an env variable.
+func New(driver, device, requestCommand string) (Driver, error) {
+	switch driver {
+	case "serial":
+		if device == "" {
+			return nil, errors.New("scale device is required for the serial driver")
+		}
+		command := strings.NewReplacer(`\r`, "\r", `\n`, "\n").Replace(requestCommand)
+		return NewSerialDriver(device, command), nil
+	case "simulated":
+		return NewSimulatedDriver(), nil
+	case "", "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown scale driver: %s", driver)
+}
@@ -0,0 +1,85 @@
+package scale
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		weight float64
+		unit   string
+		stable bool
+		err    error
+	}{
+		{name: "A&D stable", line: "ST,GS,+  12.345 g\r\n", weight: 12.345, unit: "g", stable: true},
+		{name: "A&D unstable", line: "US,GS,+  12.340 g", weight: 12.34, unit: "g", stable: false},
+		{name: "A&D overload", line: "OL,GS,+9999999 g", err: ErrOverload},
+		{name: "MT-SICS stable", line: "S S     12.345 g", weight: 12.345, unit: "g", stable: true},
+		{name: "MT-SICS dynamic", line: "S D     12.300 g", weight: 12.3, unit: "g", stable: false},
+		{name: "MT-SICS overload", line: "S +", err: ErrOverload},
+		{name: "continuous", line: "+  5.000 g", weight: 5, unit: "g", stable: true},
+		{name: "question mark unstable", line: "?  5.000 g", weight: 5, unit: "g", stable: false},
+		{name: "no unit is gram", line: "  7.5", weight: 7.5, unit: "g", stable: true},
+		{name: "decimal comma", line: "ST,GS,+  3,250 g", weight: 3.25, unit: "g", stable: true},
+		{name: "carat", line: "ST,GS,+  10.000 ct", weight: 2, unit: "ct", stable: true},
+		{name: "troy ounce", line: "ST,GS,+  1.000 ozt", weight: 31.1034768, unit: "ozt", stable: true},
+		{name: "zero with minus sign", line: "ST,GS,-  0.000 g", weight: 0, unit: "g", stable: true},
+		{name: "negative", line: "ST,GS,-  1.250 g", err: ErrNegative},
+		{name: "unknown unit", line: "ST,GS,+  1.000 lb", err: ErrUnknownUOM},
+		{name: "empty", line: "\r\n", err: ErrNoWeight},
+		{name: "garbage", line: "ST,GS,hello", err: ErrNoWeight},
+		{name: "only letters", line: "ABC", err: ErrNoWeight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading, err := ParseLine(tt.line)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ParseLine(%q) error = %v, want %v", tt.line, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLine(%q) unexpected error: %v", tt.line, err)
+			}
+			if math.Abs(reading.Weight-tt.weight) > 1e-9 {
+				t.Errorf("weight = %v, want %v", reading.Weight, tt.weight)
+			}
+			if reading.Unit != tt.unit {
+				t.Errorf("unit = %q, want %q", reading.Unit, tt.unit)
+			}
+			if reading.Stable != tt.stable {
+				t.Errorf("stable = %v, want %v", reading.Stable, tt.stable)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	if driver, err := New("", "", ""); err != nil || driver != nil {
+		t.Fatalf("New(\"\") = %v, %v; want no driver", driver, err)
+	}
+	if driver, err := New("simulated", "", ""); err != nil {
+		t.Fatalf("New(simulated) error: %v", err)
+	} else if _, ok := driver.(*SimulatedDriver); !ok {
+		t.Fatalf("New(simulated) = %T, want *SimulatedDriver", driver)
+	}
+	if _, err := New("serial", "", ""); err == nil {
+		t.Fatal("New(serial) without device should fail")
+	}
+	driver, err := New("serial", "/dev/ttyUSB0", `Q\r\n`)
+	if err != nil {
+		t.Fatalf("New(serial) error: %v", err)
+	}
+	if command := driver.(*SerialDriver).RequestCommand; command != "Q\r\n" {
+		t.Errorf("request command = %q, want escapes replaced", command)
+	}
+	if _, err := New("bluetooth", "", ""); err == nil {
+		t.Fatal("New(bluetooth) should fail")
+	}
+}
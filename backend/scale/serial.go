@@ -0,0 +1,179 @@
+package scale
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// SerialDriver reads a scale attached to a serial or USB-serial device (e.g. /dev/ttyUSB0, COM3).
+// Port settings (baud rate, parity) are taken from the OS, configure them with
+// `stty -F /dev/ttyUSB0 9600 cs8 -cstopb -parenb` or the Windows device manager.
+type SerialDriver struct {
+	Device string
+	// RequestCommand is written before each read for scales that only print on request
+	// (e.g. "Q\r\n" for A&D, "SI\r\n" for MT-SICS). Empty for scales that stream continuously.
+	RequestCommand string
+	// StableCount is how many consecutive identical stable lines are needed before a reading is accepted
+	StableCount int
+
+	mu    sync.Mutex
+	file  *os.File
+	lines chan lineResult
+	done  chan struct{} // Ditutup saat port ditutup agar goroutine pembaca berhenti
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+// NewSerialDriver creates a driver for the given device path
+func NewSerialDriver(device, requestCommand string) *SerialDriver {
+	return &SerialDriver{Device: device, RequestCommand: requestCommand, StableCount: 2}
+}
+
+func (d *SerialDriver) open() error {
+	if d.file != nil {
+		return nil
+	}
+	file, err := os.OpenFile(d.Device, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	d.file = file
+	d.lines = make(chan lineResult, 16)
+	d.done = make(chan struct{})
+
+	go func(lines chan<- lineResult, done <-chan struct{}) {
+		defer close(lines)
+		send := func(result lineResult) bool {
+			select {
+			case lines <- result:
+				return true
+			case <-done:
+				return false
+			}
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if !send(lineResult{line: scanner.Text()}) {
+				return
+			}
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = errors.New("scale device closed")
+		}
+		send(lineResult{err: err})
+	}(d.lines, d.done)
+	return nil
+}
+
+// Read waits for StableCount consecutive stable lines with the same weight
+func (d *SerialDriver) Read(ctx context.Context) (Reading, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.open(); err != nil {
+		return Reading{}, err
+	}
+
+	required := d.StableCount
+	if required < 1 {
+		required = 1
+	}
+
+	// Buang baris lama yang tertumpuk sejak pembacaan sebelumnya
+	d.drain()
+
+	var last Reading
+	matched := 0
+	for {
+		if d.RequestCommand != "" {
+			if _, err := d.file.WriteString(d.RequestCommand); err != nil {
+				d.reset()
+				return Reading{}, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return Reading{}, ctx.Err()
+		case result, ok := <-d.lines:
+			if !ok || result.err != nil {
+				d.reset()
+				if result.err != nil {
+					return Reading{}, result.err
+				}
+				return Reading{}, errors.New("scale device closed")
+			}
+
+			reading, err := ParseLine(result.line)
+			if err == ErrNoWeight {
+				continue
+			}
+			if err != nil {
+				return Reading{}, err
+			}
+			if !reading.Stable {
+				matched = 0
+				continue
+			}
+			if matched > 0 && reading.Weight == last.Weight {
+				matched++
+			} else {
+				matched = 1
+			}
+			last = reading
+			if matched >= required {
+				return last, nil
+			}
+		}
+
+		if d.RequestCommand != "" {
+			// Beri jeda agar timbangan tidak dibanjiri perintah
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+func (d *SerialDriver) drain() {
+	for {
+		select {
+		case result, ok := <-d.lines:
+			if !ok || result.err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (d *SerialDriver) reset() {
+	d.closePort()
+}
+
+// closePort stops the reader goroutine and closes the device
+func (d *SerialDriver) closePort() error {
+	if d.file == nil {
+		return nil
+	}
+	close(d.done)
+	err := d.file.Close()
+	d.file = nil
+	d.lines = nil
+	d.done = nil
+	return err
+}
+
+// Close releases the serial device
+func (d *SerialDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.closePort()
+}
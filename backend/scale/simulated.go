@@ -0,0 +1,68 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SimulatedDriver is an in-memory scale for development and automated tests.
+// Weights placed with Put are returned in order; Read waits until one is available.
+type SimulatedDriver struct {
+	mu      sync.Mutex
+	pending []Reading
+	notify  chan struct{}
+}
+
+// NewSimulatedDriver creates an empty simulated scale
+func NewSimulatedDriver() *SimulatedDriver {
+	return &SimulatedDriver{notify: make(chan struct{}, 1)}
+}
+
+// Put places a stable weight (gram) on the simulated scale
+func (d *SimulatedDriver) Put(weight float64) {
+	d.PutLine(fmt.Sprintf("ST,GS,+%10.3f g", weight))
+}
+
+// PutLine feeds a raw protocol line, as a real scale would send it
+func (d *SimulatedDriver) PutLine(line string) {
+	reading, err := ParseLine(line)
+	if err != nil || !reading.Stable {
+		return
+	}
+	d.mu.Lock()
+	d.pending = append(d.pending, reading)
+	d.mu.Unlock()
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Read returns the next weight put on the scale
+func (d *SimulatedDriver) Read(ctx context.Context) (Reading, error) {
+	for {
+		d.mu.Lock()
+		if len(d.pending) > 0 {
+			reading := d.pending[0]
+			d.pending = d.pending[1:]
+			d.mu.Unlock()
+			reading.At = time.Now()
+			return reading, nil
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Reading{}, ctx.Err()
+		case <-d.notify:
+		}
+	}
+}
+
+// Close is a no-op for the simulated scale
+func (d *SimulatedDriver) Close() error {
+	return nil
+}
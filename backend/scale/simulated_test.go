@@ -0,0 +1,69 @@
+package scale
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSimulatedDriverReadsInOrder(t *testing.T) {
+	driver := NewSimulatedDriver()
+	defer driver.Close()
+
+	driver.Put(12.345)
+	driver.PutLine("ST,GS,+  10.000 ct")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	first, err := driver.Read(ctx)
+	if err != nil {
+		t.Fatalf("first read: %v", err)
+	}
+	if first.Weight != 12.345 || !first.Stable {
+		t.Errorf("first reading = %+v, want stable 12.345 g", first)
+	}
+	second, err := driver.Read(ctx)
+	if err != nil {
+		t.Fatalf("second read: %v", err)
+	}
+	if second.Weight != 2 || second.Unit != "ct" {
+		t.Errorf("second reading = %+v, want 2 g from 10 ct", second)
+	}
+}
+
+func TestSimulatedDriverIgnoresUnstableAndInvalidLines(t *testing.T) {
+	driver := NewSimulatedDriver()
+	driver.PutLine("US,GS,+  12.000 g")
+	driver.PutLine("OL,GS,+9999999 g")
+	driver.PutLine("garbage")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := driver.Read(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Read error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestSimulatedDriverReadWaitsForWeight(t *testing.T) {
+	driver := NewSimulatedDriver()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		driver.Put(5.5)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reading, err := driver.Read(ctx)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if reading.Weight != 5.5 {
+		t.Errorf("weight = %v, want 5.5", reading.Weight)
+	}
+	if reading.At.IsZero() {
+		t.Error("reading time is not set")
+	}
+}
@@ -0,0 +1,150 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Column is one column of the item table. Widths are in millimetres.
+type Column struct {
+	Title   string
+	WidthMM float64
+	Right   bool // Rata kanan (angka)
+}
+
+// Entry is one transaction on the statement: a heading line, its item rows and a footer line
+type Entry struct {
+	Heading string
+	Rows    [][]string // Satu baris per item, urut sesuai Columns
+	Footer  string
+}
+
+// Document is a printable history handed to a customer
+type Document struct {
+	Title   string
+	Info    []string // Baris identitas di bawah judul (member, periode)
+	Columns []Column
+	Entries []Entry
+	Summary []string // Baris ringkasan di akhir dokumen
+}
+
+// RenderPDF renders the document on A4 portrait pages, repeating the column header on every page
+func RenderPDF(d Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", 8)
+		for _, column := range d.Columns {
+			align := "L"
+			if column.Right {
+				align = "R"
+			}
+			pdf.CellFormat(column.WidthMM, 6, tr(column.Title), "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetHeaderFunc(func() {
+		if pdf.PageNo() > 1 {
+			header()
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Halaman %d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, tr(d.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range d.Info {
+		pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+	header()
+
+	for _, entry := range d.Entries {
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.CellFormat(0, 6, tr(entry.Heading), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 8)
+		for _, row := range entry.Rows {
+			for i, column := range d.Columns {
+				value := ""
+				if i < len(row) {
+					value = row[i]
+				}
+				align := "L"
+				if column.Right {
+					align = "R"
+				}
+				pdf.CellFormat(column.WidthMM, 5, tr(fit(pdf, value, column.WidthMM-1)), "", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		if entry.Footer != "" {
+			pdf.SetFont("Helvetica", "I", 8)
+			pdf.CellFormat(0, 5, tr(entry.Footer), "B", 1, "R", false, 0, "")
+		}
+	}
+
+	if len(d.Entries) == 0 {
+		pdf.SetFont("Helvetica", "I", 9)
+		pdf.CellFormat(0, 8, "Tidak ada transaksi pada periode ini", "", 1, "L", false, 0, "")
+	}
+
+	if len(d.Summary) > 0 {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", 9)
+		for _, line := range d.Summary {
+			pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// fit shortens text with an ellipsis so it stays inside a cell of the given width
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// Rupiah formats an amount as "Rp 1.234.567" (dibulatkan ke rupiah terdekat)
+func Rupiah(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := fmt.Sprintf("%.0f", math.Round(amount))
+	var groups []string
+	for len(digits) > 3 {
+		groups = append([]string{digits[len(digits)-3:]}, groups...)
+		digits = digits[:len(digits)-3]
+	}
+	groups = append([]string{digits}, groups...)
+	return sign + "Rp " + strings.Join(groups, ".")
+}
+
+// Grams formats a weight as "12,345 g"
+func Grams(weight float64) string {
+	return strings.Replace(fmt.Sprintf("%.3f g", weight), ".", ",", 1)
+}